@@ -0,0 +1,17 @@
+package base
+
+import (
+	"errors"
+	"net/http"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// KubeErrorStatus 返回 Kubernetes API 错误对应的 HTTP 状态码（例如 404、409、422），其它错误返回 500
+func KubeErrorStatus(err error) int {
+	var status apierrors.APIStatus
+	if errors.As(err, &status) && status.Status().Code != 0 {
+		return int(status.Status().Code)
+	}
+	return http.StatusInternalServerError
+}
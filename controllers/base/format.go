@@ -0,0 +1,94 @@
+package base
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/common"
+	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/yaml"
+)
+
+// MIMEYAML 返回 YAML 时使用的 Content-Type
+const MIMEYAML = "application/yaml"
+
+// 识别为 YAML 的 Content-Type / Accept
+var yamlMediaTypes = map[string]bool{
+	"application/yaml":   true,
+	"application/x-yaml": true,
+	"text/yaml":          true,
+	"text/x-yaml":        true,
+}
+
+// IsYAMLRequest 判断请求体是否为 YAML
+func IsYAMLRequest(c *gin.Context) bool {
+	return yamlMediaTypes[c.ContentType()]
+}
+
+// WantsYAML 判断是否需要返回 YAML，支持 Accept: application/yaml 和 ?format=yaml
+func WantsYAML(c *gin.Context) bool {
+	if format := c.Query("format"); format != "" {
+		return format == "yaml"
+	}
+	for _, accept := range strings.Split(c.GetHeader("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err == nil && yamlMediaTypes[mediaType] {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadBodyJSON 读取请求体，YAML 请求体转换为 JSON 返回
+func ReadBodyJSON(c *gin.Context) ([]byte, error) {
+	data, err := c.GetRawData()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("request body is empty")
+	}
+	if IsYAMLRequest(c) {
+		if data, err = yaml.YAMLToJSON(data); err != nil {
+			return nil, fmt.Errorf("invalid yaml: %v", err)
+		}
+	}
+	return data, nil
+}
+
+// BindObject 将 JSON 或 YAML 请求体解析到 obj
+func BindObject(c *gin.Context, obj interface{}) error {
+	data, err := ReadBodyJSON(c)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, obj)
+}
+
+// RenderObject 返回资源对象。
+// ?export=true 时去掉 status、managedFields 等由服务端维护的字段；需要 YAML 时直接返回对象的 YAML，否则返回 {"data": obj}。
+func RenderObject(c *gin.Context, status int, obj interface{}) {
+	if export, _ := strconv.ParseBool(c.Query("export")); export {
+		exported, err := common.ExportObject(obj)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		obj = exported
+	}
+
+	if WantsYAML(c) {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Data(status, MIMEYAML, data)
+		return
+	}
+	c.JSON(status, gin.H{"data": obj})
+}
@@ -0,0 +1,21 @@
+package base
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ParseDryRun 解析 dryRun 参数，与 Kubernetes 一致只支持 All，未指定时返回 nil
+func ParseDryRun(c *gin.Context) ([]string, error) {
+	dryRun := c.Query("dryRun")
+	switch dryRun {
+	case "":
+		return nil, nil
+	case metav1.DryRunAll:
+		return []string{metav1.DryRunAll}, nil
+	default:
+		return nil, fmt.Errorf("invalid dryRun %q, only %s is supported", dryRun, metav1.DryRunAll)
+	}
+}
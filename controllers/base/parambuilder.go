@@ -1,9 +1,12 @@
 package base
 
 import (
+	"errors"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/dataselector"
 	"github.com/JLPAY/gwayne/pkg/pagequery"
 	"github.com/JLPAY/gwayne/pkg/snaker"
 	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/klog/v2"
 	"net/http"
 	"strconv"
@@ -27,44 +30,103 @@ func BuildQueryParam(ctx *gin.Context) *pagequery.QueryParam {
 	}
 
 	// 处理 "filter" 参数，允许通过多个键值对（逗号分隔）进行查询
-	filter := ctx.DefaultQuery("filter", "")
-	if filter != "" {
-		filters := strings.Split(filter, ",")
-		for _, param := range filters {
-			params := strings.Split(param, "=")
-			if len(params) != 2 {
-				// 忽略无效的过滤条件
-				continue
-			}
-			key, value := params[0], params[1]
-			// 兼容在filter中使用deleted参数
-			if key == "deleted" {
-				deleted, err := strconv.ParseBool(value)
-				if err != nil {
-					continue
-				}
-				qmap[key] = deleted
-				continue
-			}
-			qmap[params[0]] = params[1]
+	// 键支持 name__eq、name__in 等操作符形式，多个 filter 参数之间为 OR 关系
+	filters := ctx.QueryArray("filter")
+	var orQuery []map[string]interface{}
+	for i, filter := range filters {
+		if filter == "" {
+			continue
 		}
+		group := qmap
+		if i > 0 {
+			group = map[string]interface{}{}
+			orQuery = append(orQuery, group)
+		}
+		parseFilter(filter, group)
 	}
+	filter := strings.Join(filters, "|")
 
 	relate := ctx.DefaultQuery("relate", "")
 
-	// 处理 "sortby" 参数，将 CamelCase 转换为 snake_case 格式
+	// 处理 "sortby" 参数，支持多个字段（逗号分隔）和 "-" 前缀表示降序，例如 -creationTimestamp,name
 	//sortby := snaker.CamelToSnake(ctx.DefaultQuery("sortby", ""))
-	sortby := snaker.SnakeToCamelLower(ctx.DefaultQuery("sortby", ""))
+	sortby := buildSortby(ctx.DefaultQuery("sortby", ""))
+
+	// 处理 "fields" 参数，列投影，多个字段用逗号分隔
+	var fields []string
+	if fieldsStr := ctx.DefaultQuery("fields", ""); fieldsStr != "" {
+		fields = strings.Split(fieldsStr, ",")
+	}
+
+	// 处理 "continue" 参数，带有该参数（包括空值）时使用游标分页
+	continueToken, useContinue := ctx.GetQuery("continue")
 
 	klog.V(3).Infof("分布参数filter: %s,relate: %s, sortby: %s", filter, relate, sortby)
 
 	return &pagequery.QueryParam{
-		PageNo:   no,     // 当前页码
-		PageSize: size,   // 每页大小
-		Query:    qmap,   // 查询条件
-		Sortby:   sortby, // 排序字段（已转换为 snake_case）
-		Relate:   relate, // 关联查询参数
+		PageNo:      no,            // 当前页码
+		PageSize:    size,          // 每页大小
+		Query:       qmap,          // 查询条件
+		OrQuery:     orQuery,       // 额外的查询条件组（OR）
+		Sortby:      sortby,        // 排序字段（已转换为 camelCase）
+		Relate:      relate,        // 关联查询参数
+		Fields:      fields,        // 列投影字段
+		UseContinue: useContinue,   // 是否使用游标分页
+		Continue:    continueToken, // 游标分页的 continue 令牌
+	}
+}
+
+// 将 sortby 中的每个字段由 snake_case 转换为 camelCase 格式，保留排序方向，jsonpath 属性保持原样
+func buildSortby(sortby string) string {
+	if sortby == "" {
+		return ""
+	}
+	keys := strings.Split(sortby, dataselector.ListSortKeySep)
+	for i, key := range keys {
+		key = strings.TrimSpace(key)
+		prefix := ""
+		if strings.HasPrefix(key, "-") || strings.HasPrefix(key, "+") {
+			prefix, key = key[:1], key[1:]
+		}
+		if !strings.HasPrefix(key, dataselector.JSONPathPropertyPrefix) {
+			key = snaker.SnakeToCamelLower(key)
+		}
+		keys[i] = prefix + key
+	}
+	return strings.Join(keys, dataselector.ListSortKeySep)
+}
+
+// 解析单个 filter 参数，结果写入 qmap
+func parseFilter(filter string, qmap map[string]interface{}) {
+	for _, param := range strings.Split(filter, ",") {
+		params := strings.SplitN(param, "=", 2)
+		if len(params) != 2 {
+			// 忽略无效的过滤条件
+			continue
+		}
+		key, value := params[0], params[1]
+		// 兼容在filter中使用deleted参数
+		if key == "deleted" {
+			deleted, err := strconv.ParseBool(value)
+			if err != nil {
+				continue
+			}
+			qmap[key] = deleted
+			continue
+		}
+		qmap[key] = value
+	}
+}
+
+// QueryErrorStatus 根据查询错误返回 HTTP 状态码，查询参数错误返回 400，continue 令牌过期返回 410，其它错误返回 500
+func QueryErrorStatus(err error) int {
+	if errors.Is(err, pagequery.ErrInvalidQuery) || errors.Is(err, pagequery.ErrInvalidContinue) {
+		return http.StatusBadRequest
+	}
+	if apierrors.IsResourceExpired(err) {
+		return http.StatusGone
 	}
+	return http.StatusInternalServerError
 }
 
 func buildPageParam(ctx *gin.Context) (no int64, size int64) {
@@ -94,7 +94,7 @@ func List(c *gin.Context) {
 	err := models.GetAll(new(models.Cluster), &clusters, param)
 	if err != nil {
 		klog.Errorf("list by param (%v) error. %v", param, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to get total count"})
+		c.JSON(base.QueryErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
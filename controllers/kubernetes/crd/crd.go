@@ -32,7 +32,7 @@ func List(c *gin.Context) {
 	result, err := crd.GetCRDPage(manager.CrdClient, param)
 	if err != nil {
 		klog.Errorf("list cluster %s error: %v", cluster, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(base.QueryErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": result})
@@ -59,7 +59,7 @@ func Get(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": result})
+	base.RenderObject(c, http.StatusOK, result)
 }
 
 // @Title Create
@@ -69,7 +69,7 @@ func Create(c *gin.Context) {
 	cluster := c.Param("cluster")
 
 	var tpl apiextensions.CustomResourceDefinition
-	err := c.ShouldBindJSON(&tpl)
+	err := base.BindObject(c, &tpl)
 	if err != nil {
 		klog.Errorf("create cluster %s error: %v", cluster, err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -90,7 +90,7 @@ func Create(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": result})
+	base.RenderObject(c, http.StatusOK, result)
 }
 
 // @Title Update
@@ -100,7 +100,7 @@ func Update(c *gin.Context) {
 	cluster := c.Param("cluster")
 	name := c.Param("name")
 	var tpl apiextensions.CustomResourceDefinition
-	err := c.BindJSON(&tpl)
+	err := base.BindObject(c, &tpl)
 	if err != nil {
 		klog.Errorf("update crd bind crd %s error: %v", name, err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -120,7 +120,7 @@ func Update(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": result})
+	base.RenderObject(c, http.StatusOK, result)
 }
 
 // @Title Delete
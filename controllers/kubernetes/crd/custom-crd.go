@@ -1,6 +1,7 @@
 package crd
 
 import (
+	"bytes"
 	"net/http"
 
 	"github.com/JLPAY/gwayne/controllers/base"
@@ -34,7 +35,7 @@ func CRDList(c *gin.Context) {
 	result, err := crd.GetCustomCRDPage(manager.CrdClient, manager.DynamicClient, group, kind, namespace, param)
 	if err != nil {
 		klog.Errorf("list cluster %s error: %v", cluster, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(base.QueryErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": result})
@@ -79,7 +80,7 @@ func CRDGet(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": result})
+	base.RenderObject(c, http.StatusOK, result)
 }
 
 // @Title Create
@@ -112,14 +113,21 @@ func CRDCreate(c *gin.Context) {
 
 	klog.V(2).Infof("creating cluster-scoped CRD instance group: %s, version: %s, kind: %s", group, version, kind)
 
-	result, err := crd.CreateCustomCRDInstanceClusterScoped(manager.DynamicClient, group, version, kind, c.Request.Body)
+	// 支持 JSON 和 YAML 请求体
+	body, err := base.ReadBodyJSON(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := crd.CreateCustomCRDInstanceClusterScoped(manager.DynamicClient, group, version, kind, bytes.NewReader(body))
 	if err != nil {
 		klog.Errorf("create cluster %s error: %v", cluster, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": result})
+	base.RenderObject(c, http.StatusOK, result)
 }
 
 // @Title CreateWithNamespace
@@ -154,14 +162,21 @@ func CRDCreateWithNamespace(c *gin.Context) {
 
 	klog.V(2).Infof("crd version: %s, namespace: %s", version, namespace)
 
-	result, err := crd.CreateCustomCRDInstance(manager.DynamicClient, group, version, kind, namespace, c.Request.Body)
+	// 支持 JSON 和 YAML 请求体
+	body, err := base.ReadBodyJSON(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := crd.CreateCustomCRDInstance(manager.DynamicClient, group, version, kind, namespace, bytes.NewReader(body))
 	if err != nil {
 		klog.Errorf("create cluster %s error: %v", cluster, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": result})
+	base.RenderObject(c, http.StatusOK, result)
 }
 
 // @Title Update
@@ -177,7 +192,7 @@ func CRDUpdate(c *gin.Context) {
 	kind := c.Param("kind")
 
 	var object runtime.Unknown
-	err := c.BindJSON(&object)
+	err := base.BindObject(c, &object)
 	if err != nil {
 		klog.Errorf("create cluster %s error: %v", cluster, err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -211,7 +226,7 @@ func CRDUpdate(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": result})
+	base.RenderObject(c, http.StatusOK, result)
 }
 
 // @Title Delete
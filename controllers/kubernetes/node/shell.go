@@ -0,0 +1,184 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/JLPAY/gwayne/controllers/base"
+	podctl "github.com/JLPAY/gwayne/controllers/kubernetes/pod"
+	"github.com/JLPAY/gwayne/models"
+	"github.com/JLPAY/gwayne/pkg/config"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/client"
+	"github.com/JLPAY/gwayne/pkg/terminal"
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+const (
+	defaultNodeShellImage               = "busybox:1.36"
+	defaultNodeShellNamespace           = "kube-system"
+	defaultNodeShellStartTimeoutSeconds = 60
+	// 未限制终端会话时长时节点终端 Pod 的最长存活时间
+	defaultNodeShellLifetime = 4 * time.Hour
+)
+
+func nodeShellNamespace() string {
+	if ns := config.Conf.NodeShell.Namespace; ns != "" {
+		return ns
+	}
+	return defaultNodeShellNamespace
+}
+
+// Shell 打开节点终端
+// @Title Shell
+// @Description schedule a short-lived privileged pod on the node and issue a single-use ticket to open a terminal chrooted into the node's root filesystem,
+// the pod is deleted when the session ends. Admin only.
+// @Param	name		path 	string 	true		"node name."
+// @Param	cluster		path 	string 	true		"cluster name."
+// @Param	cmd		query 	string	false		"the shell to exec, bash or sh, bash is preferred when empty."
+// @Success 200 {object} pod.TerminalResult success
+// @router /:name/clusters/:cluster/shell [post]
+func Shell(c *gin.Context) {
+	name := c.Param("name")
+	cluster := c.Param("cluster")
+	cmd := c.Query("cmd")
+	user := c.MustGet("User").(*models.User)
+	conf := config.Conf.NodeShell
+
+	if !user.Admin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+		return
+	}
+	if err := terminal.ValidateCommand(cmd); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	namespace := nodeShellNamespace()
+	if !podctl.CheckTerminalAccess(c, user, cluster, namespace) {
+		return
+	}
+
+	manager, err := client.Manager(cluster)
+	if manager == nil || err != nil {
+		klog.Errorf("Failed to get manager for cluster: %s", cluster)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get manager"})
+		return
+	}
+	if _, err := manager.Client.CoreV1().Nodes().Get(c.Request.Context(), name, metav1.GetOptions{}); err != nil {
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	image := conf.Image
+	if image == "" {
+		image = defaultNodeShellImage
+	}
+	// Pod 比会话多保留一段时间，覆盖等待连接的时间，会话超时由终端会话自己处理
+	lifetime := defaultNodeShellLifetime
+	if seconds := config.Conf.Terminal.MaxDurationSeconds; seconds > 0 {
+		lifetime = time.Duration(seconds) * time.Second
+	}
+	shellPod := terminal.NewNodeShellPod(terminal.NodeShellOptions{
+		Node:      name,
+		Namespace: namespace,
+		Image:     image,
+		User:      user.Name,
+		Lifetime:  lifetime + terminal.NodeShellStaleAfter,
+	})
+	pods := manager.Client.CoreV1().Pods(namespace)
+	created, err := pods.Create(c.Request.Context(), shellPod, metav1.CreateOptions{})
+	podName := ""
+	if err == nil {
+		podName = created.Name
+	}
+	podctl.RecordAudit(user, models.AuditActionNodeShell, cluster, namespace, podName, terminal.NodeShellContainer, name, 0, err)
+	if err != nil {
+		klog.Errorf("User %s create node shell pod on node %s in cluster %s error: %v", user.Name, name, cluster, err)
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	klog.Infof("User %s create node shell pod %s/%s on node %s in cluster %s", user.Name, namespace, podName, name, cluster)
+
+	timeout := time.Duration(conf.StartTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultNodeShellStartTimeoutSeconds * time.Second
+	}
+	err = wait.PollUntilContextTimeout(c.Request.Context(), time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		p, err := pods.Get(ctx, podName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		if p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed {
+			return false, fmt.Errorf("node shell pod %s is %s: %s", podName, p.Status.Phase, p.Status.Message)
+		}
+		return terminal.ValidateContainer(p, terminal.NodeShellContainer) == nil, nil
+	})
+	if err != nil {
+		deleteShellPod(manager.Client, namespace, podName)
+		status := base.KubeErrorStatus(err)
+		if wait.Interrupted(err) {
+			status = http.StatusGatewayTimeout
+			err = fmt.Errorf("node shell pod %s is not running after %s", podName, timeout)
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	podctl.IssueTerminalTicket(c, terminal.Ticket{
+		User:      user.Name,
+		Cluster:   cluster,
+		Namespace: namespace,
+		Pod:       podName,
+		Container: terminal.NodeShellContainer,
+		Command:   cmd,
+		NodeShell: true,
+	})
+}
+
+func deleteShellPod(cli kubernetes.Interface, namespace, name string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := terminal.DeleteNodeShellPod(ctx, cli, namespace, name); err != nil {
+		klog.Errorf("delete node shell pod %s/%s error: %v", namespace, name, err)
+	}
+}
+
+// StartShellJanitor 定期清理残留的节点终端 Pod，例如 gwayne 在会话期间异常退出。
+// 会话存活期间 Pod 的心跳会持续更新，多个实例同时清理也不会删除其他实例上正在使用的 Pod。
+func StartShellJanitor() {
+	ticker := time.NewTicker(terminal.NodeShellHeartbeatInterval)
+	go func() {
+		for range ticker.C {
+			client.Managers().Range(func(key, value interface{}) bool {
+				cleanupShellPods(key.(string), value.(*client.ClusterManager).Client)
+				return true
+			})
+		}
+	}()
+}
+
+func cleanupShellPods(cluster string, cli kubernetes.Interface) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	namespace := nodeShellNamespace()
+	pods, err := cli.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: terminal.NodeShellLabel + "=true"})
+	if err != nil {
+		klog.Warningf("list node shell pods in cluster %s error: %v", cluster, err)
+		return
+	}
+	now := time.Now()
+	for i := range pods.Items {
+		p := &pods.Items[i]
+		if !terminal.NodeShellExpired(p, now, terminal.NodeShellStaleAfter) {
+			continue
+		}
+		klog.Infof("Delete stale node shell pod %s/%s of user %s in cluster %s", namespace, p.Name, p.Annotations[terminal.NodeShellUserAnnotation], cluster)
+		deleteShellPod(cli, namespace, p.Name)
+	}
+}
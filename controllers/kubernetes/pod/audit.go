@@ -0,0 +1,64 @@
+package pod
+
+import (
+	"net/http"
+
+	"github.com/JLPAY/gwayne/controllers/base"
+	"github.com/JLPAY/gwayne/models"
+	"github.com/gin-gonic/gin"
+	"k8s.io/klog/v2"
+)
+
+// RecordAudit 写入容器操作的审计记录，失败时只记录日志
+func RecordAudit(user *models.User, action models.AuditAction, cluster, namespace, pod, container, target string, bytes int64, err error) {
+	record := &models.AuditLog{
+		User:      user.Name,
+		Action:    action,
+		Cluster:   cluster,
+		Namespace: namespace,
+		Pod:       pod,
+		Container: container,
+		Target:    target,
+		Bytes:     bytes,
+		Status:    models.AuditStatusSuccess,
+	}
+	if err != nil {
+		record.Status = models.AuditStatusFailed
+		record.Message = err.Error()
+	}
+	if len(record.Target) > 1024 {
+		record.Target = record.Target[:1024]
+	}
+	if err := models.AddAuditLog(record); err != nil {
+		klog.Errorf("Add audit log %+v error: %v", record, err)
+	}
+}
+
+// @Title ListAuditLogs
+// @Description list the audit logs of container operations, newest first, admin only.
+// The audit log grows quickly, so it always uses keyset pagination, pass the continue token of the previous page to get the next page.
+// @Param	filter		query 	string 	false		"filter, e.g. user=admin,action__in=file_upload|file_download,createTime__gt=2026-10-01"
+// @Param	pageSize		query 	int 	false		"page size"
+// @Param	continue		query 	string 	false		"the continue token of the previous page"
+// @Success 200 {object} "the audit logs" success
+// @router /audit/logs [get]
+func ListAuditLogs(c *gin.Context) {
+	user := c.MustGet("User").(*models.User)
+	if !user.Admin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+		return
+	}
+	param := base.BuildQueryParam(c)
+	if param.Sortby == "" {
+		param.Sortby = "-id"
+	}
+
+	logs := []models.AuditLog{}
+	next, err := models.GetAllByCursor(new(models.AuditLog), &logs, param)
+	if err != nil {
+		klog.Errorf("list audit logs by cursor (%v) error. %v", param, err)
+		c.JSON(base.QueryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": param.NewContinuePage(logs, next, nil)})
+}
@@ -0,0 +1,128 @@
+package pod
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/JLPAY/gwayne/controllers/base"
+	"github.com/JLPAY/gwayne/models"
+	"github.com/JLPAY/gwayne/pkg/config"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/client"
+	"github.com/JLPAY/gwayne/pkg/terminal"
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+const defaultDebugStartTimeoutSeconds = 60
+
+// @Title Debug
+// @Description attach an ephemeral debug container to the pod and issue a single-use ticket to open the terminal on it,
+// used for pods without a shell such as distroless images. The debug container can not be removed until the pod is deleted.
+// @Param	cluster		path 	string 	true		"cluster name."
+// @Param	namespace		path 	string 	true		"namespace name."
+// @Param	pod		path 	string 	true		"pod name."
+// @Param	image		query 	string 	false		"the debug image, must be in the allowlist, the default image is used when empty."
+// @Param	target		query 	string 	false		"the container to share the process namespace with."
+// @Param	cmd		query 	string	false		"the shell to exec, bash or sh, detected automatically when empty."
+// @Success 200 {object} TerminalResult success
+// @router /:pod/debug/namespaces/:namespace/clusters/:cluster [post]
+func Debug(c *gin.Context) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespace")
+	pod := c.Param("pod")
+	target := c.Query("target")
+	cmd := c.Query("cmd")
+	user := c.MustGet("User").(*models.User)
+	conf := config.Conf.Debug
+
+	if err := terminal.ValidateCommand(cmd); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	image := c.DefaultQuery("image", conf.DefaultImage)
+	if image == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "image is required"})
+		return
+	}
+	if err := terminal.AllowImage(terminal.ParseList(conf.ImageAllowlist), image); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if !CheckTerminalAccess(c, user, cluster, namespace) {
+		return
+	}
+
+	manager, err := client.Manager(cluster)
+	if manager == nil || err != nil {
+		klog.Errorf("Failed to get manager for cluster: %s", cluster)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get manager"})
+		return
+	}
+	pods := manager.Client.CoreV1().Pods(namespace)
+	podObj, err := pods.Get(c.Request.Context(), pod, metav1.GetOptions{})
+	if err != nil {
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if !checkNodeShellPod(c, user, cluster, podObj) {
+		return
+	}
+	if podObj.DeletionTimestamp != nil || podObj.Status.Phase != corev1.PodRunning {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("pod %s is not running", pod)})
+		return
+	}
+	container, err := terminal.NewDebugContainer(podObj, image, target)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	podObj.Spec.EphemeralContainers = append(podObj.Spec.EphemeralContainers, *container)
+	_, err = pods.UpdateEphemeralContainers(c.Request.Context(), pod, podObj, metav1.UpdateOptions{})
+	RecordAudit(user, models.AuditActionDebugContainer, cluster, namespace, pod, container.Name, image, 0, err)
+	if err != nil {
+		klog.Errorf("User %s add debug container to pod %s/%s in cluster %s error: %v", user.Name, namespace, pod, cluster, err)
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	klog.Infof("User %s add debug container %s (%s) to pod %s/%s in cluster %s", user.Name, container.Name, image, namespace, pod, cluster)
+
+	timeout := time.Duration(conf.StartTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultDebugStartTimeoutSeconds * time.Second
+	}
+	err = wait.PollUntilContextTimeout(c.Request.Context(), time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		podObj, err := pods.Get(ctx, pod, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return terminal.EphemeralContainerReady(podObj, container.Name)
+	})
+	if wait.Interrupted(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": fmt.Sprintf("debug container %s is not running after %s", container.Name, timeout)})
+		return
+	}
+	if err != nil {
+		status := base.KubeErrorStatus(err)
+		if errors.Is(err, terminal.ErrDebugContainerFail) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	IssueTerminalTicket(c, terminal.Ticket{
+		User:      user.Name,
+		Cluster:   cluster,
+		Namespace: namespace,
+		Pod:       pod,
+		Container: container.Name,
+		Command:   cmd,
+	})
+}
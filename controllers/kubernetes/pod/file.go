@@ -0,0 +1,262 @@
+package pod
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/JLPAY/gwayne/controllers/base"
+	"github.com/JLPAY/gwayne/models"
+	"github.com/JLPAY/gwayne/pkg/config"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/client"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/cp"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/exec"
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	defaultMaxUploadBytes       = 100 << 20
+	defaultMaxFileDownloadBytes = 500 << 20
+	// multipart 表单中除文件内容以外的部分
+	multipartOverheadBytes = 1 << 20
+)
+
+// @Title UploadFiles
+// @Description upload files to a directory in the container, the directory must exist
+// @Param	cluster		path 	string 	true		"cluster name."
+// @Param	namespace		path 	string 	true		"namespace name."
+// @Param	pod		path 	string 	true		"pod name."
+// @Param	container		query 	string 	true		"container name."
+// @Param	path		query 	string 	true		"the absolute directory path in the container"
+// @Param	file		formData 	file 	true		"the files to upload, can be repeated"
+// @Success 200 {object} "the uploaded files" success
+// @router /:pod/files/namespaces/:namespace/clusters/:cluster [post]
+func UploadFiles(c *gin.Context) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespace")
+	pod := c.Param("pod")
+	container := c.Query("container")
+	user := c.MustGet("User").(*models.User)
+
+	if container == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "container is required"})
+		return
+	}
+	dir, err := cp.ValidatePath(c.Query("path"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !checkFileAccess(c, user, cluster, namespace) {
+		return
+	}
+
+	maxBytes := config.Conf.FileTransfer.MaxUploadBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxUploadBytes
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+multipartOverheadBytes)
+	form, err := c.MultipartForm()
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("upload size exceeds the limit of %d bytes", maxBytes)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer form.RemoveAll()
+
+	headers := form.File["file"]
+	if len(headers) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	var total int64
+	files := make([]cp.File, 0, len(headers))
+	for _, header := range headers {
+		total += header.Size
+		files = append(files, cp.File{Name: header.Filename, Size: header.Size, Open: openFormFile(header)})
+	}
+	if total > maxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("upload size exceeds the limit of %d bytes", maxBytes)})
+		return
+	}
+
+	manager, err := client.Manager(cluster)
+	if manager == nil || err != nil {
+		klog.Errorf("Failed to get manager for cluster: %s", cluster)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get manager"})
+		return
+	}
+	podObj, err := manager.Client.CoreV1().Pods(namespace).Get(c.Request.Context(), pod, metav1.GetOptions{})
+	if err != nil {
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if !checkNodeShellPod(c, user, cluster, podObj) {
+		return
+	}
+
+	execFunc := func(ctx context.Context, cmd []string, stdin io.Reader, stdout io.Writer) error {
+		return exec.Run(ctx, manager.Client, manager.Config, namespace, pod, container, cmd, stdin, stdout)
+	}
+	written, err := cp.Upload(c.Request.Context(), execFunc, dir, files)
+
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, path.Join(dir, file.Name))
+	}
+	RecordAudit(user, models.AuditActionFileUpload, cluster, namespace, pod, container, strings.Join(names, ","), written, err)
+	if err != nil {
+		klog.Errorf("User %s upload files to pod %s/%s container %s in cluster %s error: %v", user.Name, namespace, pod, container, cluster, err)
+		status := base.KubeErrorStatus(err)
+		if errors.Is(err, cp.ErrInvalidFileName) || errors.Is(err, cp.ErrDuplicateFileName) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"files": names, "bytes": written}})
+}
+
+// checkFileAccess 文件传输在容器中执行命令，与打开终端使用相同的权限策略
+func checkFileAccess(c *gin.Context, user *models.User, cluster, namespace string) bool {
+	if err := terminalPolicy().Allow(user.Admin, namespace); err != nil {
+		klog.Warningf("User %s transfer files in namespace %s of cluster %s denied: %v", user.Name, namespace, cluster, err)
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+func openFormFile(header *multipart.FileHeader) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return header.Open()
+	}
+}
+
+// @Title DownloadFile
+// @Description download a file or directory from the container, as a .tar.gz archive or as the single file itself
+// @Param	cluster		path 	string 	true		"cluster name."
+// @Param	namespace		path 	string 	true		"namespace name."
+// @Param	pod		path 	string 	true		"pod name."
+// @Param	container		query 	string 	true		"container name."
+// @Param	path		query 	string 	true		"the absolute path in the container"
+// @Param	format		query 	string 	false		"tar.gz (default) or file, file only works for regular files"
+// @Success 200 {object} "the file content" success
+// @router /:pod/files/namespaces/:namespace/clusters/:cluster [get]
+func DownloadFile(c *gin.Context) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespace")
+	pod := c.Param("pod")
+	container := c.Query("container")
+	format := c.DefaultQuery("format", "tar.gz")
+	user := c.MustGet("User").(*models.User)
+
+	if container == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "container is required"})
+		return
+	}
+	if format != "tar.gz" && format != "file" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be tar.gz or file"})
+		return
+	}
+	p, err := cp.ValidatePath(c.Query("path"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	maxBytes := config.Conf.FileTransfer.MaxDownloadBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxFileDownloadBytes
+	}
+
+	if !checkFileAccess(c, user, cluster, namespace) {
+		return
+	}
+	manager, err := client.Manager(cluster)
+	if manager == nil || err != nil {
+		klog.Errorf("Failed to get manager for cluster: %s", cluster)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get manager"})
+		return
+	}
+	podObj, err := manager.Client.CoreV1().Pods(namespace).Get(c.Request.Context(), pod, metav1.GetOptions{})
+	if err != nil {
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if !checkNodeShellPod(c, user, cluster, podObj) {
+		return
+	}
+
+	execFunc := func(ctx context.Context, cmd []string, stdin io.Reader, stdout io.Writer) error {
+		return exec.Run(ctx, manager.Client, manager.Config, namespace, pod, container, cmd, stdin, stdout)
+	}
+	archive, err := cp.Download(c.Request.Context(), execFunc, p)
+	if err != nil {
+		RecordAudit(user, models.AuditActionFileDownload, cluster, namespace, pod, container, p, 0, err)
+		klog.Errorf("User %s download %s from pod %s/%s container %s in cluster %s error: %v", user.Name, p, namespace, pod, container, cluster, err)
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer archive.Close()
+
+	var written int64
+	if format == "file" {
+		written, err = writeSingleFile(c, archive, maxBytes)
+	} else {
+		c.Header("Content-Type", "application/gzip")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", archiveName(p)))
+		c.Status(http.StatusOK)
+		// 响应头已经发送，出错时只能记录日志
+		written, err = archive.WriteTarGz(c.Writer, maxBytes)
+	}
+
+	RecordAudit(user, models.AuditActionFileDownload, cluster, namespace, pod, container, p, written, err)
+	if err != nil {
+		klog.Errorf("User %s download %s from pod %s/%s container %s in cluster %s error: %v", user.Name, p, namespace, pod, container, cluster, err)
+	}
+}
+
+// writeSingleFile 直接输出普通文件的内容，大小在发送响应头之前检查
+func writeSingleFile(c *gin.Context, archive *cp.Archive, maxBytes int64) (int64, error) {
+	header, content, err := archive.File()
+	if err != nil {
+		status := base.KubeErrorStatus(err)
+		if errors.Is(err, cp.ErrNotRegularFile) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return 0, err
+	}
+	if header.Size > maxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("file size %d exceeds the limit of %d bytes", header.Size, maxBytes)})
+		return 0, cp.ErrTooLarge
+	}
+
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Content-Length", strconv.FormatInt(header.Size, 10))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(header.Name)))
+	c.Status(http.StatusOK)
+	return io.Copy(c.Writer, content)
+}
+
+// archiveName 下载目录时的文件名，根目录时使用 root
+func archiveName(p string) string {
+	name := path.Base(p)
+	if name == "/" {
+		name = "root"
+	}
+	return name + ".tar.gz"
+}
@@ -1,9 +1,16 @@
 package pod
 
 import (
+	"compress/gzip"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/JLPAY/gwayne/controllers/base"
+	"github.com/JLPAY/gwayne/models"
+	"github.com/JLPAY/gwayne/pkg/config"
 	"github.com/JLPAY/gwayne/pkg/hack"
 	"github.com/JLPAY/gwayne/pkg/kubernetes/client"
 	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/log"
@@ -63,3 +70,279 @@ func ListLogs(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"data": hack.String(result)})
 }
+
+const (
+	defaultLogHeartbeatSeconds = 30
+	// 每个 SSE 事件最多合并的日志行数
+	maxLinesPerEvent = 200
+)
+
+// @Title StreamLogs
+// @Description stream pod logs via Server-Sent Events, each "log" event carries a batch of lines, the stream ends with an "end" event
+// @Param	cluster		path 	string 	true		"cluster name."
+// @Param	namespace		path 	string 	true		"namespace name."
+// @Param	pod		path 	string 	true		"pod name."
+// @Param	container		path 	string 	true		"container name."
+// @Param	follow		query 	bool 	false		"follow the log stream"
+// @Param	sinceSeconds		query 	int 	false		"only return logs newer than a relative duration in seconds"
+// @Param	sinceTime		query 	string 	false		"only return logs after a RFC3339 timestamp"
+// @Param	tailLines		query 	int 	false		"log tail lines"
+// @Param	timestamps		query 	bool 	false		"add a RFC3339 timestamp at the beginning of every line"
+// @Param	previous		query 	bool 	false		"return the logs of the previous terminated container"
+// @Param	limitBytes		query 	int 	false		"the number of bytes to read before terminating the stream"
+// @Success 200 {object} "text/event-stream" success
+// @router /:pod/containers/:container/namespaces/:namespace/clusters/:cluster/stream [get]
+func StreamLogs(c *gin.Context) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespace")
+	pod := c.Param("pod")
+	container := c.Param("container")
+	user := c.MustGet("User").(*models.User)
+
+	opt, err := log.ParseOptions(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	opt.Container = container
+
+	manager, err := client.Manager(cluster)
+	if manager == nil || err != nil {
+		klog.Errorf("Failed to get manager for cluster: %s", cluster)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get manager"})
+		return
+	}
+
+	logConf := config.Conf.PodLog
+	release, err := log.StreamLimiter.Acquire(user.Name, logConf.MaxStreamsPerUser)
+	if err != nil {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many log streams"})
+		return
+	}
+	defer release()
+
+	// 浏览器断开连接时请求的 context 被取消，日志流随之关闭
+	stream, err := log.NewStream(c.Request.Context(), manager.Client, namespace, pod, opt, logConf.BufferLines)
+	if err != nil {
+		klog.Errorf("Stream logs of pod %s/%s container %s in cluster %s error: %v", namespace, pod, container, cluster, err)
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer stream.Close()
+
+	heartbeatSeconds := logConf.HeartbeatSeconds
+	if heartbeatSeconds <= 0 {
+		heartbeatSeconds = defaultLogHeartbeatSeconds
+	}
+	heartbeat := time.NewTicker(time.Duration(heartbeatSeconds) * time.Second)
+	defer heartbeat.Stop()
+
+	klog.V(2).Infof("User %s stream logs of pod %s/%s container %s in cluster %s", user.Name, namespace, pod, container, cluster)
+
+	base.StartSSE(c)
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case line, ok := <-stream.Lines():
+			if !ok {
+				if err := stream.Err(); err != nil {
+					_ = base.WriteSSE(w, "", "error", gin.H{"error": err.Error()})
+				}
+				_ = base.WriteSSE(w, "", "end", gin.H{})
+				return false
+			}
+			// 合并已经读取到的日志行，减少事件数量
+			lines := []string{line}
+		drain:
+			for len(lines) < maxLinesPerEvent {
+				select {
+				case line, ok := <-stream.Lines():
+					if !ok {
+						break drain
+					}
+					lines = append(lines, line)
+				default:
+					break drain
+				}
+			}
+			if err := base.WriteSSE(w, "", "log", gin.H{"lines": lines}); err != nil {
+				return false
+			}
+		case <-heartbeat.C:
+			if err := base.WriteSSEHeartbeat(w); err != nil {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+const (
+	defaultMaxDownloadBytes = 500 << 20
+	defaultMaxSearchBytes   = 50 << 20
+)
+
+// parseRangeOptions 解析下载和搜索共用的日志选项，结束时间由服务端过滤，需要以 timestamps=true 读取日志
+func parseRangeOptions(c *gin.Context, maxBytes int64) (*corev1.PodLogOptions, log.FilterOptions, error) {
+	query := c.Request.URL.Query()
+	opt, err := log.ParseOptions(query)
+	if err != nil {
+		return nil, log.FilterOptions{}, err
+	}
+	if opt.Follow {
+		return nil, log.FilterOptions{}, fmt.Errorf("follow is not supported, use the stream api instead")
+	}
+	until, err := log.ParseUntilTime(query)
+	if err != nil {
+		return nil, log.FilterOptions{}, err
+	}
+	if !until.IsZero() && opt.SinceTime != nil && until.Before(opt.SinceTime.Time) {
+		return nil, log.FilterOptions{}, fmt.Errorf("untilTime must not be earlier than sinceTime")
+	}
+
+	filter := log.FilterOptions{Until: until}
+	if !until.IsZero() && !opt.Timestamps {
+		opt.Timestamps = true
+		filter.StripTimestamps = true
+	}
+	opt.Container = c.Param("container")
+	log.CapLimitBytes(opt, maxBytes)
+	return opt, filter, nil
+}
+
+// @Title DownloadLogs
+// @Description download the container log as plain text or gzip, optionally limited to a time range
+// @Param	cluster		path 	string 	true		"cluster name."
+// @Param	namespace		path 	string 	true		"namespace name."
+// @Param	pod		path 	string 	true		"pod name."
+// @Param	container		path 	string 	true		"container name."
+// @Param	gzip		query 	bool 	false		"compress the log with gzip"
+// @Param	sinceSeconds		query 	int 	false		"only return logs newer than a relative duration in seconds"
+// @Param	sinceTime		query 	string 	false		"only return logs after a RFC3339 timestamp"
+// @Param	untilTime		query 	string 	false		"only return logs before a RFC3339 timestamp"
+// @Param	tailLines		query 	int 	false		"log tail lines"
+// @Param	timestamps		query 	bool 	false		"add a RFC3339 timestamp at the beginning of every line"
+// @Param	previous		query 	bool 	false		"return the logs of the previous terminated container"
+// @Param	limitBytes		query 	int 	false		"the number of bytes to read, capped by the server"
+// @Success 200 {object} "log file" success
+// @router /:pod/containers/:container/namespaces/:namespace/clusters/:cluster/download [get]
+func DownloadLogs(c *gin.Context) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespace")
+	pod := c.Param("pod")
+	container := c.Param("container")
+	user := c.MustGet("User").(*models.User)
+
+	useGzip, err := strconv.ParseBool(c.DefaultQuery("gzip", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid gzip parameter"})
+		return
+	}
+	maxBytes := int64(config.Conf.PodLog.MaxDownloadBytes)
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxDownloadBytes
+	}
+	opt, filter, err := parseRangeOptions(c, maxBytes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	manager, err := client.Manager(cluster)
+	if manager == nil || err != nil {
+		klog.Errorf("Failed to get manager for cluster: %s", cluster)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get manager"})
+		return
+	}
+	stream, err := manager.Client.CoreV1().Pods(namespace).GetLogs(pod, opt).Stream(c.Request.Context())
+	if err != nil {
+		klog.Errorf("Download logs of pod %s/%s container %s in cluster %s error: %v", namespace, pod, container, cluster, err)
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer stream.Close()
+
+	klog.Infof("User %s download logs of pod %s/%s container %s in cluster %s", user.Name, namespace, pod, container, cluster)
+
+	filename := fmt.Sprintf("%s-%s.log", pod, container)
+	var w io.Writer = c.Writer
+	if useGzip {
+		filename += ".gz"
+		c.Header("Content-Type", "application/gzip")
+		gw := gzip.NewWriter(c.Writer)
+		defer gw.Close()
+		w = gw
+	} else {
+		c.Header("Content-Type", "text/plain; charset=utf-8")
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	// 响应头已经发送，出错时只能记录日志
+	if _, err := log.Copy(w, stream, filter); err != nil {
+		klog.Errorf("Write logs of pod %s/%s container %s error: %v", namespace, pod, container, err)
+	}
+}
+
+// @Title SearchLogs
+// @Description search the container log with a regular expression on the server, returning matches with line numbers and context lines
+// @Param	cluster		path 	string 	true		"cluster name."
+// @Param	namespace		path 	string 	true		"namespace name."
+// @Param	pod		path 	string 	true		"pod name."
+// @Param	container		path 	string 	true		"container name."
+// @Param	q		query 	string 	true		"the regular expression"
+// @Param	ignoreCase		query 	bool 	false		"case insensitive match"
+// @Param	context		query 	int 	false		"context lines before and after every match, default 2, max 20"
+// @Param	maxMatches		query 	int 	false		"max matches to return, default 100, max 1000"
+// @Param	sinceSeconds		query 	int 	false		"only search logs newer than a relative duration in seconds"
+// @Param	sinceTime		query 	string 	false		"only search logs after a RFC3339 timestamp"
+// @Param	untilTime		query 	string 	false		"only search logs before a RFC3339 timestamp"
+// @Param	tailLines		query 	int 	false		"only search the last lines"
+// @Param	previous		query 	bool 	false		"search the logs of the previous terminated container"
+// @Success 200 {object} log.SearchResult success
+// @router /:pod/containers/:container/namespaces/:namespace/clusters/:cluster/search [get]
+func SearchLogs(c *gin.Context) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespace")
+	pod := c.Param("pod")
+	container := c.Param("container")
+
+	searchOpts, err := log.ParseSearchOptions(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	maxBytes := int64(config.Conf.PodLog.MaxSearchBytes)
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxSearchBytes
+	}
+	opt, filter, err := parseRangeOptions(c, maxBytes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	searchOpts.FilterOptions = filter
+
+	manager, err := client.Manager(cluster)
+	if manager == nil || err != nil {
+		klog.Errorf("Failed to get manager for cluster: %s", cluster)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get manager"})
+		return
+	}
+	stream, err := manager.Client.CoreV1().Pods(namespace).GetLogs(pod, opt).Stream(c.Request.Context())
+	if err != nil {
+		klog.Errorf("Search logs of pod %s/%s container %s in cluster %s error: %v", namespace, pod, container, cluster, err)
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer stream.Close()
+
+	result, err := log.Search(stream, *searchOpts)
+	if err != nil {
+		klog.Errorf("Search logs of pod %s/%s container %s in cluster %s error: %v", namespace, pod, container, cluster, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
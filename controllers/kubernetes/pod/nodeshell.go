@@ -0,0 +1,37 @@
+package pod
+
+import (
+	"context"
+	"time"
+
+	"github.com/JLPAY/gwayne/pkg/terminal"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// keepNodeShellAlive 会话存活期间定期更新节点终端 Pod 的心跳，gwayne 异常退出后由清理任务删除 Pod
+func keepNodeShellAlive(ctx context.Context, cli kubernetes.Interface, ticket *terminal.Ticket) {
+	ticker := time.NewTicker(terminal.NodeShellHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := terminal.TouchNodeShellPod(ctx, cli, ticket.Namespace, ticket.Pod, now); err != nil && ctx.Err() == nil {
+				klog.Warningf("update heartbeat of node shell pod %s/%s in cluster %s error: %v", ticket.Namespace, ticket.Pod, ticket.Cluster, err)
+			}
+		}
+	}
+}
+
+// deleteNodeShellPod 会话结束后删除节点终端 Pod
+func deleteNodeShellPod(cli kubernetes.Interface, ticket *terminal.Ticket) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := terminal.DeleteNodeShellPod(ctx, cli, ticket.Namespace, ticket.Pod); err != nil {
+		klog.Errorf("delete node shell pod %s/%s in cluster %s error: %v", ticket.Namespace, ticket.Pod, ticket.Cluster, err)
+		return
+	}
+	klog.Infof("Node shell pod %s/%s in cluster %s of user %s deleted", ticket.Namespace, ticket.Pod, ticket.Cluster, ticket.User)
+}
@@ -49,7 +49,7 @@ func List(c *gin.Context) {
 			cluster, namespace, resourceType, resourceName, err)
 
 		// 返回错误响应
-		c.JSON(500, gin.H{"error": err.Error()})
+		c.JSON(base.QueryErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -0,0 +1,140 @@
+package pod
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/JLPAY/gwayne/controllers/base"
+	"github.com/JLPAY/gwayne/models"
+	"github.com/JLPAY/gwayne/pkg/config"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/client"
+	"github.com/JLPAY/gwayne/pkg/portforward"
+	"github.com/JLPAY/gwayne/pkg/terminal"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+var portForwardUpgrader = websocket.Upgrader{
+	// 使用 Authorization 请求头认证，浏览器跨站发起的 WebSocket 连接无法携带该请求头，不需要检查 Origin
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// portForwardPolicy 端口转发的权限策略
+func portForwardPolicy() terminal.Policy {
+	return terminal.Policy{
+		AdminOnly:           config.Conf.PortForward.AdminOnly,
+		ProtectedNamespaces: terminal.ParseList(config.Conf.PortForward.ProtectedNamespaces),
+	}
+}
+
+// @Title PortForward
+// @Description forward a TCP connection to a port of the pod over WebSocket, the binary messages are the TCP stream,
+// use `gwayne port-forward` to listen on local ports.
+// @Param	cluster		path 	string 	true		"cluster name."
+// @Param	namespace		path 	string 	true		"namespace name."
+// @Param	pod		path 	string 	true		"pod name."
+// @Param	port		query 	int 	true		"the container port."
+// @Success 101 {object} "switching protocols" success
+// @router /portforward/pods/:pod/namespaces/:namespace/clusters/:cluster [get]
+func PortForward(c *gin.Context) {
+	forwardPort(c, c.Param("pod"), "")
+}
+
+// @Title ServicePortForward
+// @Description forward a TCP connection to a port of the service over WebSocket, the connection goes to a ready pod of the service.
+// @Param	cluster		path 	string 	true		"cluster name."
+// @Param	namespace		path 	string 	true		"namespace name."
+// @Param	service		path 	string 	true		"service name."
+// @Param	port		query 	int 	true		"the service port."
+// @Success 101 {object} "switching protocols" success
+// @router /portforward/services/:service/namespaces/:namespace/clusters/:cluster [get]
+func ServicePortForward(c *gin.Context) {
+	forwardPort(c, "", c.Param("service"))
+}
+
+func forwardPort(c *gin.Context, pod, service string) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespace")
+	user := c.MustGet("User").(*models.User)
+
+	port, err := strconv.Atoi(c.Query("port"))
+	if err != nil || port <= 0 || port > 65535 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid port"})
+		return
+	}
+	if err := portForwardPolicy().Allow(user.Admin, namespace); err != nil {
+		klog.Warningf("User %s port-forward in namespace %s of cluster %s denied: %v", user.Name, namespace, cluster, err)
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	manager, err := client.Manager(cluster)
+	if manager == nil || err != nil {
+		klog.Errorf("Failed to get manager for cluster: %s", cluster)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get manager"})
+		return
+	}
+
+	targetPort := port
+	target := fmt.Sprintf("pod/%s:%d", pod, port)
+	if service != "" {
+		pod, targetPort, err = portforward.ResolveService(c.Request.Context(), manager.Client, namespace, service, port)
+		if err != nil {
+			status := base.KubeErrorStatus(err)
+			switch {
+			case errors.Is(err, portforward.ErrPortNotFound):
+				status = http.StatusBadRequest
+			case errors.Is(err, portforward.ErrNoReadyPod):
+				status = http.StatusServiceUnavailable
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		target = fmt.Sprintf("service/%s:%d -> pod/%s:%d", service, port, pod, targetPort)
+	}
+	// Service 解析出的 Pod 也需要检查，Service 可以选择到节点终端的 Pod
+	podObj, err := manager.Client.CoreV1().Pods(namespace).Get(c.Request.Context(), pod, metav1.GetOptions{})
+	if err != nil {
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if !checkNodeShellPod(c, user, cluster, podObj) {
+		return
+	}
+	if podObj.Status.Phase != corev1.PodRunning {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("pod %s is %s, not running", pod, podObj.Status.Phase)})
+		return
+	}
+
+	// 升级为 WebSocket 之前连接 Pod，出错时可以返回 JSON 格式的错误信息
+	stream, err := portforward.Dial(manager.Client, manager.Config, namespace, pod)
+	if err != nil {
+		klog.Errorf("User %s port-forward to %s in namespace %s of cluster %s error: %v", user.Name, target, namespace, cluster, err)
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer stream.Close()
+
+	ws, err := portForwardUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade 已经返回了错误响应
+		klog.Warningf("upgrade port-forward connection of user %s error: %v", user.Name, err)
+		return
+	}
+	conn := portforward.NewConn(ws)
+	klog.V(2).Infof("User %s port-forward to %s in namespace %s of cluster %s", user.Name, target, namespace, cluster)
+
+	sent, received, err := portforward.Forward(c.Request.Context(), stream, targetPort, conn)
+	RecordAudit(user, models.AuditActionPortForward, cluster, namespace, pod, "", target, sent+received, err)
+	if err != nil {
+		klog.Warningf("User %s port-forward to %s in namespace %s of cluster %s error: %v", user.Name, target, namespace, cluster, err)
+		conn.CloseWithReason(websocket.CloseInternalServerErr, err.Error())
+		return
+	}
+	conn.Close()
+}
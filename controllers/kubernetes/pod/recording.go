@@ -0,0 +1,228 @@
+package pod
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/JLPAY/gwayne/controllers/base"
+	"github.com/JLPAY/gwayne/models"
+	"github.com/JLPAY/gwayne/pkg/config"
+	"github.com/JLPAY/gwayne/pkg/terminal"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+)
+
+const defaultMaxRecordingBytes = 100 << 20
+
+var (
+	recordingStore     terminal.Store
+	recordingStoreErr  error
+	recordingStoreOnce sync.Once
+)
+
+func getRecordingStore() (terminal.Store, error) {
+	recordingStoreOnce.Do(func() {
+		conf := config.Conf.Recording
+		recordingStore, recordingStoreErr = terminal.NewStore(conf.Storage, conf.Dir)
+	})
+	return recordingStore, recordingStoreErr
+}
+
+// startRecording 开始录制终端会话并写入录像索引，未开启录像时返回 nil
+func startRecording(ts *TerminalSession, ticket *terminal.Ticket) (*models.TerminalRecord, error) {
+	conf := config.Conf.Recording
+	if !conf.Enabled {
+		return nil, nil
+	}
+	store, err := getRecordingStore()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	record := &models.TerminalRecord{
+		SessionId:  ticket.SessionId,
+		User:       ticket.User,
+		Cluster:    ticket.Cluster,
+		Namespace:  ticket.Namespace,
+		Pod:        ticket.Pod,
+		Container:  ticket.Container,
+		Command:    ticket.Command,
+		StorageKey: terminal.RecordingKey(ticket.SessionId, now),
+		Input:      conf.RecordInput,
+		StartTime:  now,
+	}
+	w, err := store.Create(record.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := models.AddTerminalRecord(record); err != nil {
+		w.Close()
+		store.Delete(record.StorageKey)
+		return nil, err
+	}
+
+	maxBytes := conf.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxRecordingBytes
+	}
+	ts.recorder = terminal.NewRecorder(w, terminal.RecorderOptions{
+		Title:       fmt.Sprintf("%s/%s/%s/%s", ticket.Cluster, ticket.Namespace, ticket.Pod, ticket.Container),
+		RecordInput: conf.RecordInput,
+		MaxBytes:    maxBytes,
+	})
+	return record, nil
+}
+
+// finishRecording 会话结束后保存录像并更新录像索引
+func finishRecording(recorder *terminal.Recorder, record *models.TerminalRecord) {
+	if recorder == nil || record == nil {
+		return
+	}
+	if err := recorder.Close(); err != nil {
+		klog.Errorf("Save recording of terminal session %s error: %v", record.SessionId, err)
+	}
+	now := time.Now()
+	record.Bytes = recorder.Bytes()
+	record.Truncated = recorder.Truncated()
+	record.EndTime = &now
+	if err := models.FinishTerminalRecord(record); err != nil {
+		klog.Errorf("Update recording of terminal session %s error: %v", record.SessionId, err)
+	}
+}
+
+// getRecord 获取录像索引并校验权限，非管理员只能查看自己的录像
+func getRecord(c *gin.Context) (*models.TerminalRecord, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid id"})
+		return nil, false
+	}
+	record, err := models.GetTerminalRecordById(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recording not found"})
+		return nil, false
+	}
+	if err != nil {
+		klog.Errorf("get terminal record %d error: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	user := c.MustGet("User").(*models.User)
+	if !user.Admin && record.User != user.Name {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+		return nil, false
+	}
+	return record, true
+}
+
+// @Title ListRecordings
+// @Description list terminal session recordings, non-admin users only see their own sessions
+// @Param	filter		query 	string 	false		"filter by cluster, namespace, pod, container or user, e.g. cluster=dev,namespace=default,pod=web-0"
+// @Param	startTime		query 	string 	false		"only list sessions started after a RFC3339 timestamp"
+// @Param	endTime		query 	string 	false		"only list sessions started before a RFC3339 timestamp"
+// @Param	pageNo		query 	int 	false		"page number"
+// @Param	pageSize		query 	int 	false		"page size"
+// @Param	continue		query 	string 	false		"the continue token of the previous page, enables keyset pagination ordered by id, newest first"
+// @Success 200 {object} "the recordings" success
+// @router /terminal/recordings [get]
+func ListRecordings(c *gin.Context) {
+	param := base.BuildQueryParam(c)
+	user := c.MustGet("User").(*models.User)
+	if !user.Admin {
+		param.AddQuery("user", user.Name)
+	}
+	var since, until time.Time
+	for key, t := range map[string]*time.Time{"startTime": &since, "endTime": &until} {
+		if value := c.Query(key); value != "" {
+			var err error
+			if *t, err = time.Parse(time.RFC3339, value); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %s %q, expected RFC3339", key, value)})
+				return
+			}
+		}
+	}
+
+	// 游标分页，按 id 做 keyset 分页，不统计总数
+	if param.UseContinue {
+		if param.Sortby == "" {
+			param.Sortby = "-id"
+		}
+		records, next, err := models.GetTerminalRecordsByCursor(param, since, until)
+		if err != nil {
+			klog.Errorf("list terminal records by cursor (%v) error. %v", param, err)
+			c.JSON(base.QueryErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": param.NewContinuePage(records, next, nil)})
+		return
+	}
+
+	total, records, err := models.GetTerminalRecords(param, since, until)
+	if err != nil {
+		klog.Errorf("list terminal records by param (%v) error. %v", param, err)
+		c.JSON(base.QueryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": param.NewPage(total, records)})
+}
+
+// @Title GetRecording
+// @Description get a terminal session recording
+// @Param	id		path 	int 	true		"the recording id"
+// @Success 200 {object} models.TerminalRecord success
+// @router /terminal/recordings/:id [get]
+func GetRecording(c *gin.Context) {
+	record, ok := getRecord(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": record})
+}
+
+// @Title ReplayRecording
+// @Description get the asciinema v2 (asciicast) file of a terminal session recording, which can be played by asciinema-player or `asciinema play`
+// @Param	id		path 	int 	true		"the recording id"
+// @Param	download		query 	bool 	false		"download as an attachment"
+// @Success 200 {object} "the asciicast file" success
+// @router /terminal/recordings/:id/cast [get]
+func ReplayRecording(c *gin.Context) {
+	record, ok := getRecord(c)
+	if !ok {
+		return
+	}
+	store, err := getRecordingStore()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	f, err := store.Open(record.StorageKey)
+	if errors.Is(err, terminal.ErrRecordingNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		klog.Errorf("open recording %s error: %v", record.StorageKey, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+
+	klog.Infof("User %s replay terminal session %s", c.MustGet("User").(*models.User).Name, record.SessionId)
+
+	c.Header("Content-Type", "application/x-asciicast")
+	if download, _ := strconv.ParseBool(c.Query("download")); download {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", record.SessionId+".cast"))
+	}
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, f); err != nil {
+		klog.Errorf("write recording %s error: %v", record.StorageKey, err)
+	}
+}
@@ -0,0 +1,133 @@
+package pod
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/JLPAY/gwayne/controllers/base"
+	"github.com/JLPAY/gwayne/models"
+	"github.com/JLPAY/gwayne/pkg/config"
+	"github.com/JLPAY/gwayne/pkg/terminal"
+	"github.com/gin-gonic/gin"
+	"k8s.io/klog/v2"
+)
+
+var (
+	sessionRegistry     *terminal.Registry
+	sessionRegistryOnce sync.Once
+)
+
+// getSessionRegistry 当前实例上活动的终端会话，限制从配置中读取
+func getSessionRegistry() *terminal.Registry {
+	sessionRegistryOnce.Do(func() {
+		sessionRegistry = terminal.NewRegistry(func() terminal.Limits {
+			conf := config.Conf.Terminal
+			return terminal.Limits{
+				IdleTimeout:   time.Duration(conf.IdleTimeoutSeconds) * time.Second,
+				MaxDuration:   time.Duration(conf.MaxDurationSeconds) * time.Second,
+				MaxPerUser:    conf.MaxSessionsPerUser,
+				MaxPerCluster: conf.MaxSessionsPerCluster,
+			}
+		})
+	})
+	return sessionRegistry
+}
+
+// getActiveSession 获取活动的终端会话，只有管理员可以操作
+func getActiveSession(c *gin.Context) (*models.User, *terminal.Session, bool) {
+	user := c.MustGet("User").(*models.User)
+	if !user.Admin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+		return nil, nil, false
+	}
+	session, err := getSessionRegistry().Get(c.Param("id"))
+	if errors.Is(err, terminal.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+	return user, session, true
+}
+
+// @Title ListSessions
+// @Description list the active terminal sessions on this instance, admin only
+// @Success 200 {object} []terminal.SessionInfo success
+// @router /terminal/sessions [get]
+func ListSessions(c *gin.Context) {
+	user := c.MustGet("User").(*models.User)
+	if !user.Admin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": getSessionRegistry().List()})
+}
+
+// @Title TerminateSession
+// @Description terminate an active terminal session, admin only
+// @Param	id		path 	string 	true		"the session id"
+// @Success 200 {object} "ok" success
+// @router /terminal/sessions/:id [delete]
+func TerminateSession(c *gin.Context) {
+	user, session, ok := getActiveSession(c)
+	if !ok {
+		return
+	}
+	info := session.Info()
+	session.Terminate("terminated by admin " + user.Name)
+	RecordAudit(user, models.AuditActionTerminalTerminate, info.Cluster, info.Namespace, info.Pod, info.Container, info.ID, 0, nil)
+	klog.Infof("Admin %s terminate terminal session %s of user %s", user.Name, info.ID, info.User)
+
+	c.JSON(http.StatusOK, gin.H{"data": "ok"})
+}
+
+// @Title ShadowSession
+// @Description watch the output of an active terminal session in read-only mode as server-sent events, admin only.
+// The first output event contains the recent output of the session, an end event is sent when the session ends.
+// @Param	id		path 	string 	true		"the session id"
+// @Success 200 {object} "the session output" success
+// @router /terminal/sessions/:id/shadow [get]
+func ShadowSession(c *gin.Context) {
+	user, session, ok := getActiveSession(c)
+	if !ok {
+		return
+	}
+	info := session.Info()
+	recent, output, cancel := session.Shadow()
+	defer cancel()
+	RecordAudit(user, models.AuditActionTerminalShadow, info.Cluster, info.Namespace, info.Pod, info.Container, info.ID, 0, nil)
+	klog.Infof("Admin %s shadow terminal session %s of user %s", user.Name, info.ID, info.User)
+
+	heartbeat := time.NewTicker(defaultLogHeartbeatSeconds * time.Second)
+	defer heartbeat.Stop()
+
+	base.StartSSE(c)
+	if err := base.WriteSSE(c.Writer, "", "output", gin.H{"data": string(recent)}); err != nil {
+		return
+	}
+	c.Writer.Flush()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case chunk, ok := <-output:
+			if !ok {
+				_ = base.WriteSSE(w, "", "end", gin.H{})
+				return false
+			}
+			if err := base.WriteSSE(w, "", "output", gin.H{"data": string(chunk)}); err != nil {
+				return false
+			}
+		case <-heartbeat.C:
+			if err := base.WriteSSEHeartbeat(w); err != nil {
+				return false
+			}
+		}
+		return true
+	})
+}
@@ -2,26 +2,25 @@ package pod
 
 import (
 	"bytes"
-	"crypto/md5"
-	"encoding/hex"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
-	"math/rand"
 	"net/http"
-	"strconv"
 	"sync"
 	"time"
 
 	"github.com/360yun/sockjs-go/sockjs"
-	"github.com/JLPAY/gwayne/pkg/config"
+	"github.com/JLPAY/gwayne/controllers/base"
+	"github.com/JLPAY/gwayne/models"
 	"github.com/JLPAY/gwayne/pkg/hack"
 	"github.com/JLPAY/gwayne/pkg/kubernetes/client"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/exec"
+	"github.com/JLPAY/gwayne/pkg/terminal"
 	"github.com/gin-gonic/gin"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/remotecommand"
 	"k8s.io/klog/v2"
@@ -39,6 +38,8 @@ type TerminalSession struct {
 	id            string                          // 会话 ID
 	sockJSSession sockjs.Session                  // WebSocket 会话
 	sizeChan      chan remotecommand.TerminalSize // 用于处理终端尺寸变化的通道
+	recorder      *terminal.Recorder              // 会话录像，未开启录像时为 nil
+	active        *terminal.Session               // 注册的活动会话，用于空闲检测和旁观
 }
 
 // TerminalMessage 定义了客户端发送的终端消息结构
@@ -76,6 +77,9 @@ func (t TerminalSession) Next() *remotecommand.TerminalSize {
 	select {
 	case size := <-t.sizeChan:
 		return &size
+	case <-t.active.Done():
+		// 会话结束，返回 nil 结束尺寸监听
+		return nil
 	}
 }
 
@@ -96,9 +100,14 @@ func (t TerminalSession) Read(p []byte) (int, error) {
 	switch msg.Op {
 	case "stdin":
 		// 将客户端输入数据复制到 p 缓冲区并返回
-		return copy(p, msg.Data), nil
+		n := copy(p, msg.Data)
+		t.active.Touch()
+		t.recorder.Input(p[:n])
+		return n, nil
 	case "resize":
 		// 将终端尺寸变化发送到 sizeChan
+		t.active.Touch()
+		t.recorder.Resize(msg.Cols, msg.Rows)
 		t.sizeChan <- remotecommand.TerminalSize{msg.Cols, msg.Rows}
 		return 0, nil
 	default:
@@ -108,6 +117,8 @@ func (t TerminalSession) Read(p []byte) (int, error) {
 
 // 将数据（如容器输出）写入 WebSocket 会话，发送给客户端
 func (t TerminalSession) Write(p []byte) (int, error) {
+	t.recorder.Output(p)
+	t.active.Broadcast(p)
 	msg, err := json.Marshal(TerminalMessage{
 		Op:   "stdout",
 		Data: string(p),
@@ -148,29 +159,21 @@ func preCheckShell(k8sClient *kubernetes.Clientset, cfg *rest.Config, namespace,
 
 	for _, shell := range validShells {
 		// 使用快速命令检测shell是否存在
-		req := k8sClient.CoreV1().RESTClient().Post().
-			Resource("pods").
-			Name(pod).
-			Namespace(namespace).
-			SubResource("exec")
-
-		req.VersionedParams(&corev1.PodExecOptions{
+		executor, err := exec.NewExecutor(k8sClient, cfg, namespace, pod, &corev1.PodExecOptions{
 			Container: container,
 			Command:   []string{"which", shell},
 			Stdin:     false,
 			Stdout:    true,
 			Stderr:    false,
 			TTY:       false,
-		}, scheme.ParameterCodec)
-
-		exec, err := remotecommand.NewSPDYExecutor(cfg, "POST", req.URL())
+		})
 		if err != nil {
 			continue
 		}
 
 		// 快速检测shell是否存在
 		var stdout bytes.Buffer
-		err = exec.Stream(remotecommand.StreamOptions{
+		err = executor.Stream(remotecommand.StreamOptions{
 			Stdout: &stdout,
 		})
 
@@ -229,24 +232,70 @@ func handleTerminalSession(session sockjs.Session) {
 		return
 	}
 
-	// 验证客户端传来的 token 是否有效
-	err = checkShellToken(tr.Token, tr.Namespace, tr.Pod)
+	// 兑换客户端传来的 ticket，连接的容器以 ticket 中的信息为准
+	ticket, err := getTicketIssuer().Redeem(tr.Token)
 	if err != nil {
-		klog.Error(http.StatusBadRequest, fmt.Sprintf("token (%s) not valid %v.", tr.Token, err))
+		klog.Warningf("handleTerminalSession: redeem ticket of session %s error: %v", tr.SessionId, err)
+		session.Close(2, err.Error())
 		return
 	}
 
-	manager, err := client.Manager(tr.Cluster)
+	manager, err := client.Manager(ticket.Cluster)
 	if err == nil {
 		ts := TerminalSession{
-			id:            tr.SessionId,
+			id:            ticket.SessionId,
 			sockJSSession: session,
 			sizeChan:      make(chan remotecommand.TerminalSize, 10), // 增加缓冲区大小
 		}
-		go WaitForTerminal(manager.Client, manager.Config, ts, tr.Namespace, tr.Pod, tr.Container, "")
+		// 空闲超时、超过最长时间或者被管理员关闭时，取消 exec 连接并关闭 WebSocket
+		ctx, cancel := context.WithCancel(context.Background())
+		registry := getSessionRegistry()
+		ts.active, err = registry.Register(terminal.SessionInfo{
+			ID:        ticket.SessionId,
+			User:      ticket.User,
+			Cluster:   ticket.Cluster,
+			Namespace: ticket.Namespace,
+			Pod:       ticket.Pod,
+			Container: ticket.Container,
+			Command:   ticket.Command,
+		}, func(reason string) {
+			klog.Infof("Close terminal session %s of user %s: %s", ticket.SessionId, ticket.User, reason)
+			cancel()
+			ts.Close(2, reason)
+		})
+		if err != nil {
+			cancel()
+			klog.Warningf("register terminal session %s error: %v", ticket.SessionId, err)
+			session.Close(2, err.Error())
+			return
+		}
+		klog.Infof("User %s open terminal session %s to pod %s/%s container %s in cluster %s",
+			ticket.User, ticket.SessionId, ticket.Namespace, ticket.Pod, ticket.Container, ticket.Cluster)
+		// 开启录像时，录像无法创建则不允许打开终端
+		record, err := startRecording(&ts, ticket)
+		if err != nil {
+			klog.Errorf("start recording of terminal session %s error: %v", ticket.SessionId, err)
+			cancel()
+			registry.Unregister(ticket.SessionId)
+			ts.Close(2, "unable to start session recording")
+			return
+		}
+		if ticket.NodeShell {
+			go keepNodeShellAlive(ctx, manager.Client, ticket)
+		}
+		go func() {
+			WaitForTerminal(ctx, manager.Client, manager.Config, ts, ticket)
+			cancel()
+			registry.Unregister(ticket.SessionId)
+			finishRecording(ts.recorder, record)
+			if ticket.NodeShell {
+				deleteNodeShellPod(manager.Client, ticket)
+			}
+		}()
 		return
 	} else {
-		klog.Error(http.StatusBadRequest, fmt.Sprintf("%s %v", tr.Cluster, err))
+		klog.Error(http.StatusBadRequest, fmt.Sprintf("%s %v", ticket.Cluster, err))
+		session.Close(2, err.Error())
 		return
 	}
 }
@@ -256,9 +305,9 @@ func CreateAttachHandler(path string) http.Handler {
 }
 
 // @Title Create terminal
-// @Param	cmd		query 	string	true		"the cmd you want to exec."
+// @Param	cmd		query 	string	false		"the shell to exec, bash or sh, detected automatically when empty."
 // @Param	container		query 	string	true		"the container name."
-// @Description create container terminal
+// @Description check the permission and issue a single-use ticket to open the container terminal
 // @router /:pod/terminal/namespaces/:namespace/clusters/:cluster [post]
 func Terminal(c *gin.Context) {
 	cluster := c.Param("cluster")
@@ -266,105 +315,120 @@ func Terminal(c *gin.Context) {
 	container := c.DefaultQuery("container", "")
 	namespace := c.Param("namespace")
 	cmd := c.DefaultQuery("cmd", "")
+	user := c.MustGet("User").(*models.User)
 
 	if pod == "" || container == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Pod and container are required!"})
 		return
 	}
+	if err := terminal.ValidateCommand(cmd); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !CheckTerminalAccess(c, user, cluster, namespace) {
+		return
+	}
 
-	sessionId, err := genTerminalSessionId()
+	manager, err := client.Manager(cluster)
+	if manager == nil || err != nil {
+		klog.Errorf("Failed to get manager for cluster: %s", cluster)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get manager"})
+		return
+	}
+	podObj, err := manager.Client.CoreV1().Pods(namespace).Get(c.Request.Context(), pod, metav1.GetOptions{})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if !checkNodeShellPod(c, user, cluster, podObj) {
+		return
+	}
+	if err := terminal.ValidateContainer(podObj, container); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	klog.V(2).Infof("sessionId: %s", sessionId)
-
-	result := TerminalResult{
-		SessionId: sessionId,
-		Token:     generateToken(namespace, pod),
+	IssueTerminalTicket(c, terminal.Ticket{
+		User:      user.Name,
 		Cluster:   cluster,
 		Namespace: namespace,
 		Pod:       pod,
 		Container: container,
-		Cmd:       cmd,
-	}
-
-	klog.V(2).Infof("result: %+v", result)
-
-	c.JSON(http.StatusOK, gin.H{"data": result})
+		Command:   cmd,
+	})
 }
 
-// token生成规则
-// 1. 拼接namespace、podName、unixtime(加600秒，十分钟期限)，平台appkey，并进行md5加密操作
-// 2. 取生成的32位加密字符串第12-20位，于unixtime进行拼接生成token
-func generateToken(namespace, pod string) string {
-	appKey := config.Conf.App.AppKey
-	endTime := time.Now().Unix() + 60*10
-	rawTokenKey := namespace + pod + strconv.FormatInt(endTime, 10) + appKey
-	md5Hash := md5.New()
-	md5Hash.Write([]byte(rawTokenKey))
-	cipher := md5Hash.Sum(nil)
-	cipherStr := hex.EncodeToString(cipher)
-	return cipherStr[12:20] + strconv.FormatInt(endTime, 10)
+// CheckTerminalAccess 检查用户是否可以在命名空间下打开终端，以及是否超过会话数量限制
+func CheckTerminalAccess(c *gin.Context, user *models.User, cluster, namespace string) bool {
+	if err := terminalPolicy().Allow(user.Admin, namespace); err != nil {
+		klog.Warningf("User %s open terminal in namespace %s of cluster %s denied: %v", user.Name, namespace, cluster, err)
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return false
+	}
+	// 提前检查会话数量，建立连接时还会再检查一次
+	if err := getSessionRegistry().Check(user.Name, cluster); err != nil {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
 }
 
-func checkShellToken(token string, namespace string, podName string) error {
-	endTimeRaw := []rune(token)
-	var endTime int64
-	var endTimeStr string
-	var err error
-
-	if len(endTimeRaw) > 8 {
-		endTimeStr = string(endTimeRaw[8:])
-		endTime, err = strconv.ParseInt(endTimeStr, 10, 64)
-		if err != nil {
-			return err
-		}
+// checkNodeShellPod 检查用户是否可以访问 Pod，节点终端的 Pod 只有管理员可以访问
+func checkNodeShellPod(c *gin.Context, user *models.User, cluster string, pod *corev1.Pod) bool {
+	if err := terminal.AllowNodeShellPod(user.Admin, pod); err != nil {
+		klog.Warningf("User %s access pod %s/%s of cluster %s denied: %v", user.Name, pod.Namespace, pod.Name, cluster, err)
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return false
 	}
-	ntime := time.Now().Unix()
+	return true
+}
 
-	if ntime > endTime {
-		return errors.New("token time expired")
+// IssueTerminalTicket 签发连接容器终端的 ticket，客户端使用 ticket 通过 SockJS 建立连接
+func IssueTerminalTicket(c *gin.Context, ticket terminal.Ticket) {
+	sessionId, err := terminal.NewSessionId()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ticket.SessionId = sessionId
+	token, err := getTicketIssuer().Issue(ticket)
+	if err != nil {
+		klog.Errorf("issue terminal ticket error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
-	rawToken := namespace + podName + endTimeStr + config.Conf.App.AppKey
-
-	md5Ctx := md5.New()
-	md5Ctx.Write([]byte(rawToken))
-	cipherToken := hex.EncodeToString(md5Ctx.Sum(nil))
+	klog.V(2).Infof("sessionId: %s", sessionId)
 
-	checkToken := string([]rune(cipherToken)[12:20]) + endTimeStr
-	if checkToken != token {
-		return errors.New("token not match")
+	result := TerminalResult{
+		SessionId: sessionId,
+		Token:     token,
+		Cluster:   ticket.Cluster,
+		Namespace: ticket.Namespace,
+		Pod:       ticket.Pod,
+		Container: ticket.Container,
+		Cmd:       ticket.Command,
 	}
-	return nil
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
 }
 
 // 开始建立ws连接
 // Kubernetes 中启动进程并通过 ptyHandler 进行流交互
-func startProcess(k8sClient *kubernetes.Clientset, cfg *rest.Config, cmd []string, ptyHandler PtyHandler, namespace, pod, container string) error {
-	req := k8sClient.CoreV1().RESTClient().Post().
-		Resource("pods").
-		Name(pod).
-		Namespace(namespace).
-		SubResource("exec")
-
-	req.VersionedParams(&corev1.PodExecOptions{
+func startProcess(ctx context.Context, k8sClient *kubernetes.Clientset, cfg *rest.Config, cmd []string, ptyHandler PtyHandler, namespace, pod, container string) error {
+	executor, err := exec.NewExecutor(k8sClient, cfg, namespace, pod, &corev1.PodExecOptions{
 		Container: container,
 		Command:   cmd,
 		Stdin:     true,
 		Stdout:    true,
 		Stderr:    true,
 		TTY:       true,
-	}, scheme.ParameterCodec)
-
-	exec, err := remotecommand.NewSPDYExecutor(cfg, "POST", req.URL())
+	})
 	if err != nil {
 		return err
 	}
 
-	err = exec.Stream(remotecommand.StreamOptions{
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{
 		Stdin:             ptyHandler,
 		Stdout:            ptyHandler,
 		Stderr:            ptyHandler,
@@ -378,16 +442,6 @@ func startProcess(k8sClient *kubernetes.Clientset, cfg *rest.Config, cmd []strin
 	return nil
 }
 
-func genTerminalSessionId() (string, error) {
-	bytes := make([]byte, 16)
-	if _, err := rand.Read(bytes); err != nil {
-		return "", err
-	}
-	id := make([]byte, hex.EncodedLen(len(bytes)))
-	hex.Encode(id, bytes)
-	return string(id), nil
-}
-
 func isValidShell(validShells []string, shell string) bool {
 	for _, validShell := range validShells {
 		if validShell == shell {
@@ -398,20 +452,22 @@ func isValidShell(validShells []string, shell string) bool {
 }
 
 // WaitForTerminal 等待并启动一个终端会话，使用预检测的shell优化性能
-func WaitForTerminal(k8sClient *kubernetes.Clientset, cfg *rest.Config, ts TerminalSession, namespace, pod, container, cmd string) {
-	var err error
-
-	if cmd != "" && isValidShell([]string{"bash", "sh"}, cmd) {
+func WaitForTerminal(ctx context.Context, k8sClient *kubernetes.Clientset, cfg *rest.Config, ts TerminalSession, ticket *terminal.Ticket) {
+	var cmds []string
+	switch {
+	case ticket.NodeShell:
+		// 节点终端通过 chroot 进入节点的根文件系统
+		cmds = terminal.NodeShellCommand(ticket.Command)
+	case ticket.Command != "" && isValidShell(terminal.Shells, ticket.Command):
 		// 使用指定的shell
-		cmds := []string{cmd}
-		err = startProcess(k8sClient, cfg, cmds, ts, namespace, pod, container)
-	} else {
+		cmds = []string{ticket.Command}
+	default:
 		// 使用预检测的shell，避免重复尝试
-		shell, _ := preCheckShell(k8sClient, cfg, namespace, pod, container)
-		cmds := []string{shell}
-		err = startProcess(k8sClient, cfg, cmds, ts, namespace, pod, container)
+		shell, _ := preCheckShell(k8sClient, cfg, ticket.Namespace, ticket.Pod, ticket.Container)
+		cmds = []string{shell}
 	}
 
+	err := startProcess(ctx, k8sClient, cfg, cmds, ts, ticket.Namespace, ticket.Pod, ticket.Container)
 	if err != nil {
 		// 启动失败，关闭并返回错误
 		ts.Close(2, err.Error())
@@ -0,0 +1,46 @@
+package pod
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"sync"
+	"time"
+
+	"github.com/JLPAY/gwayne/models"
+	"github.com/JLPAY/gwayne/pkg/config"
+	"github.com/JLPAY/gwayne/pkg/rsakey"
+	"github.com/JLPAY/gwayne/pkg/terminal"
+)
+
+var (
+	ticketIssuer     *terminal.TicketIssuer
+	ticketIssuerOnce sync.Once
+)
+
+// dbRedeemer 在数据库中记录已经使用的 ticket，多个实例之间共享
+type dbRedeemer struct{}
+
+func (dbRedeemer) Redeem(id string, expireTime time.Time) (bool, error) {
+	return models.RedeemTerminalTicket(id, expireTime)
+}
+
+// getTicketIssuer 返回终端 ticket 的签发者。
+// 签名密钥由 RSA 私钥派生，多个实例使用相同的密钥，并且与登录 token 的签名密钥不同，两者不能互相替代。
+func getTicketIssuer() *terminal.TicketIssuer {
+	ticketIssuerOnce.Do(func() {
+		mac := hmac.New(sha256.New, x509.MarshalPKCS1PrivateKey(rsakey.RsaPrivateKey))
+		mac.Write([]byte("gwayne terminal ticket"))
+		ttl := time.Duration(config.Conf.Terminal.TicketTTLSeconds) * time.Second
+		ticketIssuer = terminal.NewTicketIssuer(mac.Sum(nil), ttl, dbRedeemer{})
+	})
+	return ticketIssuer
+}
+
+// terminalPolicy 打开终端的权限策略
+func terminalPolicy() terminal.Policy {
+	return terminal.Policy{
+		AdminOnly:           config.Conf.Terminal.AdminOnly,
+		ProtectedNamespaces: terminal.ParseList(config.Conf.Terminal.ProtectedNamespaces),
+	}
+}
@@ -0,0 +1,87 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/JLPAY/gwayne/controllers/base"
+	"github.com/JLPAY/gwayne/models"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/client"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/apply"
+	"github.com/gin-gonic/gin"
+	"k8s.io/klog/v2"
+)
+
+// @Title Apply
+// @Description apply a multi-document YAML/JSON manifest with server-side apply in dependency order
+// @Param	cluster		path 	string	true		"the cluster name"
+// @Param	namespace		query 	string	false		"the default namespace for namespaced objects"
+// @Param	mode		query 	string	false		"report (default) or all"
+// @Param	force		query 	bool	false		"force conflicts"
+// @Param	dryRun		query 	string	false		"All to validate the manifest without persisting it"
+// @Param	manifest		body 	string	true		"the multi-document manifest"
+// @Success 200 {object} apply.Report success
+// @router /apply [post]
+func Apply(c *gin.Context) {
+	cluster := c.Param("cluster")
+	user := c.MustGet("User").(*models.User)
+
+	mode := apply.Mode(c.DefaultQuery("mode", string(apply.ModeReport)))
+	if mode != apply.ModeReport && mode != apply.ModeAll {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid mode %q, expected report or all", mode)})
+		return
+	}
+	force := false
+	if forceStr := c.Query("force"); forceStr != "" {
+		var err error
+		if force, err = strconv.ParseBool(forceStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid force in query."})
+			return
+		}
+	}
+
+	dryRun, err := base.ParseDryRun(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := c.GetRawData()
+	if err != nil || len(data) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid manifest"})
+		return
+	}
+	objs, err := apply.ParseManifests(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(objs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "manifest contains no objects"})
+		return
+	}
+
+	manager, err := client.Manager(cluster)
+	if err != nil {
+		klog.Errorf("Failed to get manager for cluster: %s, %v", cluster, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	klog.Infof("User %s apply %d objects to cluster %s, mode: %s, dryRun: %v", user.Name, len(objs), cluster, mode, dryRun)
+
+	applier := apply.NewApplier(manager.Client.Discovery(), manager.DynamicClient)
+	report := applier.Apply(c.Request.Context(), objs, apply.Options{
+		Namespace:    c.Query("namespace"),
+		Mode:         mode,
+		FieldManager: client.FieldManager,
+		Force:        force,
+		DryRun:       len(dryRun) > 0,
+	})
+	if report.Failed > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("%d of %d objects failed", report.Failed, len(objs)), "data": report})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
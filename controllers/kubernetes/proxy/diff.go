@@ -0,0 +1,105 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/JLPAY/gwayne/controllers/base"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/client"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/diff"
+	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+)
+
+// @Title Diff
+// @Description server-side dry-run the resource and diff it against the live object
+// @Param	cluster		path 	string	true		"the cluster name"
+// @Param	kind		path 	string	true		"the resource kind"
+// @Param	namespace		path 	string	true		"the namespace name"
+// @Param	name		path 	string	true		"the resource name"
+// @Param	strategy		query 	string	false		"update (default) or apply"
+// @Param	resource		body 	string	true		"the proposed kubernetes resource"
+// @Success 200 {object} diff.Result success
+// @router /:name/diff [post]
+func Diff(c *gin.Context) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespaceName")
+	name := c.Param("name")
+	kind := c.Param("kind")
+
+	strategy := c.DefaultQuery("strategy", "update")
+	if strategy != "update" && strategy != "apply" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid strategy %q, expected update or apply", strategy)})
+		return
+	}
+
+	data, err := base.ReadBodyJSON(c)
+	if err != nil || len(data) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid resource object"})
+		return
+	}
+
+	manager, err := client.Manager(cluster)
+	if err != nil || manager.KubeClient == nil {
+		klog.Errorf("Failed to get kubeClient for cluster: %s", cluster)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get kubeClient"})
+		return
+	}
+	kubeClient := manager.KubeClient
+	resource, err := kubeClient.GVRK(kind)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 线上对象直接从 apiserver 获取，informer 缓存可能落后于 dry-run 所基于的当前对象
+	var resourceInterface dynamic.ResourceInterface
+	if resource.Namespaced {
+		resourceInterface = manager.DynamicClient.Resource(resource.GroupVersionResourceKind.GroupVersionResource).Namespace(namespace)
+	} else {
+		resourceInterface = manager.DynamicClient.Resource(resource.GroupVersionResourceKind.GroupVersionResource)
+	}
+
+	dryRun := []string{metav1.DryRunAll}
+	var proposed *runtime.Unknown
+	live, err := resourceInterface.Get(context.TODO(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		// 资源不存在时与新建后的对象比较
+		live = nil
+		proposed, err = kubeClient.Create(kind, namespace, &runtime.Unknown{Raw: data}, metav1.CreateOptions{DryRun: dryRun})
+	} else if err == nil && strategy == "apply" {
+		force := true
+		proposed, err = kubeClient.Patch(kind, namespace, name, types.ApplyPatchType, data,
+			metav1.PatchOptions{FieldManager: client.FieldManager, Force: &force, DryRun: dryRun})
+	} else if err == nil {
+		proposed, err = kubeClient.Update(kind, namespace, name, &runtime.Unknown{Raw: data}, metav1.UpdateOptions{DryRun: dryRun})
+	}
+	if err != nil {
+		klog.Errorf("Dry-run kubernetes resource (%s:%s:%s) from cluster (%s) error: %v", kind, namespace, name, cluster, err)
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := diff.Compare(live, proposed)
+	if err != nil {
+		klog.Errorf("Diff kubernetes resource (%s:%s:%s) from cluster (%s) error: %v", kind, namespace, name, cluster, err)
+		c.JSON(diffErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// diffErrorStatus 返回比较差异出错时的 HTTP 状态码
+func diffErrorStatus(err error) int {
+	if errors.Is(err, diff.ErrTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusInternalServerError
+}
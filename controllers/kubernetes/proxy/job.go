@@ -0,0 +1,315 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/JLPAY/gwayne/controllers/base"
+	"github.com/JLPAY/gwayne/models"
+	"github.com/JLPAY/gwayne/pkg/hack"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/client"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/client/api"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/job"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/log"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/pod"
+	"github.com/gin-gonic/gin"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// 日志接口默认返回的行数
+const defaultJobLogTailLines = 100
+
+// CronJobJob CronJob 创建的 Job 及其 Pod
+type CronJobJob struct {
+	Job  batchv1.Job   `json:"job"`
+	Pods []*corev1.Pod `json:"pods"`
+}
+
+// ContainerLog 单个容器的日志
+type ContainerLog struct {
+	Job       string `json:"job"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	Log       string `json:"log"`
+	Error     string `json:"error,omitempty"`
+}
+
+// requireKind 校验路径中的资源类型，不匹配时返回 400
+func requireKind(c *gin.Context, kinds ...api.ResourceName) bool {
+	kind := c.Param("kind")
+	for _, k := range kinds {
+		if kind == k {
+			return true
+		}
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unsupported kind %q, expected %v", kind, kinds)})
+	return false
+}
+
+// @Title TriggerCronJob
+// @Description create a job from the cronjob's jobTemplate immediately
+// @Param	cluster		path 	string	true		"the cluster name"
+// @Param	namespace		path 	string	true		"the namespace name"
+// @Param	kind		path 	string	true		"cronjobs"
+// @Param	name		path 	string	true		"the cronjob name"
+// @Success 201 {object} batchv1.Job success
+// @router /namespaces/:namespaceName/:kind/:name/trigger [post]
+func TriggerCronJob(c *gin.Context) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespaceName")
+	name := c.Param("name")
+	user := c.MustGet("User").(*models.User)
+
+	if !requireKind(c, api.ResourceNameCronJob) {
+		return
+	}
+	clientset, err := client.Client(cluster)
+	if err != nil {
+		klog.Errorf("Failed to get clientset for cluster: %s", cluster)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get clientset"})
+		return
+	}
+
+	result, err := job.TriggerCronJob(c.Request.Context(), clientset, namespace, name)
+	if err != nil {
+		klog.Errorf("Trigger cronjob %s/%s in cluster %s error: %v", namespace, name, cluster, err)
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	klog.Infof("User %s trigger cronjob %s/%s in cluster %s, job %s created", user.Name, namespace, name, cluster, result.Name)
+	c.JSON(http.StatusCreated, gin.H{"data": result})
+}
+
+// @Title SuspendCronJob
+// @Description set spec.suspend of the cronjob to true
+// @Param	cluster		path 	string	true		"the cluster name"
+// @Param	namespace		path 	string	true		"the namespace name"
+// @Param	kind		path 	string	true		"cronjobs"
+// @Param	name		path 	string	true		"the cronjob name"
+// @Success 200 {object} batchv1.CronJob success
+// @router /namespaces/:namespaceName/:kind/:name/suspend [put]
+func SuspendCronJob(c *gin.Context) {
+	setCronJobSuspend(c, true)
+}
+
+// @Title ResumeCronJob
+// @Description set spec.suspend of the cronjob to false
+// @Param	cluster		path 	string	true		"the cluster name"
+// @Param	namespace		path 	string	true		"the namespace name"
+// @Param	kind		path 	string	true		"cronjobs"
+// @Param	name		path 	string	true		"the cronjob name"
+// @Success 200 {object} batchv1.CronJob success
+// @router /namespaces/:namespaceName/:kind/:name/resume [put]
+func ResumeCronJob(c *gin.Context) {
+	setCronJobSuspend(c, false)
+}
+
+func setCronJobSuspend(c *gin.Context, suspend bool) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespaceName")
+	name := c.Param("name")
+	user := c.MustGet("User").(*models.User)
+
+	if !requireKind(c, api.ResourceNameCronJob) {
+		return
+	}
+	clientset, err := client.Client(cluster)
+	if err != nil {
+		klog.Errorf("Failed to get clientset for cluster: %s", cluster)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get clientset"})
+		return
+	}
+
+	result, err := job.SetCronJobSuspend(c.Request.Context(), clientset, namespace, name, suspend)
+	if err != nil {
+		klog.Errorf("Set suspend=%t of cronjob %s/%s in cluster %s error: %v", suspend, namespace, name, cluster, err)
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	klog.Infof("User %s set suspend=%t of cronjob %s/%s in cluster %s", user.Name, suspend, namespace, name, cluster)
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// @Title RerunJob
+// @Description create a new job with a fresh name from a finished job
+// @Param	cluster		path 	string	true		"the cluster name"
+// @Param	namespace		path 	string	true		"the namespace name"
+// @Param	kind		path 	string	true		"jobs"
+// @Param	name		path 	string	true		"the job name"
+// @Success 201 {object} batchv1.Job success
+// @router /namespaces/:namespaceName/:kind/:name/rerun [post]
+func RerunJob(c *gin.Context) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespaceName")
+	name := c.Param("name")
+	user := c.MustGet("User").(*models.User)
+
+	if !requireKind(c, api.ResourceNameJob) {
+		return
+	}
+	clientset, err := client.Client(cluster)
+	if err != nil {
+		klog.Errorf("Failed to get clientset for cluster: %s", cluster)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get clientset"})
+		return
+	}
+
+	result, err := job.RerunJob(c.Request.Context(), clientset, namespace, name)
+	if err != nil {
+		klog.Errorf("Rerun job %s/%s in cluster %s error: %v", namespace, name, cluster, err)
+		status := base.KubeErrorStatus(err)
+		if errors.Is(err, job.ErrJobNotFinished) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	klog.Infof("User %s rerun job %s/%s in cluster %s, job %s created", user.Name, namespace, name, cluster, result.Name)
+	c.JSON(http.StatusCreated, gin.H{"data": result})
+}
+
+// @Title ListCronJobJobs
+// @Description list the jobs created by the cronjob together with their pods, newest first
+// @Param	cluster		path 	string	true		"the cluster name"
+// @Param	namespace		path 	string	true		"the namespace name"
+// @Param	kind		path 	string	true		"cronjobs"
+// @Param	name		path 	string	true		"the cronjob name"
+// @Success 200 {object} []CronJobJob success
+// @router /namespaces/:namespaceName/:kind/:name/jobs [get]
+func ListCronJobJobs(c *gin.Context) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespaceName")
+	name := c.Param("name")
+
+	if !requireKind(c, api.ResourceNameCronJob) {
+		return
+	}
+	manager, err := client.Manager(cluster)
+	if err != nil {
+		klog.Errorf("Failed to get manager for cluster: %s, %v", cluster, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	jobs, err := job.ListCronJobJobs(c.Request.Context(), manager.Client, namespace, name)
+	if err != nil {
+		klog.Errorf("List jobs of cronjob %s/%s in cluster %s error: %v", namespace, name, cluster, err)
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	result := make([]CronJobJob, 0, len(jobs))
+	for _, j := range jobs {
+		pods, err := pod.GetPodListByType(manager.KubeClient, namespace, j.Name, api.ResourceNameJob)
+		if err != nil {
+			klog.Errorf("List pods of job %s/%s in cluster %s error: %v", namespace, j.Name, cluster, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		result = append(result, CronJobJob{Job: j, Pods: pods})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// @Title ListJobLogs
+// @Description get the logs of the pods belonging to a job, or to a job of the cronjob (the newest one by default)
+// @Param	cluster		path 	string	true		"the cluster name"
+// @Param	namespace		path 	string	true		"the namespace name"
+// @Param	kind		path 	string	true		"cronjobs or jobs"
+// @Param	name		path 	string	true		"the resource name"
+// @Param	job		query 	string	false		"the job of the cronjob, defaults to the newest job"
+// @Param	container		query 	string	false		"the container name, defaults to all containers"
+// @Param	tailLines		query 	int	false		"log tail lines, default 100"
+// @Success 200 {object} []ContainerLog success
+// @router /namespaces/:namespaceName/:kind/:name/logs [get]
+func ListJobLogs(c *gin.Context) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespaceName")
+	name := c.Param("name")
+	kind := c.Param("kind")
+
+	if !requireKind(c, api.ResourceNameCronJob, api.ResourceNameJob) {
+		return
+	}
+	tailLines := int64(defaultJobLogTailLines)
+	if value := c.Query("tailLines"); value != "" {
+		lines, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || lines <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tailLines parameter"})
+			return
+		}
+		tailLines = lines
+	}
+
+	manager, err := client.Manager(cluster)
+	if err != nil {
+		klog.Errorf("Failed to get manager for cluster: %s, %v", cluster, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	jobName := name
+	if kind == api.ResourceNameCronJob {
+		jobs, err := job.ListCronJobJobs(c.Request.Context(), manager.Client, namespace, name)
+		if err != nil {
+			klog.Errorf("List jobs of cronjob %s/%s in cluster %s error: %v", namespace, name, cluster, err)
+			c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		jobName = c.Query("job")
+		if jobName == "" {
+			if len(jobs) == 0 {
+				c.JSON(http.StatusOK, gin.H{"data": []ContainerLog{}})
+				return
+			}
+			jobName = jobs[0].Name
+		} else if !containsJob(jobs, jobName) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("job %s does not belong to cronjob %s", jobName, name)})
+			return
+		}
+	}
+
+	pods, err := pod.GetPodListByType(manager.KubeClient, namespace, jobName, api.ResourceNameJob)
+	if err != nil {
+		klog.Errorf("List pods of job %s/%s in cluster %s error: %v", namespace, jobName, cluster, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	container := c.Query("container")
+	result := make([]ContainerLog, 0, len(pods))
+	for _, p := range pods {
+		for _, ct := range p.Spec.Containers {
+			if container != "" && ct.Name != container {
+				continue
+			}
+			entry := ContainerLog{Job: jobName, Pod: p.Name, Container: ct.Name}
+			// 单个容器获取失败（例如尚未启动）不影响其他容器
+			logs, err := log.GetLogsByPod(manager.Client, namespace, p.Name, &corev1.PodLogOptions{
+				Container: ct.Name,
+				TailLines: &tailLines,
+			})
+			if err != nil {
+				klog.Warningf("Get logs of pod %s/%s container %s in cluster %s error: %v", namespace, p.Name, ct.Name, cluster, err)
+				entry.Error = err.Error()
+			} else {
+				entry.Log = hack.String(logs)
+			}
+			result = append(result, entry)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+func containsJob(jobs []batchv1.Job, name string) bool {
+	for _, j := range jobs {
+		if j.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,178 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/JLPAY/gwayne/controllers/base"
+	"github.com/JLPAY/gwayne/models"
+	"github.com/JLPAY/gwayne/pkg/config"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/client"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/client/api"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/log"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/pod"
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// 每个 SSE 事件最多合并的日志条数
+const maxEntriesPerEvent = 200
+
+// logTargets 返回工作负载下可以读取日志的容器，follow 模式下只返回运行中的容器，其余容器在启动后加入
+func logTargets(pods []*corev1.Pod, container string, follow bool) []log.Target {
+	targets := make([]log.Target, 0)
+	for _, p := range pods {
+		for _, status := range p.Status.ContainerStatuses {
+			if container != "" && status.Name != container {
+				continue
+			}
+			if status.State.Running == nil && (follow || status.State.Terminated == nil) {
+				continue
+			}
+			targets = append(targets, log.Target{Pod: p.Name, Container: status.Name})
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Pod != targets[j].Pod {
+			return targets[i].Pod < targets[j].Pod
+		}
+		return targets[i].Container < targets[j].Container
+	})
+	return targets
+}
+
+// @Title AggregateLogs
+// @Description stream the logs of all pods of a workload merged by time via Server-Sent Events.
+// "log" events carry a batch of entries prefixed with pod and container, "join", "leave", "limit" and "error" events are notices, the stream ends with an "end" event.
+// @Param	cluster		path 	string	true		"the cluster name"
+// @Param	namespace		path 	string	true		"the namespace name"
+// @Param	kind		path 	string	true		"deployments, statefulsets, daemonsets or jobs"
+// @Param	name		path 	string	true		"the resource name"
+// @Param	container		query 	string	false		"only read the given container"
+// @Param	follow		query 	bool 	false		"follow the logs, new pods join the stream as they start"
+// @Param	sinceSeconds		query 	int 	false		"only return logs newer than a relative duration in seconds"
+// @Param	sinceTime		query 	string 	false		"only return logs after a RFC3339 timestamp"
+// @Param	tailLines		query 	int 	false		"log tail lines of every container"
+// @Param	timestamps		query 	bool 	false		"keep the RFC3339 timestamp at the beginning of every line"
+// @Param	limitBytes		query 	int 	false		"the number of bytes to read from every container"
+// @Success 200 {object} "text/event-stream" success
+// @router /namespaces/:namespaceName/:kind/:name/logs/stream [get]
+func AggregateLogs(c *gin.Context) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespaceName")
+	name := c.Param("name")
+	kind := c.Param("kind")
+	container := c.Query("container")
+	user := c.MustGet("User").(*models.User)
+
+	if !requireKind(c, api.ResourceNameDeployment, api.ResourceNameStatefulSet, api.ResourceNameDaemonSet, api.ResourceNameJob) {
+		return
+	}
+	opt, err := log.ParseOptions(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if opt.Previous {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "previous is not supported when aggregating logs"})
+		return
+	}
+
+	manager, err := client.Manager(cluster)
+	if err != nil {
+		klog.Errorf("Failed to get manager for cluster: %s, %v", cluster, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// 先确认工作负载存在，之后的 Pod 列表从缓存中获取
+	pods, err := pod.GetPodListByType(manager.KubeClient, namespace, name, kind)
+	if err != nil {
+		klog.Errorf("List pods of %s %s/%s in cluster %s error: %v", kind, namespace, name, cluster, err)
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	logConf := config.Conf.PodLog
+	release, err := log.StreamLimiter.Acquire(user.Name, logConf.MaxStreamsPerUser)
+	if err != nil {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many log streams"})
+		return
+	}
+	defer release()
+
+	aggregator := log.Aggregate(c.Request.Context(), manager.Client, namespace, log.AggregateOptions{
+		LogOptions:  *opt,
+		MaxStreams:  logConf.MaxStreamsPerRequest,
+		BufferLines: logConf.BufferLines,
+		Discover: func() ([]log.Target, error) {
+			if pods != nil {
+				// 第一次使用上面已经获取的 Pod 列表
+				current := pods
+				pods = nil
+				return logTargets(current, container, opt.Follow), nil
+			}
+			current, err := pod.GetPodListByType(manager.KubeClient, namespace, name, kind)
+			if err != nil {
+				return nil, err
+			}
+			return logTargets(current, container, opt.Follow), nil
+		},
+	})
+
+	heartbeatSeconds := logConf.HeartbeatSeconds
+	if heartbeatSeconds <= 0 {
+		heartbeatSeconds = defaultHeartbeatSeconds
+	}
+	heartbeat := time.NewTicker(time.Duration(heartbeatSeconds) * time.Second)
+	defer heartbeat.Stop()
+
+	klog.V(2).Infof("User %s aggregate logs of %s %s/%s in cluster %s", user.Name, kind, namespace, name, cluster)
+
+	base.StartSSE(c)
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-aggregator.Events():
+			if !ok {
+				_ = base.WriteSSE(w, "", "end", gin.H{})
+				return false
+			}
+			if event.Type != log.EventLog {
+				return base.WriteSSE(w, "", string(event.Type), event) == nil
+			}
+			// 合并已经到达的日志，遇到通知类事件时先发送已合并的日志
+			entries := []log.Event{event}
+			for len(entries) < maxEntriesPerEvent && len(aggregator.Events()) > 0 {
+				next, ok := <-aggregator.Events()
+				if !ok {
+					break
+				}
+				if next.Type != log.EventLog {
+					if err := base.WriteSSE(w, "", "log", gin.H{"entries": entries}); err != nil {
+						return false
+					}
+					entries = nil
+					if err := base.WriteSSE(w, "", string(next.Type), next); err != nil {
+						return false
+					}
+					break
+				}
+				entries = append(entries, next)
+			}
+			if len(entries) > 0 {
+				if err := base.WriteSSE(w, "", "log", gin.H{"entries": entries}); err != nil {
+					return false
+				}
+			}
+		case <-heartbeat.C:
+			if err := base.WriteSSEHeartbeat(w); err != nil {
+				return false
+			}
+		}
+		return true
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
 )
 
@@ -77,7 +78,7 @@ func Get(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": result})
+	base.RenderObject(c, http.StatusOK, result)
 }
 
 func List(c *gin.Context) {
@@ -99,7 +100,7 @@ func List(c *gin.Context) {
 	result, err := proxy.GetPage(kubeClient, kind, namespace, param)
 	if err != nil {
 		klog.Errorf("List kubernetes resource (%s:%s) from cluster (%s) error: %v", kind, namespace, cluster, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(base.QueryErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -115,6 +116,7 @@ func List(c *gin.Context) {
 // @Param	name		path 	string	true		"the resource name"
 // @Param	resource		body 	string	false		"the kubernetes resource"
 // @Success 200 {string} delete success!
+// @Param	dryRun		query 	string	false		"All to run the request on the server without persisting it"
 // @router / [post]
 func Create(c *gin.Context) {
 	// 获取路径参数
@@ -124,13 +126,19 @@ func Create(c *gin.Context) {
 
 	// 解析请求体
 	var object runtime.Unknown
-	if err := c.BindJSON(&object); err != nil {
+	if err := base.BindObject(c, &object); err != nil {
 		// 处理解析错误
 		klog.Errorf("Create kubernetes resource (%s:%s) from cluster (%s) error. %v", kind, namespace, cluster, err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
 		return
 	}
 
+	dryRun, err := base.ParseDryRun(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 获取 Kubernetes 客户端
 	kubeClient, err := client.KubeClient(cluster)
 	if kubeClient == nil || err != nil {
@@ -140,7 +148,7 @@ func Create(c *gin.Context) {
 	}
 
 	// 调用 Kubernetes 客户端的 Create 方法
-	result, err := kubeClient.Create(kind, namespace, &object)
+	result, err := kubeClient.Create(kind, namespace, &object, metav1.CreateOptions{DryRun: dryRun})
 	if err != nil {
 		// 处理错误
 		klog.Errorf("Error creating resource (%s:%s) in cluster (%s): %v", kind, namespace, cluster, err)
@@ -148,7 +156,7 @@ func Create(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": result})
+	base.RenderObject(c, http.StatusOK, result)
 }
 
 // @Title Update
@@ -159,6 +167,7 @@ func Create(c *gin.Context) {
 // @Param	name		path 	string	true		"the resource name"
 // @Param	resource		body 	string	false		"the kubernetes resource"
 // @Success 200 {string} delete success!
+// @Param	dryRun		query 	string	false		"All to run the request on the server without persisting it"
 // @router /:name [put]
 func Put(c *gin.Context) {
 	// 获取查询参数
@@ -169,13 +178,19 @@ func Put(c *gin.Context) {
 
 	// 解析请求体中的资源对象
 	var object runtime.Unknown
-	if err := c.ShouldBindJSON(&object); err != nil {
+	if err := base.BindObject(c, &object); err != nil {
 		// 请求体无法解析为 JSON 时，返回 400 错误
 		klog.Errorf("Update kubernetes resource (%s:%s:%s) from cluster (%s) error. %v", kind, namespace, name, cluster, err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid resource object"})
 		return
 	}
 
+	dryRun, err := base.ParseDryRun(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 获取 Kubernetes 客户端
 	kubeClient, err := client.KubeClient(cluster)
 	if kubeClient == nil || err != nil {
@@ -184,15 +199,98 @@ func Put(c *gin.Context) {
 		return
 	}
 
-	result, err := kubeClient.Update(kind, namespace, name, &object)
+	result, err := kubeClient.Update(kind, namespace, name, &object, metav1.UpdateOptions{DryRun: dryRun})
 	if err != nil {
-		// 记录错误日志并返回 500 错误
+		// 记录错误日志，资源已被修改（resourceVersion 不一致）时返回 409
 		klog.Errorf("Update kubernetes resource (%s:%s:%s) from cluster (%s) error: %v", kind, namespace, name, cluster, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update resource"})
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": result})
+	base.RenderObject(c, http.StatusOK, result)
+}
+
+// patch 类型与 Content-Type 的对应关系，也可以通过 type 参数指定
+var patchTypes = map[string]types.PatchType{
+	"json":                                types.JSONPatchType,
+	"merge":                               types.MergePatchType,
+	"strategic":                           types.StrategicMergePatchType,
+	"apply":                               types.ApplyPatchType,
+	string(types.JSONPatchType):           types.JSONPatchType,
+	string(types.MergePatchType):          types.MergePatchType,
+	string(types.StrategicMergePatchType): types.StrategicMergePatchType,
+	string(types.ApplyPatchType):          types.ApplyPatchType,
+	"application/apply-patch+json":        types.ApplyPatchType,
+}
+
+// @Title Patch
+// @Description patch the resource, support json patch, merge patch, strategic merge patch and server-side apply
+// @Param	cluster		path 	string	true		"the cluster name"
+// @Param	namespace		path 	string	true		"the namespace name"
+// @Param	kind		path 	string	true		"the resource kind"
+// @Param	name		path 	string	true		"the resource name"
+// @Param	type		query 	string	false		"json, merge, strategic or apply, default by Content-Type"
+// @Param	force		query 	bool	false		"force conflicts when server-side apply"
+// @Param	fieldManager		query 	string	false		"the field manager, default gwayne"
+// @Success 200 {object}  success
+// @Param	dryRun		query 	string	false		"All to run the request on the server without persisting it"
+// @router /:name [patch]
+func Patch(c *gin.Context) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespaceName")
+	name := c.Param("name")
+	kind := c.Param("kind")
+
+	patchTypeName := c.Query("type")
+	if patchTypeName == "" {
+		patchTypeName = c.ContentType()
+	}
+	patchType, ok := patchTypes[patchTypeName]
+	if !ok {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": fmt.Sprintf("unsupported patch type %q", patchTypeName)})
+		return
+	}
+
+	data, err := c.GetRawData()
+	if err != nil || len(data) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patch body"})
+		return
+	}
+
+	dryRun, err := base.ParseDryRun(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	options := metav1.PatchOptions{FieldManager: c.Query("fieldManager"), DryRun: dryRun}
+	if force := c.Query("force"); force != "" {
+		if patchType != types.ApplyPatchType {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "force is only supported for server-side apply"})
+			return
+		}
+		forceBool, err := strconv.ParseBool(force)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid force in query."})
+			return
+		}
+		options.Force = &forceBool
+	}
+
+	kubeClient, err := client.KubeClient(cluster)
+	if kubeClient == nil || err != nil {
+		klog.Errorf("Failed to get kubeClient for cluster: %s", cluster)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get kubeClient"})
+		return
+	}
+
+	result, err := kubeClient.Patch(kind, namespace, name, patchType, data, options)
+	if err != nil {
+		klog.Errorf("Patch kubernetes resource (%s:%s:%s) from cluster (%s) error: %v", kind, namespace, name, cluster, err)
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	base.RenderObject(c, http.StatusOK, result)
 }
 
 // @Title Delete
@@ -203,6 +301,7 @@ func Put(c *gin.Context) {
 // @Param	name		path 	string	true		"the name want to delete"
 // @Param	force		query 	bool	false		"force to delete the resource from etcd."
 // @Success 200 {string} delete success!
+// @Param	dryRun		query 	string	false		"All to run the request on the server without persisting it"
 // @router /:name [delete]
 func Delete(c *gin.Context) {
 	// 获取路径参数
@@ -234,6 +333,13 @@ func Delete(c *gin.Context) {
 		}
 	}
 
+	dryRun, err := base.ParseDryRun(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defaultDeleteOptions.DryRun = dryRun
+
 	// 获取 Kubernetes 客户端
 	kubeClient, err := client.KubeClient(cluster)
 	if kubeClient == nil || err != nil {
@@ -270,7 +376,7 @@ func NamespacesList(c *gin.Context) {
 	result, err := proxy.GetPage(kubeClient, kind, namespace, param)
 	if err != nil {
 		klog.Errorf("List kubernetes resource (%s:%s) from cluster (%s) error: %v", kind, namespace, cluster, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(base.QueryErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -302,7 +408,7 @@ func NamespacesGet(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": result})
+	base.RenderObject(c, http.StatusOK, result)
 }
 
 func NamespacesCreate(c *gin.Context) {
@@ -313,13 +419,19 @@ func NamespacesCreate(c *gin.Context) {
 
 	// 解析请求体
 	var object runtime.Unknown
-	if err := c.BindJSON(&object); err != nil {
+	if err := base.BindObject(c, &object); err != nil {
 		// 处理解析错误
 		klog.Errorf("Create kubernetes resource (%s:%s) from cluster (%s) error. %v", kind, namespace, cluster, err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
 		return
 	}
 
+	dryRun, err := base.ParseDryRun(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 获取 Kubernetes 客户端
 	kubeClient, err := client.KubeClient(cluster)
 	if kubeClient == nil || err != nil {
@@ -329,7 +441,7 @@ func NamespacesCreate(c *gin.Context) {
 	}
 
 	// 调用 Kubernetes 客户端的 Create 方法
-	result, err := kubeClient.Create(kind, namespace, &object)
+	result, err := kubeClient.Create(kind, namespace, &object, metav1.CreateOptions{DryRun: dryRun})
 	if err != nil {
 		// 处理错误
 		klog.Errorf("Error creating resource (%s:%s) in cluster (%s): %v", kind, namespace, cluster, err)
@@ -337,7 +449,7 @@ func NamespacesCreate(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": result})
+	base.RenderObject(c, http.StatusOK, result)
 }
 
 func NamespacesPut(c *gin.Context) {
@@ -349,13 +461,19 @@ func NamespacesPut(c *gin.Context) {
 
 	// 解析请求体中的资源对象
 	var object runtime.Unknown
-	if err := c.ShouldBindJSON(&object); err != nil {
+	if err := base.BindObject(c, &object); err != nil {
 		// 请求体无法解析为 JSON 时，返回 400 错误
 		klog.Errorf("Update kubernetes resource (%s:%s:%s) from cluster (%s) error. %v", kind, namespace, name, cluster, err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid resource object"})
 		return
 	}
 
+	dryRun, err := base.ParseDryRun(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 获取 Kubernetes 客户端
 	kubeClient, err := client.KubeClient(cluster)
 	if kubeClient == nil || err != nil {
@@ -364,15 +482,15 @@ func NamespacesPut(c *gin.Context) {
 		return
 	}
 
-	result, err := kubeClient.Update(kind, namespace, name, &object)
+	result, err := kubeClient.Update(kind, namespace, name, &object, metav1.UpdateOptions{DryRun: dryRun})
 	if err != nil {
-		// 记录错误日志并返回 500 错误
+		// 记录错误日志，资源已被修改（resourceVersion 不一致）时返回 409
 		klog.Errorf("Update kubernetes resource (%s:%s:%s) from cluster (%s) error: %v", kind, namespace, name, cluster, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update resource"})
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": result})
+	base.RenderObject(c, http.StatusOK, result)
 }
 
 func NamespacesDelete(c *gin.Context) {
@@ -405,6 +523,13 @@ func NamespacesDelete(c *gin.Context) {
 		}
 	}
 
+	dryRun, err := base.ParseDryRun(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defaultDeleteOptions.DryRun = dryRun
+
 	// 获取 Kubernetes 客户端
 	kubeClient, err := client.KubeClient(cluster)
 	if kubeClient == nil || err != nil {
@@ -0,0 +1,123 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/JLPAY/gwayne/controllers/base"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/client"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/client/api"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/pod"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/relation"
+	"github.com/gin-gonic/gin"
+	"k8s.io/klog/v2"
+)
+
+// @Title ListWorkloadPods
+// @Description list the pods belonging to a workload, deployments through replicasets and cronjobs through jobs
+// @Param	cluster		path 	string	true		"the cluster name"
+// @Param	namespace		path 	string	true		"the namespace name"
+// @Param	kind		path 	string	true		"deployments, statefulsets, daemonsets, cronjobs, jobs or pods"
+// @Param	name		path 	string	true		"the resource name"
+// @Param	pageNo		query 	int	false		"the page current no"
+// @Param	pageSize		query 	int	false		"the page size"
+// @Success 200 {object} pagequery.Page success
+// @router /namespaces/:namespaceName/:kind/:name/pods [get]
+func ListWorkloadPods(c *gin.Context) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespaceName")
+	name := c.Param("name")
+	kind := c.Param("kind")
+
+	if !requireKind(c, api.ResourceNameDeployment, api.ResourceNameStatefulSet, api.ResourceNameDaemonSet,
+		api.ResourceNameCronJob, api.ResourceNameJob, api.ResourceNamePod) {
+		return
+	}
+	kubeClient, err := client.KubeClient(cluster)
+	if err != nil {
+		klog.Errorf("Failed to get kubeClient for cluster: %s, %v", cluster, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := pod.GetPodListPageByType(kubeClient, namespace, name, kind, base.BuildQueryParam(c))
+	if err != nil {
+		klog.Errorf("List pods of %s %s/%s in cluster %s error: %v", kind, namespace, name, cluster, err)
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// @Title WorkloadTree
+// @Description get the owner-reference tree of a workload together with the services, ingresses, hpas and pvcs selecting or referencing it
+// @Param	cluster		path 	string	true		"the cluster name"
+// @Param	namespace		path 	string	true		"the namespace name"
+// @Param	kind		path 	string	true		"deployments, statefulsets, daemonsets, replicasets, jobs or cronjobs"
+// @Param	name		path 	string	true		"the resource name"
+// @Success 200 {object} relation.Node success
+// @router /namespaces/:namespaceName/:kind/:name/tree [get]
+func WorkloadTree(c *gin.Context) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespaceName")
+	name := c.Param("name")
+	kind := c.Param("kind")
+
+	kubeClient, err := client.KubeClient(cluster)
+	if err != nil {
+		klog.Errorf("Failed to get kubeClient for cluster: %s, %v", cluster, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tree, err := relation.Tree(kubeClient, kind, namespace, name)
+	if err != nil {
+		klog.Errorf("Get relation tree of %s %s/%s in cluster %s error: %v", kind, namespace, name, cluster, err)
+		status := base.KubeErrorStatus(err)
+		if errors.Is(err, relation.ErrUnsupportedKind) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": tree})
+}
+
+// @Title NamespaceTopology
+// @Description get the resource dependency graph of a namespace as nodes/edges json, or as a graphviz dot file with format=dot
+// @Param	cluster		path 	string	true		"the cluster name"
+// @Param	namespace		path 	string	true		"the namespace name"
+// @Param	format		query 	string	false		"json or dot, default json"
+// @Success 200 {object} relation.Graph success
+// @router /namespaces/:namespaceName/topology [get]
+func NamespaceTopology(c *gin.Context) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespaceName")
+	format := c.DefaultQuery("format", "json")
+
+	if format != "json" && format != "dot" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid format %q, expected json or dot", format)})
+		return
+	}
+	kubeClient, err := client.KubeClient(cluster)
+	if err != nil {
+		klog.Errorf("Failed to get kubeClient for cluster: %s, %v", cluster, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	graph, err := relation.NamespaceGraph(kubeClient, namespace)
+	if err != nil {
+		klog.Errorf("Get topology of namespace %s in cluster %s error: %v", namespace, cluster, err)
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if format == "dot" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-%s.dot", cluster, namespace))
+		c.Data(http.StatusOK, "text/vnd.graphviz; charset=utf-8", []byte(graph.DOT()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": graph})
+}
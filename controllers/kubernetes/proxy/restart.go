@@ -1,18 +1,15 @@
 package proxy
 
 import (
-	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/JLPAY/gwayne/pkg/kubernetes/client"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/rollout"
 	"github.com/gin-gonic/gin"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
-	"k8s.io/kubectl/pkg/polymorphichelpers"
 )
 
 // RestartRequest 重启请求结构体
@@ -59,65 +56,15 @@ func RestartWorkload(c *gin.Context) {
 		return
 	}
 
-	// 根据资源类型获取对应的资源对象
-	var obj runtime.Object
-	switch kind {
-	case "deployments":
-		obj, err = clientset.AppsV1().Deployments(namespace).Get(context.Background(), name, metav1.GetOptions{})
-	case "statefulsets":
-		obj, err = clientset.AppsV1().StatefulSets(namespace).Get(context.Background(), name, metav1.GetOptions{})
-	case "daemonsets":
-		obj, err = clientset.AppsV1().DaemonSets(namespace).Get(context.Background(), name, metav1.GetOptions{})
-	default:
+	// 使用polymorphichelpers生成重启patch并应用
+	err = rollout.Restart(c.Request.Context(), clientset, kind, namespace, name)
+	if errors.Is(err, rollout.ErrUnsupportedKind) {
 		klog.Errorf("Unsupported resource kind: %s", kind)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported resource type"})
 		return
 	}
-
-	if err != nil {
-		klog.Errorf("Failed to get %s %s/%s: %v", kind, namespace, name, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	// 使用polymorphichelpers生成重启patch
-	patchBytes, err := polymorphichelpers.ObjectRestarterFn(obj)
-	if err != nil {
-		klog.Errorf("Failed to generate restart patch for %s %s/%s: %v", kind, namespace, name, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	// 应用patch来执行重启
-	switch kind {
-	case "deployments":
-		_, err = clientset.AppsV1().Deployments(namespace).Patch(
-			context.Background(),
-			name,
-			types.StrategicMergePatchType,
-			patchBytes,
-			metav1.PatchOptions{},
-		)
-	case "statefulsets":
-		_, err = clientset.AppsV1().StatefulSets(namespace).Patch(
-			context.Background(),
-			name,
-			types.StrategicMergePatchType,
-			patchBytes,
-			metav1.PatchOptions{},
-		)
-	case "daemonsets":
-		_, err = clientset.AppsV1().DaemonSets(namespace).Patch(
-			context.Background(),
-			name,
-			types.StrategicMergePatchType,
-			patchBytes,
-			metav1.PatchOptions{},
-		)
-	}
-
 	if err != nil {
-		klog.Errorf("Failed to apply restart patch to %s %s/%s in cluster %s: %v", kind, namespace, name, cluster, err)
+		klog.Errorf("Failed to restart %s %s/%s in cluster %s: %v", kind, namespace, name, cluster, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,264 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/JLPAY/gwayne/controllers/base"
+	"github.com/JLPAY/gwayne/models"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/client"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/rollout"
+	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+)
+
+// rolloutErrorStatus 返回发布操作错误对应的 HTTP 状态码
+func rolloutErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, rollout.ErrUnsupportedKind), errors.Is(err, rollout.ErrPauseUnsupported):
+		return http.StatusBadRequest
+	case errors.Is(err, rollout.ErrRevisionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, rollout.ErrAlreadyPaused), errors.Is(err, rollout.ErrNotPaused):
+		return http.StatusConflict
+	}
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		return base.KubeErrorStatus(err)
+	}
+	// kubectl 回滚时的校验错误（例如版本不存在、Deployment 已暂停）不是 API 错误
+	return http.StatusBadRequest
+}
+
+func queryRevision(c *gin.Context, key string) (int64, error) {
+	value := c.Query(key)
+	if value == "" {
+		return 0, nil
+	}
+	revision, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || revision < 0 {
+		return 0, fmt.Errorf("invalid %s %q", key, value)
+	}
+	return revision, nil
+}
+
+// @Title RolloutHistory
+// @Description list the revisions of a deployment, statefulset or daemonset
+// @Param	cluster		path 	string	true		"the cluster name"
+// @Param	namespace		path 	string	true		"the namespace name"
+// @Param	kind		path 	string	true		"deployments, statefulsets or daemonsets"
+// @Param	name		path 	string	true		"the resource name"
+// @Param	revision		query 	int	false		"return the given revision with its pod template"
+// @Success 200 {object} []rollout.Revision success
+// @router /namespaces/:namespaceName/:kind/:name/rollout/history [get]
+func RolloutHistory(c *gin.Context) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespaceName")
+	name := c.Param("name")
+	kind := c.Param("kind")
+
+	revision, err := queryRevision(c, "revision")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientset, err := client.Client(cluster)
+	if err != nil {
+		klog.Errorf("Failed to get clientset for cluster: %s", cluster)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get clientset"})
+		return
+	}
+
+	revisions, err := rollout.History(c.Request.Context(), clientset, kind, namespace, name)
+	if err != nil {
+		klog.Errorf("Get rollout history of %s %s/%s in cluster %s error: %v", kind, namespace, name, cluster, err)
+		c.JSON(rolloutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("revision") != "" {
+		found, err := rollout.FindRevision(revisions, revision)
+		if err != nil {
+			c.JSON(rolloutErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": found})
+		return
+	}
+
+	// 列表中不返回 Pod 模板，需要时通过 revision 参数或 diff 接口获取
+	for i := range revisions {
+		revisions[i].Template = nil
+	}
+	c.JSON(http.StatusOK, gin.H{"data": revisions})
+}
+
+// @Title RolloutDiff
+// @Description diff the pod templates of two revisions
+// @Param	cluster		path 	string	true		"the cluster name"
+// @Param	namespace		path 	string	true		"the namespace name"
+// @Param	kind		path 	string	true		"deployments, statefulsets or daemonsets"
+// @Param	name		path 	string	true		"the resource name"
+// @Param	from		query 	int	true		"the old revision"
+// @Param	to		query 	int	false		"the new revision, defaults to the current revision"
+// @Success 200 {object} diff.Result success
+// @router /namespaces/:namespaceName/:kind/:name/rollout/diff [get]
+func RolloutDiff(c *gin.Context) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespaceName")
+	name := c.Param("name")
+	kind := c.Param("kind")
+
+	from, err := queryRevision(c, "from")
+	if err == nil && from == 0 {
+		err = fmt.Errorf("from is required")
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	to, err := queryRevision(c, "to")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientset, err := client.Client(cluster)
+	if err != nil {
+		klog.Errorf("Failed to get clientset for cluster: %s", cluster)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get clientset"})
+		return
+	}
+
+	revisions, err := rollout.History(c.Request.Context(), clientset, kind, namespace, name)
+	if err != nil {
+		klog.Errorf("Get rollout history of %s %s/%s in cluster %s error: %v", kind, namespace, name, cluster, err)
+		c.JSON(rolloutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	fromRevision, err := rollout.FindRevision(revisions, from)
+	if err != nil {
+		c.JSON(rolloutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	toRevision, err := rollout.FindRevision(revisions, to)
+	if err != nil {
+		c.JSON(rolloutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := rollout.DiffRevisions(fromRevision, toRevision)
+	if err != nil {
+		c.JSON(diffErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// @Title RolloutRollback
+// @Description roll back to the given revision
+// @Param	cluster		path 	string	true		"the cluster name"
+// @Param	namespace		path 	string	true		"the namespace name"
+// @Param	kind		path 	string	true		"deployments, statefulsets or daemonsets"
+// @Param	name		path 	string	true		"the resource name"
+// @Param	revision		query 	int	false		"the revision to roll back to, defaults to the previous revision"
+// @Success 200 {string} rollback message
+// @router /namespaces/:namespaceName/:kind/:name/rollout/rollback [post]
+func RolloutRollback(c *gin.Context) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespaceName")
+	name := c.Param("name")
+	kind := c.Param("kind")
+	user := c.MustGet("User").(*models.User)
+
+	revision, err := queryRevision(c, "revision")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientset, err := client.Client(cluster)
+	if err != nil {
+		klog.Errorf("Failed to get clientset for cluster: %s", cluster)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get clientset"})
+		return
+	}
+
+	message, err := rollout.Rollback(c.Request.Context(), clientset, kind, namespace, name, revision)
+	if err != nil {
+		klog.Errorf("Rollback %s %s/%s to revision %d in cluster %s error: %v", kind, namespace, name, revision, cluster, err)
+		c.JSON(rolloutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	klog.Infof("User %s rollback %s %s/%s to revision %d in cluster %s: %s", user.Name, kind, namespace, name, revision, cluster, message)
+	c.JSON(http.StatusOK, gin.H{"data": message})
+}
+
+// @Title RolloutPause
+// @Description pause the rollout of a deployment
+// @router /namespaces/:namespaceName/:kind/:name/rollout/pause [put]
+func RolloutPause(c *gin.Context) {
+	setPaused(c, true)
+}
+
+// @Title RolloutResume
+// @Description resume the rollout of a paused deployment
+// @router /namespaces/:namespaceName/:kind/:name/rollout/resume [put]
+func RolloutResume(c *gin.Context) {
+	setPaused(c, false)
+}
+
+func setPaused(c *gin.Context, paused bool) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespaceName")
+	name := c.Param("name")
+	kind := c.Param("kind")
+
+	clientset, err := client.Client(cluster)
+	if err != nil {
+		klog.Errorf("Failed to get clientset for cluster: %s", cluster)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get clientset"})
+		return
+	}
+
+	if err := rollout.SetPaused(c.Request.Context(), clientset, kind, namespace, name, paused); err != nil {
+		klog.Errorf("Set paused=%v for %s %s/%s in cluster %s error: %v", paused, kind, namespace, name, cluster, err)
+		c.JSON(rolloutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": "ok!"})
+}
+
+// @Title RolloutStatus
+// @Description get the rollout status, including stuck detection via ProgressDeadlineExceeded
+// @Param	cluster		path 	string	true		"the cluster name"
+// @Param	namespace		path 	string	true		"the namespace name"
+// @Param	kind		path 	string	true		"deployments, statefulsets or daemonsets"
+// @Param	name		path 	string	true		"the resource name"
+// @Success 200 {object} rollout.Status success
+// @router /namespaces/:namespaceName/:kind/:name/rollout/status [get]
+func RolloutStatus(c *gin.Context) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespaceName")
+	name := c.Param("name")
+	kind := c.Param("kind")
+
+	clientset, err := client.Client(cluster)
+	if err != nil {
+		klog.Errorf("Failed to get clientset for cluster: %s", cluster)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get clientset"})
+		return
+	}
+
+	status, err := rollout.GetStatus(c.Request.Context(), clientset, kind, namespace, name)
+	if err != nil {
+		klog.Errorf("Get rollout status of %s %s/%s in cluster %s error: %v", kind, namespace, name, cluster, err)
+		c.JSON(rolloutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": status})
+}
@@ -0,0 +1,143 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/JLPAY/gwayne/controllers/base"
+	"github.com/JLPAY/gwayne/models"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/client"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/scale"
+	"github.com/gin-gonic/gin"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+)
+
+// scaleTarget 根据路径参数解析出资源的 GVR，CRD 路径中带有 group 和 version
+func scaleTarget(c *gin.Context, manager *client.ClusterManager) (schema.GroupVersionResource, string, error) {
+	if group := c.Param("group"); group != "" {
+		return schema.GroupVersionResource{Group: group, Version: c.Param("version"), Resource: c.Param("kind")},
+			c.Param("namespacesName"), nil
+	}
+	resource, err := manager.KubeClient.GVRK(c.Param("kind"))
+	if err != nil {
+		return schema.GroupVersionResource{}, "", err
+	}
+	return resource.GroupVersionResourceKind.GroupVersionResource, c.Param("namespaceName"), nil
+}
+
+// @Title GetScale
+// @Description get the scale subresource of a scalable resource, including CRDs with a scale subresource
+// @Param	cluster		path 	string	true		"the cluster name"
+// @Param	namespace		path 	string	true		"the namespace name"
+// @Param	kind		path 	string	true		"the resource kind"
+// @Param	name		path 	string	true		"the resource name"
+// @Success 200 {object} autoscalingv1.Scale success
+// @router /namespaces/:namespaceName/:kind/:name/scale [get]
+func GetScale(c *gin.Context) {
+	cluster := c.Param("cluster")
+	name := c.Param("name")
+
+	manager, err := client.Manager(cluster)
+	if err != nil {
+		klog.Errorf("Failed to get manager for cluster: %s, %v", cluster, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	gvr, namespace, err := scaleTarget(c, manager)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := scale.GetScale(c.Request.Context(), manager.DynamicClient, gvr, namespace, name)
+	if err != nil {
+		klog.Errorf("Get scale of %s %s/%s from cluster (%s) error: %v", gvr.Resource, namespace, name, cluster, err)
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	base.RenderObject(c, http.StatusOK, result)
+}
+
+// @Title UpdateScale
+// @Description set the replicas through the scale subresource, metadata.resourceVersion enables conflict detection
+// @Param	cluster		path 	string	true		"the cluster name"
+// @Param	namespace		path 	string	true		"the namespace name"
+// @Param	kind		path 	string	true		"the resource kind"
+// @Param	name		path 	string	true		"the resource name"
+// @Param	scale		body 	autoscalingv1.Scale	true		"the scale object"
+// @Success 200 {object} autoscalingv1.Scale success
+// @router /namespaces/:namespaceName/:kind/:name/scale [put]
+func UpdateScale(c *gin.Context) {
+	cluster := c.Param("cluster")
+	name := c.Param("name")
+	user := c.MustGet("User").(*models.User)
+
+	var body autoscalingv1.Scale
+	if err := base.BindObject(c, &body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid scale object: %v", err)})
+		return
+	}
+
+	manager, err := client.Manager(cluster)
+	if err != nil {
+		klog.Errorf("Failed to get manager for cluster: %s, %v", cluster, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	gvr, namespace, err := scaleTarget(c, manager)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := scale.UpdateScale(c.Request.Context(), manager.DynamicClient, gvr, namespace, name, body.Spec.Replicas, body.ResourceVersion)
+	if err != nil {
+		klog.Errorf("Update scale of %s %s/%s from cluster (%s) error: %v", gvr.Resource, namespace, name, cluster, err)
+		status := base.KubeErrorStatus(err)
+		if errors.Is(err, scale.ErrInvalidReplicas) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	klog.Infof("User %s scale %s %s/%s to %d replicas in cluster %s", user.Name, gvr.Resource, namespace, name, body.Spec.Replicas, cluster)
+	base.RenderObject(c, http.StatusOK, result)
+}
+
+// @Title ScaleNamespace
+// @Description scale all deployments and statefulsets in the namespace to zero (park) or back to the recorded replicas (restore)
+// @Param	cluster		path 	string	true		"the cluster name"
+// @Param	namespace		path 	string	true		"the namespace name"
+// @Param	action		path 	string	true		"park or restore"
+// @Success 200 {object} []scale.Result success
+// @router /namespaces/:namespaceName/scale/:action [post]
+func ScaleNamespace(c *gin.Context) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespaceName")
+	action := scale.Action(c.Param("action"))
+	user := c.MustGet("User").(*models.User)
+
+	if action != scale.ActionPark && action != scale.ActionRestore {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid action %q, expected park or restore", action)})
+		return
+	}
+
+	clientset, err := client.Client(cluster)
+	if err != nil {
+		klog.Errorf("Failed to get clientset for cluster: %s", cluster)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get clientset"})
+		return
+	}
+
+	klog.Infof("User %s %s namespace %s in cluster %s", user.Name, action, namespace, cluster)
+	results, err := scale.Namespace(c.Request.Context(), clientset, namespace, action)
+	if err != nil {
+		klog.Errorf("%s namespace %s in cluster %s error: %v", action, namespace, cluster, err)
+		c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": results})
+}
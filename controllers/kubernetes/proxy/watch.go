@@ -0,0 +1,154 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/JLPAY/gwayne/controllers/base"
+	"github.com/JLPAY/gwayne/models"
+	"github.com/JLPAY/gwayne/pkg/config"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/client"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/crd"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/watch"
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+)
+
+const defaultHeartbeatSeconds = 30
+
+// 每个用户同时打开的 watch 连接数
+var watchLimiter = watch.NewConnectionLimiter()
+
+// @Title Watch
+// @Description watch resource changes via Server-Sent Events
+// @Param	cluster		path 	string	true		"the cluster name"
+// @Param	namespace		path 	string	false		"the namespace name"
+// @Param	group		path 	string	false		"the CRD group, kind is the plural resource name when set"
+// @Param	version		path 	string	false		"the CRD version"
+// @Param	kind		path 	string	true		"the resource kind"
+// @Param	labelSelector		query 	string	false		"the label selector"
+// @Param	resourceVersion		query 	string	false		"resume from resourceVersion, Last-Event-ID header is also accepted"
+// @Success 200 {object} watch.Event success
+// @router /watch/namespaces/:namespaceName/:kind [get]
+// @router /watch/apis/:group/:version/namespaces/:namespaceName/:kind [get]
+func Watch(c *gin.Context) {
+	cluster := c.Param("cluster")
+	namespace := c.Param("namespaceName")
+	kind := c.Param("kind")
+	group := c.Param("group")
+	user := c.MustGet("User").(*models.User)
+
+	selector, err := labels.Parse(c.Query("labelSelector"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid labelSelector: %v", err)})
+		return
+	}
+	// EventSource 自动重连时会带上 Last-Event-ID
+	resourceVersion := c.Query("resourceVersion")
+	if resourceVersion == "" {
+		resourceVersion = c.GetHeader("Last-Event-ID")
+	}
+
+	manager, err := client.Manager(cluster)
+	if err != nil {
+		klog.Errorf("Failed to get manager for cluster: %s, %v", cluster, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if manager.CacheFactory == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("cache of cluster %s is not ready", cluster)})
+		return
+	}
+
+	var gvr schema.GroupVersionResource
+	var namespaced bool
+	if group != "" {
+		// CRD 实例，kind 为复数资源名
+		gvr, namespaced, err = crd.GetCustomCRDResource(manager.CrdClient, group, c.Param("version"), kind)
+		if err != nil {
+			c.JSON(base.KubeErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+	} else {
+		resource, err := manager.KubeClient.GVRK(kind)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		gvr, namespaced = resource.GroupVersionResourceKind.GroupVersionResource, resource.Namespaced
+	}
+	if !namespaced {
+		namespace = ""
+	}
+
+	watchConf := config.Conf.Watch
+	release, err := watchLimiter.Acquire(user.Name, watchConf.MaxConnectionsPerUser)
+	if err != nil {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	defer release()
+
+	// 最后一个 watch 断开后停止按需启动的 informer
+	informer, releaseInformer, err := manager.CacheFactory.Informer(gvr)
+	if err != nil {
+		klog.Errorf("Failed to get informer for %s in cluster %s: %v", gvr, cluster, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer releaseInformer()
+
+	watcher, err := watch.NewWatcher(informer, watch.Options{
+		Namespace:       namespace,
+		LabelSelector:   selector,
+		ResourceVersion: resourceVersion,
+		BufferSize:      watchConf.BufferSize,
+	})
+	if err != nil {
+		if errors.Is(err, watch.ErrInvalidResourceVersion) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer watcher.Stop()
+
+	heartbeatSeconds := watchConf.HeartbeatSeconds
+	if heartbeatSeconds <= 0 {
+		heartbeatSeconds = defaultHeartbeatSeconds
+	}
+	heartbeat := time.NewTicker(time.Duration(heartbeatSeconds) * time.Second)
+	defer heartbeat.Stop()
+
+	klog.V(2).Infof("User %s watch %s in cluster %s namespace %s", user.Name, kind, cluster, namespace)
+
+	base.StartSSE(c)
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-watcher.Done():
+			if err := watcher.Err(); err != nil {
+				_ = base.WriteSSE(w, "", string(watch.Error), gin.H{"error": err.Error()})
+			}
+			return false
+		case event := <-watcher.ResultChan():
+			if err := base.WriteSSE(w, event.ResourceVersion, string(event.Type), event); err != nil {
+				klog.Errorf("Failed to write watch event: %v", err)
+				return false
+			}
+		case <-heartbeat.C:
+			if err := base.WriteSSEHeartbeat(w); err != nil {
+				return false
+			}
+		}
+		return true
+	})
+}
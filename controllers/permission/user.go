@@ -13,6 +13,7 @@ import (
 // @Description get all user
 // @Param	pageNo		query 	int	false		"the page current no"
 // @Param	pageSize		query 	int	false		"the page size"
+// @Param	continue		query 	string	false		"the continue token of cursor pagination"
 // @Success 200 {object} []models.User success
 // @router / [get]
 func UsersList(c *gin.Context) {
@@ -21,14 +22,27 @@ func UsersList(c *gin.Context) {
 
 	name := c.Query("name")
 	if name != "" {
-		param.Query["name__contains"] = name
+		param.AddQuery("name__contains", name)
+	}
+
+	// 游标分页，按 id 做 keyset 分页，不统计总数
+	if param.UseContinue {
+		users := []models.User{}
+		next, err := models.GetAllByCursor(new(models.User), &users, param)
+		if err != nil {
+			klog.Errorf("Get users by cursor err:%v", err)
+			c.JSON(base.QueryErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": param.NewContinuePage(users, next, nil)})
+		return
 	}
 
 	// 获取总记录数
 	total, err := models.GetTotal(new(models.User), param)
 	if err != nil {
 		klog.Errorf("Get Total users err:%v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err})
+		c.JSON(base.QueryErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -36,7 +50,7 @@ func UsersList(c *gin.Context) {
 	err = models.GetAll(new(models.User), &users, param)
 	if err != nil {
 		klog.Errorf("Get all users err:%v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err})
+		c.JSON(base.QueryErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -0,0 +1,209 @@
+package scheduledjob
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/JLPAY/gwayne/controllers/base"
+	"github.com/JLPAY/gwayne/models"
+	"github.com/JLPAY/gwayne/pkg/scheduler"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+)
+
+// 每次最多返回的执行记录数
+const maxExecutions = 100
+
+// getJob 获取任务并校验权限，非管理员只能操作自己创建的任务
+func getJob(c *gin.Context) (*models.ScheduledJob, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid id"})
+		return nil, false
+	}
+	job, err := models.GetScheduledJobById(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scheduled job not found"})
+		return nil, false
+	}
+	if err != nil {
+		klog.Errorf("get scheduled job %d error: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	user := c.MustGet("User").(*models.User)
+	if !user.Admin && job.User != user.Name {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+		return nil, false
+	}
+	return job, true
+}
+
+// validate 校验任务配置并计算下次执行时间
+func validate(c *gin.Context, job *models.ScheduledJob) bool {
+	if err := scheduler.Validate(job); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	user := c.MustGet("User").(*models.User)
+	if scheduler.IsNodeAction(job.Action) && !user.Admin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admin can schedule node operations"})
+		return false
+	}
+
+	job.NextRunTime = nil
+	if job.Enabled {
+		next, err := scheduler.NextRunTime(job, time.Now())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return false
+		}
+		job.NextRunTime = next
+	}
+	return true
+}
+
+// List 获取定时任务列表，非管理员只能看到自己创建的任务
+func List(c *gin.Context) {
+	param := base.BuildQueryParam(c)
+	user := c.MustGet("User").(*models.User)
+	if !user.Admin {
+		param.AddQuery("user", user.Name)
+	}
+
+	total, err := models.GetTotal(new(models.ScheduledJob), param)
+	if err != nil {
+		klog.Errorf("get scheduled job total by param (%v) error. %v", param, err)
+		c.JSON(base.QueryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	jobs := []models.ScheduledJob{}
+	if err := models.GetAll(new(models.ScheduledJob), &jobs, param); err != nil {
+		klog.Errorf("list scheduled jobs by param (%v) error. %v", param, err)
+		c.JSON(base.QueryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": param.NewPage(total, jobs)})
+}
+
+func Create(c *gin.Context) {
+	var job models.ScheduledJob
+	if err := c.ShouldBindJSON(&job); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format for create scheduled job"})
+		return
+	}
+	job.ID = 0
+	job.User = c.MustGet("User").(*models.User).Name
+	job.LastRunTime = nil
+	job.LastStatus = ""
+	if !validate(c, &job) {
+		return
+	}
+
+	id, err := models.AddScheduledJob(&job)
+	if err != nil {
+		klog.Errorf("create scheduled job error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": id})
+}
+
+func Get(c *gin.Context) {
+	job, ok := getJob(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+func Update(c *gin.Context) {
+	existing, ok := getJob(c)
+	if !ok {
+		return
+	}
+	var job models.ScheduledJob
+	if err := c.ShouldBindJSON(&job); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// 创建人和执行状态不允许修改
+	job.ID = existing.ID
+	job.User = existing.User
+	job.LastRunTime = existing.LastRunTime
+	job.LastStatus = existing.LastStatus
+	job.CreateTime = existing.CreateTime
+	if !validate(c, &job) {
+		return
+	}
+
+	if err := models.UpdateScheduledJob(&job); err != nil {
+		klog.Errorf("update scheduled job %d error: %v", job.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+func Delete(c *gin.Context) {
+	job, ok := getJob(c)
+	if !ok {
+		return
+	}
+	if err := models.DeleteScheduledJob(job.ID); err != nil {
+		klog.Errorf("delete scheduled job %d error: %v", job.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": nil})
+}
+
+// Run 立即执行一次任务，返回执行记录，执行结果通过执行记录查询
+func Run(c *gin.Context) {
+	job, ok := getJob(c)
+	if !ok {
+		return
+	}
+	user := c.MustGet("User").(*models.User)
+	if scheduler.IsNodeAction(job.Action) && !user.Admin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admin can run node operations"})
+		return
+	}
+
+	execution, err := scheduler.Trigger(job, user.Name)
+	if err != nil {
+		klog.Errorf("run scheduled job %d error: %v", job.ID, err)
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	klog.Infof("User %s run scheduled job %d (%s)", user.Name, job.ID, job.Action)
+	c.JSON(http.StatusOK, gin.H{"data": execution})
+}
+
+// Executions 获取任务最近的执行记录
+func Executions(c *gin.Context) {
+	job, ok := getJob(c)
+	if !ok {
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return
+	}
+	if limit > maxExecutions {
+		limit = maxExecutions
+	}
+
+	executions, err := models.GetScheduledJobExecutions(job.ID, limit)
+	if err != nil {
+		klog.Errorf("get executions of scheduled job %d error: %v", job.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": executions})
+}
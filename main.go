@@ -9,15 +9,22 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/JLPAY/gwayne/controllers/kubernetes/node"
 	"github.com/JLPAY/gwayne/controllers/kubernetes/pod"
 	"github.com/JLPAY/gwayne/pkg/config"
 	"github.com/JLPAY/gwayne/pkg/initial"
+	"github.com/JLPAY/gwayne/pkg/portforward"
 	"github.com/JLPAY/gwayne/pkg/rsakey"
+	"github.com/JLPAY/gwayne/pkg/scheduler"
 	"github.com/JLPAY/gwayne/routers"
 	"k8s.io/klog/v2"
 )
 
 func main() {
+	// 本地端口转发模式，不需要读取服务端配置
+	if len(os.Args) > 1 && os.Args[1] == "port-forward" {
+		os.Exit(portforward.RunHelper(os.Args[2:]))
+	}
 
 	cfg := config.GetConfig()
 
@@ -37,6 +44,12 @@ func main() {
 	pod.CleanupShellCache()
 	klog.Info("Shell cache cleanup started")
 
+	// 启动残留节点终端 Pod 的清理
+	node.StartShellJanitor()
+
+	// 启动定时任务调度
+	scheduler.Start()
+
 	router := routers.InitRouter()
 
 	srv := &http.Server{
@@ -65,6 +78,9 @@ func main() {
 	<-quit
 	klog.Info("shutdown Server ...")
 
+	// 停止定时任务调度并释放 leader 租约
+	scheduler.Stop()
+
 	// 创建一个 5 秒的上下文，用于等待当前请求完成
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+)
+
+const TableNameAuditLog = "audit_log"
+
+// AuditAction 审计的操作类型
+type AuditAction string
+
+const (
+	// 上传文件到容器、从容器下载文件
+	AuditActionFileUpload   AuditAction = "file_upload"
+	AuditActionFileDownload AuditAction = "file_download"
+	// 管理员关闭和旁观终端会话
+	AuditActionTerminalTerminate AuditAction = "terminal_terminate"
+	AuditActionTerminalShadow    AuditAction = "terminal_shadow"
+	// 添加临时调试容器
+	AuditActionDebugContainer AuditAction = "debug_container"
+	// 打开节点终端
+	AuditActionNodeShell AuditAction = "node_shell"
+	// 端口转发，每个转发的连接记录一次
+	AuditActionPortForward AuditAction = "port_forward"
+)
+
+// AuditStatus 操作结果
+type AuditStatus string
+
+const (
+	AuditStatusSuccess AuditStatus = "success"
+	AuditStatusFailed  AuditStatus = "failed"
+)
+
+// AuditLog 容器操作的审计记录
+type AuditLog struct {
+	ID        int64       `gorm:"primary_key;auto_increment" json:"id,omitempty"`
+	User      string      `gorm:"size:128;index" json:"user"`
+	Action    AuditAction `gorm:"size:32;index" json:"action"`
+	Cluster   string      `gorm:"size:128" json:"cluster"`
+	Namespace string      `gorm:"size:128" json:"namespace,omitempty"`
+	Pod       string      `gorm:"size:256" json:"pod,omitempty"`
+	Container string      `gorm:"size:256" json:"container,omitempty"`
+	// 操作的对象，例如文件路径，上传多个文件时以逗号分隔
+	Target string `gorm:"size:1024" json:"target,omitempty"`
+	// 传输的字节数
+	Bytes      int64       `json:"bytes"`
+	Status     AuditStatus `gorm:"size:32" json:"status"`
+	Message    string      `gorm:"type:text" json:"message,omitempty"`
+	CreateTime *time.Time  `gorm:"autoCreateTime;index" json:"createTime,omitempty"`
+}
+
+func (AuditLog) TableName() string {
+	return TableNameAuditLog
+}
+
+func AddAuditLog(log *AuditLog) error {
+	return DB.Create(log).Error
+}
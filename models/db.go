@@ -86,6 +86,12 @@ func dbAutoMigrate() error {
 	err := DB.AutoMigrate(
 		&User{},
 		&Cluster{},
+		&ScheduledJob{},
+		&ScheduledJobExecution{},
+		&SchedulerLease{},
+		&AuditLog{},
+		&TerminalRecord{},
+		&TerminalTicket{},
 		/*&model.Role{},
 		&model.Group{},
 		&model.Menu{},
@@ -0,0 +1,187 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	TableNameScheduledJob          = "scheduled_job"
+	TableNameScheduledJobExecution = "scheduled_job_execution"
+	TableNameSchedulerLease        = "scheduler_lease"
+)
+
+// ScheduledJobAction 定时任务执行的操作
+type ScheduledJobAction string
+
+const (
+	// 重启 Deployment/StatefulSet/DaemonSet
+	ScheduledJobActionRestart ScheduledJobAction = "restart"
+	// 通过 scale 子资源修改副本数
+	ScheduledJobActionScale ScheduledJobAction = "scale"
+	// 命名空间下的工作负载缩容到 0 / 恢复原副本数
+	ScheduledJobActionPark    ScheduledJobAction = "park"
+	ScheduledJobActionRestore ScheduledJobAction = "restore"
+	// 节点隔离、解除隔离和驱逐
+	ScheduledJobActionCordon   ScheduledJobAction = "cordon"
+	ScheduledJobActionUncordon ScheduledJobAction = "uncordon"
+	ScheduledJobActionDrain    ScheduledJobAction = "drain"
+)
+
+// ScheduledJobStatus 执行结果
+type ScheduledJobStatus string
+
+const (
+	ScheduledJobStatusRunning ScheduledJobStatus = "running"
+	ScheduledJobStatusSuccess ScheduledJobStatus = "success"
+	ScheduledJobStatusFailed  ScheduledJobStatus = "failed"
+)
+
+// ScheduledJob 定时任务
+type ScheduledJob struct {
+	ID      int64              `gorm:"primary_key;auto_increment" json:"id,omitempty"`
+	Name    string             `gorm:"size:128;not null" json:"name"`
+	Cluster string             `gorm:"size:128;index;not null" json:"cluster"`
+	Action  ScheduledJobAction `gorm:"size:32;not null" json:"action"`
+	// 操作的资源类型，例如 deployments，节点操作时为空
+	Kind      string `gorm:"size:128" json:"kind,omitempty"`
+	Namespace string `gorm:"size:128" json:"namespace,omitempty"`
+	// 资源名称，节点操作时也可以通过 Selector 指定一组节点
+	Target   string `gorm:"size:256" json:"target,omitempty"`
+	Selector string `gorm:"size:512" json:"selector,omitempty"`
+	// 操作参数（JSON），例如 scale 的 {"replicas":0}、drain 的驱逐选项
+	Params string `gorm:"type:text" json:"params,omitempty"`
+	// 标准 5 段 cron 表达式：分 时 日 月 周
+	Schedule string `gorm:"size:128;not null" json:"schedule"`
+	// IANA 时区，例如 Asia/Shanghai，为空时使用服务器时区
+	TimeZone    string             `gorm:"size:64" json:"timeZone,omitempty"`
+	Enabled     bool               `gorm:"not null" json:"enabled"`
+	User        string             `gorm:"size:128" json:"user,omitempty"`
+	NextRunTime *time.Time         `gorm:"index" json:"nextRunTime,omitempty"`
+	LastRunTime *time.Time         `json:"lastRunTime,omitempty"`
+	LastStatus  ScheduledJobStatus `gorm:"size:32" json:"lastStatus,omitempty"`
+	CreateTime  *time.Time         `gorm:"autoCreateTime" json:"createTime,omitempty"`
+	UpdateTime  *time.Time         `gorm:"autoUpdateTime" json:"updateTime,omitempty"`
+}
+
+func (ScheduledJob) TableName() string {
+	return TableNameScheduledJob
+}
+
+// ScheduledJobExecution 定时任务的执行记录
+type ScheduledJobExecution struct {
+	ID     int64              `gorm:"primary_key;auto_increment" json:"id,omitempty"`
+	JobID  int64              `gorm:"index;not null" json:"jobId"`
+	Action ScheduledJobAction `gorm:"size:32" json:"action"`
+	// 执行任务的实例，手动触发时为触发的用户
+	Instance  string             `gorm:"size:256" json:"instance"`
+	Status    ScheduledJobStatus `gorm:"size:32" json:"status"`
+	Message   string             `gorm:"type:text" json:"message,omitempty"`
+	StartTime time.Time          `json:"startTime"`
+	EndTime   *time.Time         `json:"endTime,omitempty"`
+}
+
+func (ScheduledJobExecution) TableName() string {
+	return TableNameScheduledJobExecution
+}
+
+// SchedulerLease 多实例部署时用于选主的租约
+type SchedulerLease struct {
+	Name       string    `gorm:"primaryKey;size:64" json:"name"`
+	Holder     string    `gorm:"size:256" json:"holder"`
+	ExpireTime time.Time `json:"expireTime"`
+}
+
+func (SchedulerLease) TableName() string {
+	return TableNameSchedulerLease
+}
+
+func AddScheduledJob(job *ScheduledJob) (int64, error) {
+	if err := DB.Create(job).Error; err != nil {
+		return 0, err
+	}
+	return job.ID, nil
+}
+
+func GetScheduledJobById(id int64) (*ScheduledJob, error) {
+	var job ScheduledJob
+	if err := DB.First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func UpdateScheduledJob(job *ScheduledJob) error {
+	return DB.Save(job).Error
+}
+
+// DeleteScheduledJob 删除任务及其执行记录
+func DeleteScheduledJob(id int64) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("job_id = ?", id).Delete(&ScheduledJobExecution{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&ScheduledJob{}, id).Error
+	})
+}
+
+// GetDueScheduledJobs 获取已启用且到期的任务
+func GetDueScheduledJobs(now time.Time) ([]ScheduledJob, error) {
+	var jobs []ScheduledJob
+	err := DB.Where("enabled = ? AND (next_run_time IS NULL OR next_run_time <= ?)", true, now).
+		Order("next_run_time").Find(&jobs).Error
+	return jobs, err
+}
+
+// UpdateScheduledJobNextRunTime 更新任务的下次执行时间
+func UpdateScheduledJobNextRunTime(id int64, nextRunTime *time.Time) error {
+	return DB.Model(&ScheduledJob{}).Where("id = ?", id).Update("next_run_time", nextRunTime).Error
+}
+
+// UpdateScheduledJobLastRun 记录任务最近一次的执行时间和结果
+func UpdateScheduledJobLastRun(id int64, lastRunTime time.Time, status ScheduledJobStatus) error {
+	return DB.Model(&ScheduledJob{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"last_run_time": lastRunTime, "last_status": status}).Error
+}
+
+func AddScheduledJobExecution(execution *ScheduledJobExecution) error {
+	return DB.Create(execution).Error
+}
+
+func FinishScheduledJobExecution(execution *ScheduledJobExecution) error {
+	return DB.Model(execution).Select("status", "message", "end_time").Updates(execution).Error
+}
+
+// GetScheduledJobExecutions 获取任务最近的执行记录
+func GetScheduledJobExecutions(jobId int64, limit int) ([]ScheduledJobExecution, error) {
+	var executions []ScheduledJobExecution
+	err := DB.Where("job_id = ?", jobId).Order("id DESC").Limit(limit).Find(&executions).Error
+	return executions, err
+}
+
+// AcquireSchedulerLease 获取或续约租约，租约未过期且由其它实例持有时返回 false。
+// 过期时间使用各实例的本地时间，要求实例之间的时钟基本同步。
+func AcquireSchedulerLease(name, holder string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	err := DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&SchedulerLease{Name: name, ExpireTime: now}).Error
+	if err != nil {
+		return false, err
+	}
+
+	result := DB.Model(&SchedulerLease{}).
+		Where("name = ? AND (holder = ? OR expire_time < ?)", name, holder, now).
+		Updates(map[string]interface{}{"holder": holder, "expire_time": now.Add(ttl)})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReleaseSchedulerLease 实例退出时释放租约，其它实例可以立即接管
+func ReleaseSchedulerLease(name, holder string) error {
+	return DB.Model(&SchedulerLease{}).Where("name = ? AND holder = ?", name, holder).
+		Update("expire_time", time.Now().Add(-time.Second)).Error
+}
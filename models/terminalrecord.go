@@ -0,0 +1,101 @@
+package models
+
+import (
+	"time"
+
+	"github.com/JLPAY/gwayne/pkg/pagequery"
+	"gorm.io/gorm"
+)
+
+const TableNameTerminalRecord = "terminal_record"
+
+// TerminalRecord 终端会话录像的索引，录像内容保存在录像存储中
+type TerminalRecord struct {
+	ID        int64  `gorm:"primary_key;auto_increment" json:"id,omitempty"`
+	SessionId string `gorm:"size:64;uniqueIndex;not null" json:"sessionId"`
+	User      string `gorm:"size:128;index" json:"user"`
+	Cluster   string `gorm:"size:128;index" json:"cluster"`
+	Namespace string `gorm:"size:128" json:"namespace"`
+	Pod       string `gorm:"size:256;index" json:"pod"`
+	Container string `gorm:"size:256" json:"container"`
+	Command   string `gorm:"size:256" json:"command,omitempty"`
+	// 录像在存储中的路径
+	StorageKey string `gorm:"size:512" json:"-"`
+	Bytes      int64  `json:"bytes"`
+	// 是否记录了用户输入
+	Input bool `json:"input"`
+	// 录像超过大小限制后不再记录
+	Truncated bool       `json:"truncated"`
+	StartTime time.Time  `gorm:"index" json:"startTime"`
+	EndTime   *time.Time `json:"endTime,omitempty"`
+}
+
+func (TerminalRecord) TableName() string {
+	return TableNameTerminalRecord
+}
+
+func AddTerminalRecord(record *TerminalRecord) error {
+	return DB.Create(record).Error
+}
+
+// FinishTerminalRecord 会话结束时更新录像大小和结束时间
+func FinishTerminalRecord(record *TerminalRecord) error {
+	return DB.Model(record).Select("bytes", "truncated", "end_time").Updates(record).Error
+}
+
+func GetTerminalRecordById(id int64) (*TerminalRecord, error) {
+	var record TerminalRecord
+	if err := DB.First(&record, id).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// terminalRecordFilterColumns 录像列表允许过滤的字段
+var terminalRecordFilterColumns = []string{"cluster", "namespace", "pod", "container", "user"}
+
+// GetTerminalRecords 按过滤条件和会话开始时间分页查询录像，按开始时间倒序，since、until 为零值时不限制
+func GetTerminalRecords(q *pagequery.QueryParam, since, until time.Time) (int64, []TerminalRecord, error) {
+	qs, err := terminalRecordQuery(q, since, until)
+	if err != nil {
+		return 0, nil, err
+	}
+	// 统计总数和查询列表共用过滤条件
+	qs = qs.Session(&gorm.Session{})
+
+	var total int64
+	if err := qs.Count(&total).Error; err != nil {
+		return 0, nil, err
+	}
+	records := []TerminalRecord{}
+	err = qs.Order("start_time DESC, id DESC").Offset(int(q.Offset())).Limit(int(q.Limit())).Find(&records).Error
+	return total, records, err
+}
+
+// GetTerminalRecordsByCursor 与 GetTerminalRecords 相同的过滤条件，按 id 做 keyset 分页，返回下一页的 continue 令牌
+func GetTerminalRecordsByCursor(q *pagequery.QueryParam, since, until time.Time) ([]TerminalRecord, string, error) {
+	qs, err := terminalRecordQuery(q, since, until)
+	if err != nil {
+		return nil, "", err
+	}
+	records := []TerminalRecord{}
+	next, err := findByCursor(qs, &records, q)
+	return records, next, err
+}
+
+func terminalRecordQuery(q *pagequery.QueryParam, since, until time.Time) (*gorm.DB, error) {
+	if err := CheckFilterColumns(q, terminalRecordFilterColumns...); err != nil {
+		return nil, err
+	}
+	qs, err := BuildFilter(DB.Model(&TerminalRecord{}), q)
+	if err != nil {
+		return nil, err
+	}
+	if !since.IsZero() {
+		qs = qs.Where("start_time >= ?", since)
+	}
+	if !until.IsZero() {
+		qs = qs.Where("start_time < ?", until)
+	}
+	return qs, nil
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+const TableNameTerminalTicket = "terminal_ticket"
+
+// TerminalTicket 已经使用的终端 ticket，多个实例共享，保证每个 ticket 只能使用一次
+type TerminalTicket struct {
+	ID         string    `gorm:"primaryKey;size:64" json:"id"`
+	ExpireTime time.Time `gorm:"index" json:"expireTime"`
+}
+
+func (TerminalTicket) TableName() string {
+	return TableNameTerminalTicket
+}
+
+// RedeemTerminalTicket 标记 ticket 已经使用，ticket 已经被使用过时返回 false
+func RedeemTerminalTicket(id string, expireTime time.Time) (bool, error) {
+	// 过期的 ticket 在验证签名时已经被拒绝，不需要继续保留
+	if err := DB.Where("expire_time < ?", time.Now()).Delete(&TerminalTicket{}).Error; err != nil {
+		return false, err
+	}
+	result := DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&TerminalTicket{ID: id, ExpireTime: expireTime})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
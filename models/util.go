@@ -3,17 +3,35 @@ package models
 import (
 	"fmt"
 	"github.com/JLPAY/gwayne/pkg/pagequery"
+	"github.com/JLPAY/gwayne/pkg/snaker"
 	"gorm.io/gorm"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 )
 
+// 排序和过滤的字段只允许字母、数字、下划线和点，避免拼接 SQL 时注入
+var orderColumnRegexp = regexp.MustCompile(`^[A-Za-z0-9_.]+$`)
+
+const (
+	// 过滤字段和操作符之间的分隔符，例如 name__contains，与 Kubernetes 资源列表一致
+	filterOperatorSep = "__"
+	// __in 操作符多个取值之间的分隔符，例如 cluster__in=dev|test
+	filterValueSep = "|"
+)
+
 // 获取数总数
 func GetTotal(queryTable interface{}, q *pagequery.QueryParam) (int64, error) {
 	// 构建基础查询
 	qs := DB.Model(queryTable)
 
 	// 应用过滤条件
-	qs = BuildFilter(qs, q.Query)
+	qs, err := BuildFilter(qs, q)
+	if err != nil {
+		return 0, err
+	}
 
 	// 分组
 	if len(q.Groupby) != 0 {
@@ -35,7 +53,10 @@ func GetAll(queryTable interface{}, list interface{}, q *pagequery.QueryParam) e
 	qs := DB.Model(queryTable)
 
 	// 应用过滤条件
-	qs = BuildFilter(qs, q.Query)
+	qs, err := BuildFilter(qs, q)
+	if err != nil {
+		return err
+	}
 
 	// 关联查询
 	if q.Relate != "" {
@@ -53,9 +74,9 @@ func GetAll(queryTable interface{}, list interface{}, q *pagequery.QueryParam) e
 		qs = qs.Group(groupByStr)
 	}
 
-	// 排序
-	if q.Sortby != "" {
-		qs = qs.Order(q.Sortby)
+	// 排序，与 Kubernetes 资源列表保持一致的 sortby 语义
+	if order := BuildOrder(q.Sortby); order != "" {
+		qs = qs.Order(order)
 	}
 
 	// 应用分页
@@ -68,13 +89,195 @@ func GetAll(queryTable interface{}, list interface{}, q *pagequery.QueryParam) e
 	return nil
 }
 
-// BuildFilter 构建过滤条件
-func BuildFilter(db *gorm.DB, query map[string]interface{}) *gorm.DB {
-	for key, value := range query {
-		// 这里简单的按键值对添加过滤条件，复杂的过滤条件可以根据需要进一步扩展
-		if value != nil {
-			db = db.Where(fmt.Sprintf("%s = ?", key), value)
+// GetAllByCursor 使用 keyset 分页查询，按主键 id 排序返回游标之后的 PageSize 条记录，
+// 不再使用 OFFSET，避免大表翻页时扫描前面的全部记录。list 必须是结构体切片的指针。
+// sortby 只支持 id（默认）和 -id，其它排序字段返回 pagequery.ErrInvalidQuery。
+// 返回下一页的 continue 令牌，没有更多数据时为空。
+func GetAllByCursor(queryTable interface{}, list interface{}, q *pagequery.QueryParam) (string, error) {
+	qs, err := BuildFilter(DB.Model(queryTable), q)
+	if err != nil {
+		return "", err
+	}
+	return findByCursor(qs, list, q)
+}
+
+// findByCursor 在 qs 的查询条件上按 id 做 keyset 分页
+func findByCursor(qs *gorm.DB, list interface{}, q *pagequery.QueryParam) (string, error) {
+	desc, err := cursorOrder(q.Sortby)
+	if err != nil {
+		return "", err
+	}
+	cursor, err := pagequery.DecodeCursor(q.Continue)
+	if err != nil {
+		return "", err
+	}
+
+	order := "id ASC"
+	if desc {
+		order = "id DESC"
+	}
+	if cursor.LastID > 0 {
+		if cursor.Desc != desc {
+			return "", fmt.Errorf("%w: sortby changed between pages", pagequery.ErrInvalidContinue)
+		}
+		if desc {
+			qs = qs.Where("id < ?", cursor.LastID)
+		} else {
+			qs = qs.Where("id > ?", cursor.LastID)
+		}
+	}
+
+	// 多查一条用于判断是否还有下一页
+	if err := qs.Order(order).Limit(int(q.Limit()) + 1).Find(list).Error; err != nil {
+		return "", err
+	}
+
+	items := reflect.ValueOf(list).Elem()
+	if int64(items.Len()) <= q.Limit() {
+		return "", nil
+	}
+	items.Set(items.Slice(0, int(q.Limit())))
+
+	lastID, err := recordID(items.Index(items.Len() - 1))
+	if err != nil {
+		return "", err
+	}
+	return pagequery.Cursor{LastID: lastID, Desc: desc}.Encode(), nil
+}
+
+// cursorOrder 游标分页只能按 id 排序，返回是否降序
+func cursorOrder(sortby string) (bool, error) {
+	switch strings.TrimSpace(sortby) {
+	case "", "id", "+id":
+		return false, nil
+	case "-id":
+		return true, nil
+	}
+	return false, fmt.Errorf("%w: sortby %q is not supported with continue, expected id or -id", pagequery.ErrInvalidQuery, sortby)
+}
+
+// recordID 获取记录的主键，兼容 ID 和 Id 两种字段命名
+func recordID(v reflect.Value) (int64, error) {
+	v = reflect.Indirect(v)
+	for _, name := range []string{"ID", "Id"} {
+		if f := v.FieldByName(name); f.IsValid() && f.CanInt() {
+			return f.Int(), nil
+		}
+	}
+	return 0, fmt.Errorf("%s has no integer id field", v.Type())
+}
+
+// BuildFilter 构建过滤条件，与 Kubernetes 资源列表保持一致的 filter 语义：
+// Query 作为第一组条件，OrQuery 中的每一项作为额外的一组，组内为 AND，组间为 OR。
+// 键支持 name__eq 等操作符形式，不带操作符时为等于。字段或操作符不合法时返回 pagequery.ErrInvalidQuery。
+func BuildFilter(db *gorm.DB, q *pagequery.QueryParam) (*gorm.DB, error) {
+	groups := make([]string, 0, len(q.OrQuery)+1)
+	args := make([]interface{}, 0)
+	for _, query := range append([]map[string]interface{}{q.Query}, q.OrQuery...) {
+		conds := make([]string, 0, len(query))
+		for key, value := range query {
+			if value == nil {
+				continue
+			}
+			cond, condArgs, err := buildCondition(key, value)
+			if err != nil {
+				return nil, err
+			}
+			conds = append(conds, cond)
+			args = append(args, condArgs...)
+		}
+		if len(conds) == 0 {
+			// 空的条件组匹配所有记录
+			return db, nil
+		}
+		groups = append(groups, "("+strings.Join(conds, " AND ")+")")
+	}
+	return db.Where(strings.Join(groups, " OR "), args...), nil
+}
+
+// CheckFilterColumns 检查过滤条件只使用了允许的字段，用于对所有用户开放的列表
+func CheckFilterColumns(q *pagequery.QueryParam, columns ...string) error {
+	for _, query := range append([]map[string]interface{}{q.Query}, q.OrQuery...) {
+		for key := range query {
+			column, _, _ := strings.Cut(key, filterOperatorSep)
+			if !slices.Contains(columns, column) {
+				return fmt.Errorf("%w: filter field %q is not allowed, expected one of %s", pagequery.ErrInvalidQuery, column, strings.Join(columns, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// buildCondition 将 filter 中的一个键值对转换为 SQL 条件，例如 createTime__gt 转换为 create_time > ?
+func buildCondition(key string, value interface{}) (string, []interface{}, error) {
+	column, operator := key, "eq"
+	if idx := strings.Index(key, filterOperatorSep); idx >= 0 {
+		column, operator = key[:idx], key[idx+len(filterOperatorSep):]
+	}
+	if column == "" || !orderColumnRegexp.MatchString(column) {
+		return "", nil, fmt.Errorf("%w: invalid filter field %q", pagequery.ErrInvalidQuery, key)
+	}
+	column = snaker.CamelToSnake(column)
+
+	switch operator {
+	case "eq":
+		return column + " = ?", []interface{}{value}, nil
+	case "ne":
+		return column + " <> ?", []interface{}{value}, nil
+	case "gt":
+		return column + " > ?", []interface{}{value}, nil
+	case "gte":
+		return column + " >= ?", []interface{}{value}, nil
+	case "lt":
+		return column + " < ?", []interface{}{value}, nil
+	case "lte":
+		return column + " <= ?", []interface{}{value}, nil
+	case "contains":
+		return column + " LIKE ?", []interface{}{"%" + likeEscaper.Replace(fmt.Sprint(value)) + "%"}, nil
+	case "in":
+		values := make([]string, 0)
+		for _, v := range strings.Split(fmt.Sprint(value), filterValueSep) {
+			if v != "" {
+				values = append(values, v)
+			}
+		}
+		if len(values) == 0 {
+			return "", nil, fmt.Errorf("%w: %s requires at least one value", pagequery.ErrInvalidQuery, key)
+		}
+		return column + " IN ?", []interface{}{values}, nil
+	case "exists":
+		exists, err := strconv.ParseBool(fmt.Sprint(value))
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %s expects true or false, got %q", pagequery.ErrInvalidQuery, key, value)
+		}
+		if exists {
+			return column + " IS NOT NULL", nil, nil
+		}
+		return column + " IS NULL", nil, nil
+	}
+	return "", nil, fmt.Errorf("%w: unsupported filter operator %q in %q", pagequery.ErrInvalidQuery, operator, key)
+}
+
+// LIKE 的通配符需要转义，contains 只做子串匹配
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// BuildOrder 将 sortby 参数转换为 ORDER BY 子句，例如 -createTime,name 转换为 create_time DESC, name ASC。
+// 非法字段会被忽略。
+func BuildOrder(sortby string) string {
+	orders := make([]string, 0)
+	for _, field := range strings.Split(sortby, ",") {
+		field = strings.TrimSpace(field)
+		direction := "ASC"
+		if strings.HasPrefix(field, "-") {
+			direction = "DESC"
+			field = field[1:]
+		} else if strings.HasPrefix(field, "+") {
+			field = field[1:]
+		}
+		if field == "" || !orderColumnRegexp.MatchString(field) {
+			continue
 		}
+		orders = append(orders, fmt.Sprintf("%s %s", snaker.CamelToSnake(field), direction))
 	}
-	return db
+	return strings.Join(orders, ", ")
 }
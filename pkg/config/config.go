@@ -3,6 +3,7 @@ package config
 import (
 	"crypto/tls"
 	"fmt"
+	"os"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
@@ -12,10 +13,19 @@ import (
 var Conf = new(Config)
 
 type Config struct {
-	App      AppConf  `ini:"App"`
-	DataBase DataBase `ini:"DataBase"`
-	Log      LogConf  `ini:"Log"`
-	Auth     Auth     `ini:"Auth"`
+	App          AppConf      `ini:"App"`
+	DataBase     DataBase     `ini:"DataBase"`
+	Log          LogConf      `ini:"Log"`
+	Auth         Auth         `ini:"Auth"`
+	Watch        Watch        `ini:"Watch"`
+	Scheduler    Scheduler    `ini:"Scheduler"`
+	PodLog       PodLog       `ini:"PodLog"`
+	FileTransfer FileTransfer `ini:"FileTransfer"`
+	Recording    Recording    `ini:"Recording"`
+	Terminal     Terminal     `ini:"Terminal"`
+	Debug        Debug        `ini:"Debug"`
+	NodeShell    NodeShell    `ini:"NodeShell"`
+	PortForward  PortForward  `ini:"PortForward"`
 }
 
 type AppConf struct {
@@ -47,6 +57,78 @@ type LogConf struct {
 	LogPath  string `ini:"LogPath"`
 }
 
+// Watch 资源变化推送配置
+type Watch struct {
+	MaxConnectionsPerUser int `ini:"MaxConnectionsPerUser"` // 每个用户同时打开的 watch 连接数上限，0 表示不限制
+	HeartbeatSeconds      int `ini:"HeartbeatSeconds"`      // 心跳间隔（秒），默认 30
+	BufferSize            int `ini:"BufferSize"`            // 每个连接缓存的事件数，客户端消费过慢导致缓存写满时断开连接，默认 100
+}
+
+// Scheduler 定时任务配置
+type Scheduler struct {
+	Enabled           bool `ini:"Enabled"`           // 是否在当前实例运行定时任务，多个实例通过数据库租约选主，只有 leader 执行任务
+	LeaseSeconds      int  `ini:"LeaseSeconds"`      // leader 租约时长（秒），默认 30
+	IntervalSeconds   int  `ini:"IntervalSeconds"`   // 检查到期任务的间隔（秒），默认 10
+	JobTimeoutSeconds int  `ini:"JobTimeoutSeconds"` // 单次执行的超时时间（秒），默认 600
+}
+
+// PodLog 容器日志推送配置
+type PodLog struct {
+	MaxStreamsPerUser    int `ini:"MaxStreamsPerUser"`    // 每个用户同时打开的日志流数量上限，0 表示不限制
+	HeartbeatSeconds     int `ini:"HeartbeatSeconds"`     // 心跳间隔（秒），默认 30
+	BufferLines          int `ini:"BufferLines"`          // 每个日志流缓存的行数，写满后暂停读取，默认 1000
+	MaxStreamsPerRequest int `ini:"MaxStreamsPerRequest"` // 聚合日志时单个请求同时读取的容器数上限，默认 10
+	MaxDownloadBytes     int `ini:"MaxDownloadBytes"`     // 下载日志的最大字节数，默认 500MB
+	MaxSearchBytes       int `ini:"MaxSearchBytes"`       // 搜索日志时读取的最大字节数，默认 50MB
+}
+
+// FileTransfer 容器文件上传下载配置
+type FileTransfer struct {
+	MaxUploadBytes   int64 `ini:"MaxUploadBytes"`   // 单次上传的最大字节数，默认 100MB
+	MaxDownloadBytes int64 `ini:"MaxDownloadBytes"` // 单次下载的最大字节数（打包前的大小），默认 500MB
+}
+
+// Recording 终端会话录像配置
+type Recording struct {
+	Enabled     bool   `ini:"Enabled"`     // 是否录制终端会话，开启后录像无法创建时拒绝打开终端
+	Storage     string `ini:"Storage"`     // 录像存储：local（本地磁盘，默认）或 memory（对象存储的替代实现，重启后丢失）
+	Dir         string `ini:"Dir"`         // 本地存储的目录
+	RecordInput bool   `ini:"RecordInput"` // 是否记录用户输入，输入中可能包含密码
+	MaxBytes    int64  `ini:"MaxBytes"`    // 单个录像的最大字节数，超过后不再记录，默认 100MB
+}
+
+// Terminal 容器终端配置
+type Terminal struct {
+	AdminOnly           bool   `ini:"AdminOnly"`           // 只有管理员可以打开终端
+	ProtectedNamespaces string `ini:"ProtectedNamespaces"` // 只有管理员可以打开终端的命名空间，多个以逗号分隔，例如 kube-system
+	TicketTTLSeconds    int    `ini:"TicketTTLSeconds"`    // 终端 ticket 的有效期（秒），ticket 只能使用一次，默认 60
+	// 以下限制为 0 时不限制，多实例部署时每个实例分别计算
+	IdleTimeoutSeconds    int `ini:"IdleTimeoutSeconds"`    // 没有输入超过该时间（秒）后关闭会话
+	MaxDurationSeconds    int `ini:"MaxDurationSeconds"`    // 会话的最长持续时间（秒）
+	MaxSessionsPerUser    int `ini:"MaxSessionsPerUser"`    // 每个用户同时打开的会话数量上限
+	MaxSessionsPerCluster int `ini:"MaxSessionsPerCluster"` // 每个集群同时打开的会话数量上限
+}
+
+// Debug 临时调试容器配置
+type Debug struct {
+	ImageAllowlist      string `ini:"ImageAllowlist"`      // 允许使用的调试镜像，多个以逗号分隔，以 * 结尾时按前缀匹配，为空时不允许调试
+	DefaultImage        string `ini:"DefaultImage"`        // 未指定镜像时使用的调试镜像
+	StartTimeoutSeconds int    `ini:"StartTimeoutSeconds"` // 等待调试容器启动的时间（秒），默认 60
+}
+
+// NodeShell 节点终端配置，节点终端只有管理员可以使用
+type NodeShell struct {
+	Image               string `ini:"Image"`               // 节点终端 Pod 使用的镜像，需要包含 chroot，默认 busybox:1.36
+	Namespace           string `ini:"Namespace"`           // 节点终端 Pod 所在的命名空间，需要允许特权 Pod，默认 kube-system
+	StartTimeoutSeconds int    `ini:"StartTimeoutSeconds"` // 等待 Pod 启动的时间（秒），默认 60
+}
+
+// PortForward 端口转发配置
+type PortForward struct {
+	AdminOnly           bool   `ini:"AdminOnly"`           // 只有管理员可以使用端口转发
+	ProtectedNamespaces string `ini:"ProtectedNamespaces"` // 只有管理员可以使用端口转发的命名空间，多个以逗号分隔
+}
+
 type Auth struct {
 	Oauth2 Oauth2Conf `ini:"Oauth2"`
 	Ldap   LdapConf   `ini:"Ldap"`
@@ -83,6 +165,11 @@ type LdapConf struct {
 
 // 设置读取配置信息
 func init() {
+	// 本地端口转发模式（gwayne port-forward）在开发者本机运行，不读取服务端配置
+	if len(os.Args) > 1 && os.Args[1] == "port-forward" {
+		return
+	}
+
 	viper.SetConfigName("app")
 	viper.SetConfigType("ini")  // 设置为 ini 格式
 	viper.AddConfigPath("conf") // 配置文件路径
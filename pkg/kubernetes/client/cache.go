@@ -4,11 +4,16 @@ import (
 	"sync"
 
 	"github.com/JLPAY/gwayne/pkg/kubernetes/client/api"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	appsv1 "k8s.io/client-go/listers/apps/v1"
 	autoscalingv1 "k8s.io/client-go/listers/autoscaling/v1"
 	corev1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 )
 
@@ -17,15 +22,28 @@ var sharedInformerFactoryCache = sync.Map{} // 用于缓存工厂实例
 type CacheFactory struct {
 	stopChan              chan struct{}
 	sharedInformerFactory informers.SharedInformerFactory
+	dynamicClient         dynamic.Interface
+	// 启动时注册并一直运行的资源
+	cachedResources map[schema.GroupVersionResource]bool
+
+	// watch 按需启动的 informer，最后一个使用者释放后停止
+	watchLock      sync.Mutex
+	watchInformers map[schema.GroupVersionResource]*watchInformer
+}
+
+// watchInformer 按需启动的动态 informer 和使用者计数
+type watchInformer struct {
+	informer cache.SharedIndexInformer
+	stopChan chan struct{}
+	refs     int
 }
 
 func (c ClusterManager) Close() {
 	// 清理 informer 和 stop 通道
-	close(c.CacheFactory.stopChan)
-	c.CacheFactory.sharedInformerFactory.Shutdown()
+	c.CacheFactory.Close()
 }
 
-func buildCacheController(client *kubernetes.Clientset, clusterName string) (*CacheFactory, error) {
+func buildCacheController(client *kubernetes.Clientset, dynamicClient dynamic.Interface, clusterName string) (*CacheFactory, error) {
 	stop := make(chan struct{})
 
 	// 使用单例的 SharedInformerFactory
@@ -42,6 +60,7 @@ func buildCacheController(client *kubernetes.Clientset, clusterName string) (*Ca
 	klog.V(2).Infof("start cache controller for cluster %s , has %d ResourceKind", clusterName, len(ResourceMaps))
 
 	// Register all Informers without running them
+	cachedResources := make(map[schema.GroupVersionResource]bool, len(ResourceMaps))
 	for _, gvrk := range ResourceMaps {
 		klog.V(2).Infof("创建sharedInformerFactory.ForResource,cluster: %s Resource Name:%s Kind: %s value: %v", clusterName, gvrk.GroupVersionResourceKind.GroupVersionResource, gvrk.GroupVersionResourceKind.Kind, gvrk)
 		genericInformer, err := sharedInformerFactory.ForResource(gvrk.GroupVersionResourceKind.GroupVersionResource)
@@ -50,11 +69,15 @@ func buildCacheController(client *kubernetes.Clientset, clusterName string) (*Ca
 		}
 
 		go genericInformer.Informer().Run(stop)
+		cachedResources[gvrk.GroupVersionResourceKind.GroupVersionResource] = true
 	}
 
 	return &CacheFactory{
 		stopChan:              stop,
 		sharedInformerFactory: sharedInformerFactory,
+		dynamicClient:         dynamicClient,
+		cachedResources:       cachedResources,
+		watchInformers:        map[schema.GroupVersionResource]*watchInformer{},
 	}, nil
 }
 
@@ -101,6 +124,55 @@ func (c *CacheFactory) HPALister() autoscalingv1.HorizontalPodAutoscalerLister {
 	return c.sharedInformerFactory.Autoscaling().V1().HorizontalPodAutoscalers().Lister()
 }
 
+// Informer 返回指定资源的 informer，用于监听资源变化，使用完后需要调用返回的 release。
+// 启动时已经缓存的资源直接使用共享的 informer；其它资源（包括 CRD）按需启动动态 informer，
+// 同一资源的使用者共用一个 informer，最后一个使用者调用 release 后停止。
+func (c *CacheFactory) Informer(gvr schema.GroupVersionResource) (cache.SharedIndexInformer, func(), error) {
+	if c.cachedResources[gvr] {
+		genericInformer, err := c.sharedInformerFactory.ForResource(gvr)
+		if err != nil {
+			return nil, nil, err
+		}
+		return genericInformer.Informer(), func() {}, nil
+	}
+
+	c.watchLock.Lock()
+	defer c.watchLock.Unlock()
+	w, ok := c.watchInformers[gvr]
+	if !ok {
+		// 只用于推送变化，不需要定期 resync
+		informer := dynamicinformer.NewFilteredDynamicInformer(c.dynamicClient, gvr, metav1.NamespaceAll, 0,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, nil).Informer()
+		w = &watchInformer{informer: informer, stopChan: make(chan struct{})}
+		c.watchInformers[gvr] = w
+		klog.V(2).Infof("start watch informer for %s", gvr)
+		go informer.Run(w.stopChan)
+	}
+	w.refs++
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() { c.releaseInformer(gvr, w) })
+	}
+	return w.informer, release, nil
+}
+
+func (c *CacheFactory) releaseInformer(gvr schema.GroupVersionResource, w *watchInformer) {
+	c.watchLock.Lock()
+	defer c.watchLock.Unlock()
+	// 缓存工厂关闭时已经停止
+	if c.watchInformers[gvr] != w {
+		return
+	}
+	w.refs--
+	if w.refs > 0 {
+		return
+	}
+	delete(c.watchInformers, gvr)
+	close(w.stopChan)
+	klog.V(2).Infof("stop watch informer for %s", gvr)
+}
+
 // Close 关闭缓存工厂
 func (c *CacheFactory) Close() {
 	// 清理 informer 和 stop 通道
@@ -110,4 +182,10 @@ func (c *CacheFactory) Close() {
 	if c.sharedInformerFactory != nil {
 		c.sharedInformerFactory.Shutdown()
 	}
+	c.watchLock.Lock()
+	for gvr, w := range c.watchInformers {
+		close(w.stopChan)
+		delete(c.watchInformers, gvr)
+	}
+	c.watchLock.Unlock()
 }
@@ -97,7 +97,7 @@ func BuildApiserverClient() {
 				return
 			}
 
-			cacheFactory, err := buildCacheController(clientSet, cluster.Name)
+			cacheFactory, err := buildCacheController(clientSet, dynamicClient, cluster.Name)
 			if err != nil {
 				klog.Errorf("failed to build cache controller for cluster %s: %v", cluster.Name, err)
 				return
@@ -13,22 +13,28 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/klog/v2"
 )
 
 // 定义了资源操作的标准方法，包括 Create、Update、Get、List 和 Delete
 // 支持处理命名空间资源和全局资源。
 type ResourceHandler interface {
-	Create(kind string, namespace string, object *runtime.Unknown) (*runtime.Unknown, error)
-	Update(kind string, namespace string, name string, object *runtime.Unknown) (*runtime.Unknown, error)
+	Create(kind string, namespace string, object *runtime.Unknown, options metav1.CreateOptions) (*runtime.Unknown, error)
+	Update(kind string, namespace string, name string, object *runtime.Unknown, options metav1.UpdateOptions) (*runtime.Unknown, error)
 	Get(kind string, namespace string, name string) (runtime.Object, error)
 	List(kind string, namespace string, labelSelector string) ([]runtime.Object, error)
 	Delete(kind string, namespace string, name string, options *metav1.DeleteOptions) error
+	Patch(kind string, namespace string, name string, patchType types.PatchType, data []byte, options metav1.PatchOptions) (*runtime.Unknown, error)
 	GVRK(resourceName string) (api.ResourceMap, error)
 }
 
+// FieldManager gwayne 修改资源时使用的 field manager
+const FieldManager = "gwayne"
+
 type resourceHandler struct {
 	client        *kubernetes.Clientset
 	dynamicClient *dynamic.DynamicClient
@@ -98,7 +104,7 @@ func (h *resourceHandler) Get(kind string, namespace string, name string) (runti
 	return obj, nil
 }
 
-func (h *resourceHandler) Create(kind string, namespace string, object *runtime.Unknown) (*runtime.Unknown, error) {
+func (h *resourceHandler) Create(kind string, namespace string, object *runtime.Unknown, options metav1.CreateOptions) (*runtime.Unknown, error) {
 	// 参数检查
 	if kind == "" || object == nil {
 		return nil, fmt.Errorf("invalid input: kind or object cannot be empty")
@@ -118,9 +124,14 @@ func (h *resourceHandler) Create(kind string, namespace string, object *runtime.
 			kind, resource.GroupVersionResourceKind.GroupVersionResource.Group, resource.GroupVersionResourceKind.GroupVersionResource.Version)
 	}
 
-	// 创建 HTTP 请求，设置资源类型、Content-Type 和请求体
+	if options.FieldManager == "" {
+		options.FieldManager = FieldManager
+	}
+
+	// 创建 HTTP 请求，设置资源类型、Content-Type 和请求体，dryRun 等选项作为查询参数
 	req := kubeClient.Post().
 		Resource(kind).
+		VersionedParams(&options, scheme.ParameterCodec).
 		SetHeader("Content-Type", "application/json").
 		Body([]byte(object.Raw))
 
@@ -132,13 +143,13 @@ func (h *resourceHandler) Create(kind string, namespace string, object *runtime.
 	var result runtime.Unknown
 	err = req.Do(context.Background()).Into(&result) // 使用 context.Background() 代替 context.TODO()，如果没有特定的上下文需求
 	if err != nil {
-		return nil, fmt.Errorf("failed to create resource %s in namespace %s: %v", kind, namespace, err)
+		return nil, fmt.Errorf("failed to create resource %s in namespace %s: %w", kind, namespace, err)
 	}
 	return &result, nil
 }
 
 // 使用 dynamicClient 更新resource
-func (h *resourceHandler) Update(kind string, namespace string, name string, object *runtime.Unknown) (*runtime.Unknown, error) {
+func (h *resourceHandler) Update(kind string, namespace string, name string, object *runtime.Unknown, options metav1.UpdateOptions) (*runtime.Unknown, error) {
 	resource, err := h.getResource(kind)
 	if err != nil {
 		return nil, err
@@ -165,19 +176,23 @@ func (h *resourceHandler) Update(kind string, namespace string, name string, obj
 		return nil, fmt.Errorf("failed to unmarshal object: %v", err)
 	}
 
-	// 获取当前资源的状态
-	currentObj, err := resourceInterface.Get(context.Background(), name, metav1.GetOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to get current resource %s/%s/%s: %v", namespace, kind, name, err)
+	// 客户端携带了 resourceVersion 时保持不变，资源已被修改时 apiserver 返回 409 Conflict；
+	// 未携带时使用当前资源的 resourceVersion
+	if unstructuredObj.GetResourceVersion() == "" {
+		currentObj, err := resourceInterface.Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current resource %s/%s/%s: %w", namespace, kind, name, err)
+		}
+		unstructuredObj.SetResourceVersion(currentObj.GetResourceVersion())
 	}
 
-	// 设置 metadata.resourceVersion
-	unstructuredObj.SetResourceVersion(currentObj.GetResourceVersion())
-
 	// 使用 dynamicClient 更新资源
-	updatedObj, err := resourceInterface.Update(context.Background(), unstructuredObj, metav1.UpdateOptions{})
+	if options.FieldManager == "" {
+		options.FieldManager = FieldManager
+	}
+	updatedObj, err := resourceInterface.Update(context.Background(), unstructuredObj, options)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update resource %s/%s/%s: %v", namespace, kind, name, err)
+		return nil, fmt.Errorf("failed to update resource %s/%s/%s: %w", namespace, kind, name, err)
 	}
 
 	// 将更新的对象转化为 runtime.Unknown
@@ -189,6 +204,43 @@ func (h *resourceHandler) Update(kind string, namespace string, name string, obj
 	return &runtime.Unknown{Raw: updatedData}, nil
 }
 
+// 使用 dynamicClient patch resource，支持 json patch、merge patch、strategic merge patch 和 server-side apply
+func (h *resourceHandler) Patch(kind string, namespace string, name string, patchType types.PatchType, data []byte, options metav1.PatchOptions) (*runtime.Unknown, error) {
+	resource, err := h.getResource(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	gvr := schema.GroupVersionResource{
+		Group:    resource.GroupVersionResourceKind.GroupVersionResource.Group,
+		Version:  resource.GroupVersionResourceKind.GroupVersionResource.Version,
+		Resource: kind,
+	}
+
+	var resourceInterface dynamic.ResourceInterface
+	if resource.Namespaced {
+		resourceInterface = h.dynamicClient.Resource(gvr).Namespace(namespace)
+	} else {
+		resourceInterface = h.dynamicClient.Resource(gvr)
+	}
+
+	if options.FieldManager == "" {
+		options.FieldManager = FieldManager
+	}
+
+	patchedObj, err := resourceInterface.Patch(context.Background(), name, patchType, data, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to patch resource %s/%s/%s: %w", namespace, kind, name, err)
+	}
+
+	patchedData, err := json.Marshal(patchedObj.Object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal patched object: %v", err)
+	}
+
+	return &runtime.Unknown{Raw: patchedData}, nil
+}
+
 func (h *resourceHandler) Delete(kind string, namespace string, name string, options *metav1.DeleteOptions) error {
 	resource, err := h.getResource(kind)
 	if err != nil {
@@ -190,7 +190,7 @@ func (lcm *LazyClusterManager) initialize() error {
 	// 构建缓存工厂（可选，根据需要决定是否启用）
 	var cacheFactory *CacheFactory
 	if shouldUseCache(lcm.cluster.Name) {
-		cacheFactory, err = buildCacheController(clientSet, lcm.cluster.Name)
+		cacheFactory, err = buildCacheController(clientSet, dynamicClient, lcm.cluster.Name)
 		if err != nil {
 			klog.Warningf("Failed to build cache for cluster %s: %v", lcm.cluster.Name, err)
 			// 不返回错误，继续使用无缓存模式
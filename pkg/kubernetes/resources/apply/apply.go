@@ -0,0 +1,230 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/klog/v2"
+)
+
+// Mode 应用模式
+type Mode string
+
+const (
+	// ModeReport 逐个应用，失败的对象不影响其它对象
+	ModeReport Mode = "report"
+	// ModeAll 先 dry-run 校验全部对象，全部通过后才真正应用，应用过程中遇到错误立即停止
+	ModeAll Mode = "all"
+)
+
+// Status 单个对象的应用结果
+type Status string
+
+const (
+	StatusApplied Status = "applied"
+	StatusFailed  Status = "failed"
+	// StatusSkipped all 模式下因其它对象失败而没有应用
+	StatusSkipped Status = "skipped"
+)
+
+// Options 应用选项
+type Options struct {
+	// 命名空间级别的对象未指定 metadata.namespace 时使用的命名空间
+	Namespace string
+	Mode      Mode
+	// server-side apply 使用的 field manager
+	FieldManager string
+	// server-side apply 时强制接管冲突的字段
+	Force bool
+	// 只在服务端 dry-run，不修改任何资源
+	DryRun bool
+}
+
+// Result 单个对象的应用结果
+type Result struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	Status     Status `json:"status"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Report 清单的应用结果，Results 按实际应用顺序排列
+type Report struct {
+	Results []Result `json:"results"`
+	Failed  int      `json:"failed"`
+	// all 模式下校验失败，没有修改任何资源
+	Aborted bool `json:"aborted"`
+}
+
+// Applier 使用 server-side apply 应用清单，通过 discovery 将 GVK 映射为资源
+type Applier struct {
+	dynamicClient dynamic.Interface
+	mapper        meta.ResettableRESTMapper
+}
+
+func NewApplier(discoveryClient discovery.DiscoveryInterface, dynamicClient dynamic.Interface) *Applier {
+	return &Applier{
+		dynamicClient: dynamicClient,
+		mapper:        restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient)),
+	}
+}
+
+// Apply 按依赖顺序应用清单中的对象
+func (a *Applier) Apply(ctx context.Context, objs []*unstructured.Unstructured, opts Options) *Report {
+	SortByDependency(objs)
+
+	report := &Report{Results: make([]Result, len(objs))}
+	for i, obj := range objs {
+		report.Results[i] = Result{
+			APIVersion: obj.GetAPIVersion(),
+			Kind:       obj.GetKind(),
+			Namespace:  obj.GetNamespace(),
+			Name:       obj.GetName(),
+		}
+	}
+
+	if opts.DryRun {
+		// dry-run 时 applied 表示校验通过
+		for i := range report.Results {
+			report.Results[i].Status = StatusApplied
+		}
+		if !a.dryRun(ctx, objs, opts, report) && opts.Mode == ModeAll {
+			report.Aborted = true
+		}
+		return report
+	}
+
+	if opts.Mode == ModeAll && !a.validate(ctx, objs, opts, report) {
+		report.Aborted = true
+		return report
+	}
+
+	for i, obj := range objs {
+		err := a.applyObject(ctx, obj, opts, false)
+		report.Results[i].Namespace = obj.GetNamespace()
+		if err == nil {
+			report.Results[i].Status = StatusApplied
+			continue
+		}
+
+		klog.Errorf("Apply %s %s/%s error: %v", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+		report.Results[i].Status = StatusFailed
+		report.Results[i].Error = err.Error()
+		report.Failed++
+		if opts.Mode == ModeAll {
+			for j := i + 1; j < len(objs); j++ {
+				report.Results[j].Status = StatusSkipped
+			}
+			break
+		}
+	}
+	return report
+}
+
+// validate 使用 dry-run 校验全部对象，存在失败的对象时其余对象标记为 skipped
+func (a *Applier) validate(ctx context.Context, objs []*unstructured.Unstructured, opts Options, report *Report) bool {
+	if a.dryRun(ctx, objs, opts, report) {
+		return true
+	}
+	for i := range report.Results {
+		if report.Results[i].Status != StatusFailed {
+			report.Results[i].Status = StatusSkipped
+		}
+	}
+	return false
+}
+
+// dryRun 使用 dry-run 应用全部对象，记录失败的对象，全部通过时返回 true。
+// 依赖清单中尚未创建的命名空间或 CRD 的对象无法在 dry-run 时校验，这类错误会被忽略。
+func (a *Applier) dryRun(ctx context.Context, objs []*unstructured.Unstructured, opts Options, report *Report) bool {
+	namespaces, crds := bundleProvides(objs)
+	for i, obj := range objs {
+		err := a.applyObject(ctx, obj, opts, true)
+		report.Results[i].Namespace = obj.GetNamespace()
+		if err == nil {
+			continue
+		}
+		if meta.IsNoMatchError(err) && crds[obj.GroupVersionKind().GroupKind()] {
+			continue
+		}
+		if apierrors.IsNotFound(err) && namespaces[obj.GetNamespace()] {
+			continue
+		}
+		report.Results[i].Status = StatusFailed
+		report.Results[i].Error = err.Error()
+		report.Failed++
+	}
+	return report.Failed == 0
+}
+
+func (a *Applier) applyObject(ctx context.Context, obj *unstructured.Unstructured, opts Options, dryRun bool) error {
+	gvk := obj.GroupVersionKind()
+	mapping, err := a.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		// 前面的对象可能刚创建了 CRD，刷新 discovery 缓存后重试
+		a.mapper.Reset()
+		mapping, err = a.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+	if err != nil {
+		return err
+	}
+
+	var resourceInterface dynamic.ResourceInterface
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(opts.Namespace)
+		}
+		if obj.GetNamespace() == "" {
+			return fmt.Errorf("namespace is required for %s %s", obj.GetKind(), obj.GetName())
+		}
+		resourceInterface = a.dynamicClient.Resource(mapping.Resource).Namespace(obj.GetNamespace())
+	} else {
+		obj.SetNamespace("")
+		resourceInterface = a.dynamicClient.Resource(mapping.Resource)
+	}
+
+	// server-side apply 不允许携带 managedFields
+	applyObj := obj.DeepCopy()
+	applyObj.SetManagedFields(nil)
+	data, err := applyObj.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	force := opts.Force
+	patchOptions := metav1.PatchOptions{FieldManager: opts.FieldManager, Force: &force}
+	if dryRun {
+		patchOptions.DryRun = []string{metav1.DryRunAll}
+	}
+	_, err = resourceInterface.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, patchOptions)
+	return err
+}
+
+// bundleProvides 返回清单中会创建的命名空间和 CRD
+func bundleProvides(objs []*unstructured.Unstructured) (map[string]bool, map[schema.GroupKind]bool) {
+	namespaces := map[string]bool{}
+	crds := map[schema.GroupKind]bool{}
+	for _, obj := range objs {
+		switch obj.GetKind() {
+		case "Namespace":
+			namespaces[obj.GetName()] = true
+		case "CustomResourceDefinition":
+			group, _, _ := unstructured.NestedString(obj.Object, "spec", "group")
+			kind, _, _ := unstructured.NestedString(obj.Object, "spec", "names", "kind")
+			crds[schema.GroupKind{Group: group, Kind: kind}] = true
+		}
+	}
+	return namespaces, crds
+}
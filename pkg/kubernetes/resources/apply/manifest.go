@@ -0,0 +1,109 @@
+package apply
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// 按依赖关系排列的资源类型，未列出的类型（包括 CRD 实例）排在最后
+var kindOrder = []string{
+	"Namespace",
+	"CustomResourceDefinition",
+	"ServiceAccount",
+	"ClusterRole",
+	"ClusterRoleBinding",
+	"Role",
+	"RoleBinding",
+	"ResourceQuota",
+	"LimitRange",
+	"NetworkPolicy",
+	"PodDisruptionBudget",
+	"PriorityClass",
+	"Secret",
+	"ConfigMap",
+	"StorageClass",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"Service",
+	"Pod",
+	"ReplicationController",
+	"ReplicaSet",
+	"Deployment",
+	"StatefulSet",
+	"DaemonSet",
+	"Job",
+	"CronJob",
+	"HorizontalPodAutoscaler",
+	"IngressClass",
+	"Ingress",
+}
+
+var kindPriority = func() map[string]int {
+	priority := make(map[string]int, len(kindOrder))
+	for i, kind := range kindOrder {
+		priority[kind] = i
+	}
+	return priority
+}()
+
+// ParseManifests 解析多文档 YAML 或 JSON，kind 为 List 的对象会展开为其中的 items
+func ParseManifests(data []byte) ([]*unstructured.Unstructured, error) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	objs := make([]*unstructured.Unstructured, 0)
+	for i := 0; ; i++ {
+		var raw map[string]interface{}
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode document %d: %v", i, err)
+		}
+		// 空文档，例如连续的 ---
+		if len(raw) == 0 {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{Object: raw}
+		if obj.IsList() {
+			list, err := obj.ToList()
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode list in document %d: %v", i, err)
+			}
+			for j := range list.Items {
+				objs = append(objs, &list.Items[j])
+			}
+			continue
+		}
+		objs = append(objs, obj)
+	}
+
+	for _, obj := range objs {
+		if obj.GetKind() == "" || obj.GetAPIVersion() == "" {
+			return nil, fmt.Errorf("object %q is missing apiVersion or kind", obj.GetName())
+		}
+		if obj.GetName() == "" {
+			return nil, fmt.Errorf("%s object is missing metadata.name", obj.GetKind())
+		}
+	}
+	return objs, nil
+}
+
+// SortByDependency 按依赖关系稳定排序，同类资源保持清单中的顺序
+func SortByDependency(objs []*unstructured.Unstructured) {
+	sort.SliceStable(objs, func(i, j int) bool {
+		return priority(objs[i]) < priority(objs[j])
+	})
+}
+
+func priority(obj *unstructured.Unstructured) int {
+	if p, ok := kindPriority[obj.GetKind()]; ok {
+		return p
+	}
+	return len(kindOrder)
+}
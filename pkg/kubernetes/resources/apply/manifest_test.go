@@ -0,0 +1,59 @@
+package apply
+
+import (
+	"testing"
+)
+
+const testManifest = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+---
+---
+{"apiVersion": "v1", "kind": "List", "items": [
+  {"apiVersion": "v1", "kind": "ServiceAccount", "metadata": {"name": "web"}},
+  {"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "w"}}
+]}
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: demo
+`
+
+func TestParseManifestsAndSort(t *testing.T) {
+	objs, err := ParseManifests([]byte(testManifest))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	SortByDependency(objs)
+
+	want := []string{"Namespace", "ServiceAccount", "ConfigMap", "Deployment", "Widget"}
+	if len(objs) != len(want) {
+		t.Fatalf("expected %d objects, got %d", len(want), len(objs))
+	}
+	for i, obj := range objs {
+		if obj.GetKind() != want[i] {
+			t.Fatalf("expected order %v, got %s at %d", want, obj.GetKind(), i)
+		}
+	}
+}
+
+func TestParseManifestsErrors(t *testing.T) {
+	manifests := []string{
+		"kind: ConfigMap\nmetadata:\n  name: a\n",
+		"apiVersion: v1\nkind: ConfigMap\n",
+		"apiVersion: v1\nkind: [\n",
+	}
+	for _, manifest := range manifests {
+		if _, err := ParseManifests([]byte(manifest)); err == nil {
+			t.Errorf("expected error for %q", manifest)
+		}
+	}
+}
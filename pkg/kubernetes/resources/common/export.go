@@ -0,0 +1,33 @@
+package common
+
+import "encoding/json"
+
+// 导出资源时去掉的由服务端维护的 metadata 字段
+var exportIgnoredMetadataFields = []string{
+	"managedFields",
+	"resourceVersion",
+	"uid",
+	"creationTimestamp",
+	"generation",
+	"selfLink",
+}
+
+// ExportObject 去掉 status 和由服务端维护的 metadata 字段，返回可以直接用于创建资源的对象
+func ExportObject(obj interface{}) (map[string]interface{}, error) {
+	objByte, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var exported map[string]interface{}
+	if err := json.Unmarshal(objByte, &exported); err != nil {
+		return nil, err
+	}
+
+	delete(exported, "status")
+	if metadata, ok := exported["metadata"].(map[string]interface{}); ok {
+		for _, field := range exportIgnoredMetadataFields {
+			delete(metadata, field)
+		}
+	}
+	return exported, nil
+}
@@ -0,0 +1,205 @@
+package cp
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	ErrTooLarge          = errors.New("file size exceeds the limit")
+	ErrNotRegularFile    = errors.New("path is not a regular file")
+	ErrInvalidPath       = errors.New("path must be an absolute path")
+	ErrProtectedPath     = errors.New("path is not allowed")
+	ErrInvalidFileName   = errors.New("invalid file name")
+	ErrDuplicateFileName = errors.New("duplicate file name")
+)
+
+// 虚拟文件系统，读写没有意义并且可能一直阻塞
+var protectedPaths = []string{"/proc", "/sys", "/dev"}
+
+// Exec 在容器中执行命令，由调用方通过 exec 子资源实现，stdin 为 nil 时不打开标准输入
+type Exec func(ctx context.Context, cmd []string, stdin io.Reader, stdout io.Writer) error
+
+// ValidatePath 检查容器内的路径，返回清理后的绝对路径
+func ValidatePath(p string) (string, error) {
+	if !strings.HasPrefix(p, "/") || strings.ContainsRune(p, 0) {
+		return "", ErrInvalidPath
+	}
+	p = path.Clean(p)
+	for _, protected := range protectedPaths {
+		if p == protected || strings.HasPrefix(p, protected+"/") {
+			return "", fmt.Errorf("%w: %s", ErrProtectedPath, p)
+		}
+	}
+	return p, nil
+}
+
+// FileName 返回上传文件的文件名，浏览器提交的文件名可能包含客户端的目录，只保留最后一级
+func FileName(name string) (string, error) {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "" || name == "." || name == ".." || name == "/" || strings.ContainsRune(name, 0) {
+		return "", ErrInvalidFileName
+	}
+	return name, nil
+}
+
+// File 上传的文件
+type File struct {
+	Name string
+	Size int64
+	Open func() (io.ReadCloser, error)
+}
+
+// Upload 将文件打包为 tar 流，通过 tar 命令解压到容器的 dir 目录，目录需要已经存在。
+// 返回写入的文件内容字节数。
+func Upload(ctx context.Context, exec Exec, dir string, files []File) (int64, error) {
+	dir, err := ValidatePath(dir)
+	if err != nil {
+		return 0, err
+	}
+	names := make(map[string]bool, len(files))
+	for i := range files {
+		if files[i].Name, err = FileName(files[i].Name); err != nil {
+			return 0, err
+		}
+		if names[files[i].Name] {
+			return 0, fmt.Errorf("%w: %s", ErrDuplicateFileName, files[i].Name)
+		}
+		names[files[i].Name] = true
+	}
+
+	reader, writer := io.Pipe()
+	var written int64
+	writeErr := make(chan error, 1)
+	go func() {
+		var err error
+		written, err = writeTar(writer, files)
+		writer.CloseWithError(err)
+		writeErr <- err
+	}()
+
+	err = exec(ctx, []string{"tar", "-xmf", "-", "-C", dir}, reader, nil)
+	// 命令提前退出时结束写入
+	reader.CloseWithError(errors.New("upload aborted"))
+	if werr := <-writeErr; werr != nil && err == nil {
+		err = werr
+	}
+	return written, err
+}
+
+func writeTar(w io.Writer, files []File) (int64, error) {
+	tw := tar.NewWriter(w)
+	var written int64
+	now := time.Now()
+	for _, file := range files {
+		err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     file.Name,
+			Size:     file.Size,
+			Mode:     0644,
+			ModTime:  now,
+		})
+		if err != nil {
+			return written, err
+		}
+		src, err := file.Open()
+		if err != nil {
+			return written, err
+		}
+		n, err := io.CopyN(tw, src, file.Size)
+		src.Close()
+		written += n
+		if err != nil {
+			return written, fmt.Errorf("write %s: %v", file.Name, err)
+		}
+	}
+	return written, tw.Close()
+}
+
+// Archive 从容器中读取的 tar 流
+type Archive struct {
+	reader *bufio.Reader
+	pipe   *io.PipeReader
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Download 通过 tar 命令打包容器中的文件或目录，tar 中的文件名以 ./<最后一级路径> 开头。
+// 命令没有任何输出时（例如路径不存在）直接返回命令的错误，调用方可以据此返回错误码。
+func Download(ctx context.Context, exec Exec, p string) (*Archive, error) {
+	p, err := ValidatePath(p)
+	if err != nil {
+		return nil, err
+	}
+	dir, base := path.Split(p)
+	if base == "" {
+		base = "."
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	reader, writer := io.Pipe()
+	a := &Archive{
+		reader: bufio.NewReader(reader),
+		pipe:   reader,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(a.done)
+		// 以 ./ 开头避免以 - 开头的文件名被当作 tar 的选项
+		err := exec(ctx, []string{"tar", "-cf", "-", "-C", dir, "./" + base}, nil, writer)
+		writer.CloseWithError(err)
+	}()
+
+	// 命令的错误通过管道返回，没有错误也没有输出时为 io.EOF
+	if _, err := a.reader.Peek(1); err != nil {
+		a.Close()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("empty archive")
+		}
+		return nil, err
+	}
+	return a, nil
+}
+
+// WriteTarGz 将 tar 流压缩后写入 dst，返回压缩前的字节数。
+// 超过 max 时返回 ErrTooLarge，此时不写入 gzip 的结尾，客户端解压会失败而不会得到不完整的文件。
+func (a *Archive) WriteTarGz(dst io.Writer, max int64) (int64, error) {
+	gw := gzip.NewWriter(dst)
+	n, err := io.Copy(gw, io.LimitReader(a.reader, max+1))
+	if err != nil {
+		return n, err
+	}
+	if n > max {
+		return max, ErrTooLarge
+	}
+	return n, gw.Close()
+}
+
+// File 返回 tar 流中的第一个文件，路径不是普通文件时返回 ErrNotRegularFile
+func (a *Archive) File() (*tar.Header, io.Reader, error) {
+	tr := tar.NewReader(a.reader)
+	header, err := tr.Next()
+	if err != nil {
+		return nil, nil, err
+	}
+	if header.Typeflag != tar.TypeReg {
+		return nil, nil, ErrNotRegularFile
+	}
+	return header, tr, nil
+}
+
+// Close 结束读取，命令还在输出时会被中止
+func (a *Archive) Close() {
+	a.pipe.CloseWithError(errors.New("archive closed"))
+	a.cancel()
+	<-a.done
+}
@@ -0,0 +1,184 @@
+package cp
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestValidatePath(t *testing.T) {
+	for input, expected := range map[string]string{
+		"/tmp":          "/tmp",
+		"/tmp/../etc/":  "/etc",
+		"/":             "/",
+		"/data//a/./b":  "/data/a/b",
+		"/proc2/status": "/proc2/status",
+	} {
+		p, err := ValidatePath(input)
+		if err != nil || p != expected {
+			t.Errorf("ValidatePath(%q) = %q, %v, expected %q", input, p, err, expected)
+		}
+	}
+	for _, input := range []string{"", "tmp", "../etc", "/tmp/a\x00b", "/proc", "/proc/1/environ", "/sys/../dev/null"} {
+		if _, err := ValidatePath(input); err == nil {
+			t.Errorf("ValidatePath(%q) expected error", input)
+		}
+	}
+}
+
+func TestFileName(t *testing.T) {
+	for input, expected := range map[string]string{
+		"app.jar":               "app.jar",
+		"dir/app.jar":           "app.jar",
+		`C:\Users\me\app.jar`:   "app.jar",
+		"../../etc/passwd":      "passwd",
+		"-checkpoint-action=sh": "-checkpoint-action=sh",
+	} {
+		name, err := FileName(input)
+		if err != nil || name != expected {
+			t.Errorf("FileName(%q) = %q, %v, expected %q", input, name, err, expected)
+		}
+	}
+	for _, input := range []string{"", ".", "..", "/", "a/.."} {
+		if _, err := FileName(input); err == nil {
+			t.Errorf("FileName(%q) expected error", input)
+		}
+	}
+}
+
+func newFile(name, content string) File {
+	return File{Name: name, Size: int64(len(content)), Open: func() (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader(content)), nil
+	}}
+}
+
+func TestUpload(t *testing.T) {
+	var cmd []string
+	files := map[string]string{}
+	exec := func(ctx context.Context, c []string, stdin io.Reader, stdout io.Writer) error {
+		cmd = c
+		tr := tar.NewReader(stdin)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			content, _ := io.ReadAll(tr)
+			files[header.Name] = string(content)
+		}
+	}
+
+	n, err := Upload(context.TODO(), exec, "/data/", []File{newFile("dir/a.txt", "hello"), newFile("b.txt", "world!")})
+	if err != nil {
+		t.Fatalf("Upload error: %v", err)
+	}
+	if n != 11 || files["a.txt"] != "hello" || files["b.txt"] != "world!" {
+		t.Errorf("unexpected upload result %d %v", n, files)
+	}
+	if strings.Join(cmd, " ") != "tar -xmf - -C /data" {
+		t.Errorf("unexpected command %v", cmd)
+	}
+
+	if _, err := Upload(context.TODO(), exec, "/data", []File{newFile("a", "1"), newFile("x/a", "2")}); !errors.Is(err, ErrDuplicateFileName) {
+		t.Errorf("expected duplicate file name error, got %v", err)
+	}
+	if _, err := Upload(context.TODO(), exec, "/proc/1", []File{newFile("a", "1")}); !errors.Is(err, ErrProtectedPath) {
+		t.Errorf("expected protected path error, got %v", err)
+	}
+
+	// 命令提前退出时不能阻塞在写入上
+	failed := func(ctx context.Context, c []string, stdin io.Reader, stdout io.Writer) error {
+		return errors.New("tar: not found")
+	}
+	if _, err := Upload(context.TODO(), failed, "/data", []File{newFile("a", strings.Repeat("a", 1<<20))}); err == nil || err.Error() != "tar: not found" {
+		t.Errorf("expected command error, got %v", err)
+	}
+}
+
+// tarExec 模拟容器中的 tar -cf 命令，输出 files 打包后的内容
+func tarExec(files map[string]string, cmd *[]string) Exec {
+	return func(ctx context.Context, c []string, stdin io.Reader, stdout io.Writer) error {
+		*cmd = c
+		tw := tar.NewWriter(stdout)
+		for name, content := range files {
+			if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Size: int64(len(content)), Mode: 0644}); err != nil {
+				return err
+			}
+			if _, err := io.WriteString(tw, content); err != nil {
+				return err
+			}
+		}
+		return tw.Close()
+	}
+}
+
+func TestDownloadFile(t *testing.T) {
+	var cmd []string
+	archive, err := Download(context.TODO(), tarExec(map[string]string{"./app.log": "line1\nline2\n"}, &cmd), "/var/log/app.log")
+	if err != nil {
+		t.Fatalf("Download error: %v", err)
+	}
+	defer archive.Close()
+	if strings.Join(cmd, " ") != "tar -cf - -C /var/log/ ./app.log" {
+		t.Errorf("unexpected command %v", cmd)
+	}
+
+	header, r, err := archive.File()
+	if err != nil {
+		t.Fatalf("File error: %v", err)
+	}
+	content, _ := io.ReadAll(r)
+	if header.Size != 12 || string(content) != "line1\nline2\n" {
+		t.Errorf("unexpected file %d %q", header.Size, content)
+	}
+}
+
+func TestDownloadTarGz(t *testing.T) {
+	var cmd []string
+	files := map[string]string{"./logs/a.log": strings.Repeat("a", 1000)}
+
+	archive, err := Download(context.TODO(), tarExec(files, &cmd), "/var/logs")
+	if err != nil {
+		t.Fatalf("Download error: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := archive.WriteTarGz(&buf, 1<<20); err != nil {
+		t.Fatalf("WriteTarGz error: %v", err)
+	}
+	archive.Close()
+	gr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("gzip error: %v", err)
+	}
+	header, err := tar.NewReader(gr).Next()
+	if err != nil || header.Name != "./logs/a.log" {
+		t.Errorf("unexpected archive %v %v", header, err)
+	}
+
+	// 超过上限时返回 ErrTooLarge，并且不能阻塞
+	archive, err = Download(context.TODO(), tarExec(files, &cmd), "/var/logs")
+	if err != nil {
+		t.Fatalf("Download error: %v", err)
+	}
+	if _, err := archive.WriteTarGz(io.Discard, 512); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge, got %v", err)
+	}
+	archive.Close()
+}
+
+func TestDownloadError(t *testing.T) {
+	exec := func(ctx context.Context, c []string, stdin io.Reader, stdout io.Writer) error {
+		return errors.New("tar: /missing: No such file or directory")
+	}
+	if _, err := Download(context.TODO(), exec, "/missing"); err == nil || !strings.Contains(err.Error(), "No such file") {
+		t.Errorf("expected command error, got %v", err)
+	}
+}
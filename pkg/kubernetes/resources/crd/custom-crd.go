@@ -10,6 +10,7 @@ import (
 	"github.com/JLPAY/gwayne/pkg/pagequery"
 	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -59,20 +60,39 @@ func GetCustomCRDPage(clientset *apiextensionsclientset.Clientset, dynamicClient
 
 	var crdInstances *unstructured.UnstructuredList
 
+	listOptions := metav1.ListOptions{LabelSelector: q.LabelSelector}
+	// 游标分页，将 continue 令牌映射为 Kubernetes 的 continue/limit，避免一次加载全部实例
+	if q.UseContinue {
+		cursor, err := pagequery.DecodeCursor(q.Continue)
+		if err != nil {
+			return nil, err
+		}
+		listOptions.Limit = q.PageSize
+		listOptions.Continue = cursor.Continue
+	}
+
 	// 如果传入的 namespace 为空，查询所有命名空间的 CRD 实例
 	if namespace == "" {
 		// 查询所有命名空间中的 CRD 实例
-		crdInstances, err = resourceClient.List(context.TODO(), metav1.ListOptions{})
+		crdInstances, err = resourceClient.List(context.TODO(), listOptions)
 
 	} else {
 		// 查询特定命名空间中的 CRD 实例
-		crdInstances, err = resourceClient.Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+		crdInstances, err = resourceClient.Namespace(namespace).List(context.TODO(), listOptions)
 	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to list instances of CRD %s in namespace %s: %v", crdName, namespace, err)
+		return nil, fmt.Errorf("failed to list instances of CRD %s in namespace %s: %w", crdName, namespace, err)
+	}
+
+	if q.UseContinue {
+		next := ""
+		if crdInstances.GetContinue() != "" {
+			next = pagequery.Cursor{Continue: crdInstances.GetContinue()}.Encode()
+		}
+		return dataselector.DataSelectContinuePage(toCustomCRDCells(crdInstances.Items), q, next, crdInstances.GetRemainingItemCount())
 	}
 
-	return dataselector.DataSelectPage(toCustomCRDCells(crdInstances.Items), q), nil
+	return dataselector.DataSelectPage(toCustomCRDCells(crdInstances.Items), q)
 }
 
 func toCustomCRDCells(items []unstructured.Unstructured) []dataselector.DataCell {
@@ -250,3 +270,28 @@ func CreateCustomCRDInstanceClusterScoped(dynamicClient dynamic.Interface, group
 
 	return createdObj, nil
 }
+
+// GetCustomCRDResource 根据 group, version, kind（复数资源名）查找 CRD，返回实例的 GVR 以及是否为命名空间级别资源
+func GetCustomCRDResource(clientset *apiextensionsclientset.Clientset, group, version, kind string) (schema.GroupVersionResource, bool, error) {
+	crdName := fmt.Sprintf("%s.%s", kind, group)
+	crd, err := clientset.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), crdName, metav1.GetOptions{})
+	if err != nil {
+		return schema.GroupVersionResource{}, false, err
+	}
+
+	for _, v := range crd.Spec.Versions {
+		if v.Name != version {
+			continue
+		}
+		if !v.Served {
+			break
+		}
+		gvr := schema.GroupVersionResource{
+			Group:    group,
+			Version:  version,
+			Resource: crd.Spec.Names.Plural,
+		}
+		return gvr, crd.Spec.Scope == apiextensions.NamespaceScoped, nil
+	}
+	return schema.GroupVersionResource{}, false, apierrors.NewBadRequest(fmt.Sprintf("version %s of CRD %s is not served", version, crdName))
+}
@@ -31,7 +31,7 @@ func GetCRDPage(clientset *apiextensionsclientset.Clientset, q *pagequery.QueryP
 	}
 
 	// 返回分页数据
-	return dataselector.DataSelectPage(toCells(crdList.Items), q), nil
+	return dataselector.DataSelectPage(toCells(crdList.Items), q)
 }
 
 // 适配不同 CRD 版本
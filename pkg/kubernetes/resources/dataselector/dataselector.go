@@ -2,11 +2,9 @@ package dataselector
 
 import (
 	"sort"
-	"strings"
 	"time"
 
 	"github.com/JLPAY/gwayne/pkg/pagequery"
-	"k8s.io/klog/v2"
 )
 
 // DataCell 接口定义了数据单元的基本操作，允许获取属性并与其他数据单元进行比较。
@@ -30,6 +28,11 @@ type DataSelector struct {
 	GenericDataList []DataCell
 	// DataSelectQuery 存储数据选择的查询条件（例如过滤条件、排序规则等）。
 	DataSelectQuery *pagequery.QueryParam
+	// FilterGroups 由 DataSelectQuery 解析得到的过滤条件，组内为 AND，组间为 OR。
+	FilterGroups []FilterGroup
+
+	// 由 DataSelectQuery.Sortby 解析得到的排序字段
+	sortKeys []SortKey
 }
 
 // Implementation of sort.Interface so that we can use built-in sort function (sort.Sort) for sorting SelectableData
@@ -50,103 +53,39 @@ func (ds DataSelector) Swap(i, j int) {
 	ds.GenericDataList[i], ds.GenericDataList[j] = ds.GenericDataList[j], ds.GenericDataList[i]
 }
 
-// Less compares 2 indices inside SelectableData and returns true if first index is larger.
+// Less 按照 sortby 中的排序字段依次比较两个数据单元，返回第 i 个是否应排在第 j 个前面。
 func (ds DataSelector) Less(i, j int) bool {
-	sort := ds.DataSelectQuery.Sortby
-
-	if sort != "" {
-		// 判断是否为升序或降序排序
-		asc := true
-		// strings.Index(sort, "-") 返回 "-" 在字符串中的索引位置。如果 "-" 在字符串的开头（即索引位置为 0），则意味着是降序排序
-		if strings.Index(sort, "-") == 0 {
-			asc = false
-			sort = sort[1:] // 去掉负号，获取字段名称
-		}
-		a := ds.GenericDataList[i].GetProperty(PropertyName(sort))
-		b := ds.GenericDataList[j].GetProperty(PropertyName(sort))
-
-		// // 如果属性值为空，则跳过排序
-		if a == nil || b == nil {
-			return false
-		}
-		cmp := a.Compare(b)
-		if cmp == 0 {
-			// 如果值相同，则无需交换位置
-			return false
-		} else {
-			// 返回是否需要交换，依据升序或降序
-			return (cmp == -1 && asc) || (cmp == 1 && !asc)
-		}
+	keys := ds.sortKeys
+	if keys == nil {
+		keys = ParseSortKeys(ds.DataSelectQuery.Sortby)
 	}
-	return false
+	return compareByKeys(ds.GenericDataList[i], ds.GenericDataList[j], keys) < 0
 }
 
-// Filter 对 DataSelector 进行排序，支持链式调用。
+// Sort 对 DataSelector 进行稳定排序，排序字段相同的数据保持原有顺序，支持链式调用。
 func (ds *DataSelector) Sort() *DataSelector {
-	sort.Sort(*ds)
+	ds.sortKeys = ParseSortKeys(ds.DataSelectQuery.Sortby)
+	if len(ds.sortKeys) > 0 {
+		sort.Stable(*ds)
+	}
 	return ds
 }
 
-// Filter 根据查询条件对数据进行过滤，过滤后返回当前数据选择器，支持链式调用
+// Filter 根据 FilterGroups 对数据进行过滤，过滤后返回当前数据选择器，支持链式调用。
+// 数据单元只要满足任意一组条件即被保留。
 func (ds *DataSelector) Filter() *DataSelector {
-	filteredList := []DataCell{}
+	if len(ds.FilterGroups) == 0 {
+		return ds
+	}
 
+	filteredList := []DataCell{}
 	// 遍历所有数据单元，应用过滤条件
 	for _, c := range ds.GenericDataList {
-		matches := true
-		// 遍历所有查询条件，检查数据是否符合过滤要求
-		for key, value := range ds.DataSelectQuery.Query {
-			// 如果过滤条件中包含分隔符（例如针对嵌套字段的过滤），拆分并取第一个部分作为字段名
-			if strings.Contains(key, ListFilterExprSep) {
-				key = strings.Split(key, ListFilterExprSep)[0]
-			}
-
-			// 获取当前数据单元的属性值
-			v := c.GetProperty(PropertyName(key))
-			if v == nil {
-				// 如果属性值为空，记录日志并跳过当前数据单元
-				klog.Warningf("属性 %s 缺失，跳过当前过滤条件.", key)
-				matches = false
-				continue
+		for _, group := range ds.FilterGroups {
+			if group.Match(c) {
+				filteredList = append(filteredList, c)
+				break
 			}
-
-			// 对于 statusPhase 字段，使用大小写不敏感的匹配
-			if key == string(StatusPhaseProperty) {
-				// 将状态值转换为小写进行比较，支持大小写不敏感匹配
-				if strValue, ok := value.(string); ok {
-					lowerValue := strings.ToLower(strValue)
-					// 获取属性值并转换为小写进行比较
-					if strProp, ok := v.(StdComparableString); ok {
-						lowerProp := strings.ToLower(string(strProp))
-						if !strings.Contains(lowerProp, lowerValue) {
-							matches = false
-							continue
-						}
-					} else {
-						// 如果不是字符串类型，使用原有的 Contains 方法
-						if !v.Contains(ParseToComparableValue(value)) {
-							matches = false
-							continue
-						}
-					}
-				} else {
-					// 如果值不是字符串类型，使用原有的 Contains 方法
-					if !v.Contains(ParseToComparableValue(value)) {
-						matches = false
-						continue
-					}
-				}
-			} else {
-				// 对于其他字段，使用原有的 Contains 方法（大小写敏感）
-				if !v.Contains(ParseToComparableValue(value)) {
-					matches = false
-					continue
-				}
-			}
-		}
-		if matches {
-			// 如果所有条件都匹配，则保留该数据单元
-			filteredList = append(filteredList, c)
 		}
 	}
 
@@ -0,0 +1,268 @@
+package dataselector
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JLPAY/gwayne/pkg/pagequery"
+)
+
+// FilterOperator 过滤操作符，对应 filter 参数中 ListFilterExprSep 之后的部分，例如 name__eq 中的 eq
+type FilterOperator string
+
+const (
+	// 默认操作符，子串匹配
+	FilterOperatorContains FilterOperator = "contains"
+	FilterOperatorEq       FilterOperator = "eq"
+	FilterOperatorNe       FilterOperator = "ne"
+	FilterOperatorIn       FilterOperator = "in"
+	FilterOperatorRegex    FilterOperator = "regex"
+	FilterOperatorGt       FilterOperator = "gt"
+	FilterOperatorGte      FilterOperator = "gte"
+	FilterOperatorLt       FilterOperator = "lt"
+	FilterOperatorLte      FilterOperator = "lte"
+	FilterOperatorExists   FilterOperator = "exists"
+)
+
+// __in 操作符多个取值之间的分隔符，例如 statusPhase__in=Running|Pending
+const ListFilterValueSep = "|"
+
+var (
+	// ErrInvalidQuery 查询参数（过滤、排序、列投影）解析失败，调用方可以据此返回 400，与数据库列表使用相同的错误
+	ErrInvalidQuery = pagequery.ErrInvalidQuery
+	// ErrInvalidFilter 过滤条件解析失败
+	ErrInvalidFilter = fmt.Errorf("%w: invalid filter", ErrInvalidQuery)
+)
+
+// 比较时忽略大小写的属性
+var caseInsensitiveProperties = map[PropertyName]bool{
+	StatusPhaseProperty: true,
+}
+
+// 支持的时间格式，按顺序尝试解析
+var filterTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// FilterCondition 单个过滤条件，例如 creationTimestamp__gt=2026-10-01
+type FilterCondition struct {
+	Property PropertyName
+	Operator FilterOperator
+	Value    string
+	// __in 操作符的取值列表
+	Values []string
+
+	regex  *regexp.Regexp
+	exists bool
+}
+
+// FilterGroup 一组需要同时满足（AND）的过滤条件
+type FilterGroup []FilterCondition
+
+// ParseFilterCondition 将 query 中的一个键值对解析为过滤条件
+func ParseFilterCondition(key string, value interface{}) (FilterCondition, error) {
+	property, operator := key, FilterOperatorContains
+	if idx := strings.Index(key, ListFilterExprSep); idx >= 0 {
+		property, operator = key[:idx], FilterOperator(key[idx+len(ListFilterExprSep):])
+	}
+	if property == "" {
+		return FilterCondition{}, fmt.Errorf("%w: empty property in %q", ErrInvalidFilter, key)
+	}
+
+	cond := FilterCondition{
+		Property: PropertyName(property),
+		Operator: operator,
+		Value:    fmt.Sprint(value),
+	}
+
+	if IsJSONPathProperty(cond.Property) {
+		if _, err := parseJSONPath(strings.TrimPrefix(property, JSONPathPropertyPrefix)); err != nil {
+			return FilterCondition{}, err
+		}
+	}
+
+	switch operator {
+	case FilterOperatorContains, FilterOperatorEq, FilterOperatorNe,
+		FilterOperatorGt, FilterOperatorGte, FilterOperatorLt, FilterOperatorLte:
+	case FilterOperatorIn:
+		for _, v := range strings.Split(cond.Value, ListFilterValueSep) {
+			if v != "" {
+				cond.Values = append(cond.Values, v)
+			}
+		}
+		if len(cond.Values) == 0 {
+			return FilterCondition{}, fmt.Errorf("%w: %s requires at least one value", ErrInvalidFilter, key)
+		}
+	case FilterOperatorRegex:
+		expr := cond.Value
+		if caseInsensitiveProperties[cond.Property] {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return FilterCondition{}, fmt.Errorf("%w: %s has invalid regex: %v", ErrInvalidFilter, key, err)
+		}
+		cond.regex = re
+	case FilterOperatorExists:
+		exists, err := strconv.ParseBool(cond.Value)
+		if err != nil {
+			return FilterCondition{}, fmt.Errorf("%w: %s expects true or false, got %q", ErrInvalidFilter, key, cond.Value)
+		}
+		cond.exists = exists
+	default:
+		return FilterCondition{}, fmt.Errorf("%w: unsupported operator %q in %q", ErrInvalidFilter, operator, key)
+	}
+
+	return cond, nil
+}
+
+// ParseFilterGroups 解析 QueryParam 中的过滤条件。
+// Query 作为第一组条件，OrQuery 中的每一项作为额外的一组，组内为 AND，组间为 OR。
+func ParseFilterGroups(q *pagequery.QueryParam) ([]FilterGroup, error) {
+	groups := make([]FilterGroup, 0, len(q.OrQuery)+1)
+	for _, query := range append([]map[string]interface{}{q.Query}, q.OrQuery...) {
+		if len(query) == 0 {
+			continue
+		}
+		group := make(FilterGroup, 0, len(query))
+		for key, value := range query {
+			cond, err := ParseFilterCondition(key, value)
+			if err != nil {
+				return nil, err
+			}
+			group = append(group, cond)
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// Match 判断数据单元是否满足组内的所有条件
+func (g FilterGroup) Match(cell DataCell) bool {
+	for _, cond := range g {
+		if !cond.Match(cell) {
+			return false
+		}
+	}
+	return true
+}
+
+// Match 判断数据单元是否满足当前过滤条件
+func (c FilterCondition) Match(cell DataCell) bool {
+	v := cell.GetProperty(c.Property)
+	if c.Operator == FilterOperatorExists {
+		return (v != nil) == c.exists
+	}
+	if v == nil {
+		return false
+	}
+
+	switch c.Operator {
+	case FilterOperatorContains:
+		if s, ok := v.(StdComparableString); ok && caseInsensitiveProperties[c.Property] {
+			return strings.Contains(strings.ToLower(string(s)), strings.ToLower(c.Value))
+		}
+		other := parseLike(v, c.Value)
+		return other != nil && v.Contains(other)
+	case FilterOperatorEq:
+		return c.equals(v, c.Value)
+	case FilterOperatorNe:
+		return !c.equals(v, c.Value)
+	case FilterOperatorIn:
+		for _, value := range c.Values {
+			if c.equals(v, value) {
+				return true
+			}
+		}
+		return false
+	case FilterOperatorRegex:
+		return c.regex.MatchString(comparableToString(v))
+	case FilterOperatorGt, FilterOperatorGte, FilterOperatorLt, FilterOperatorLte:
+		other := parseLike(v, c.Value)
+		if other == nil {
+			return false
+		}
+		cmp := v.Compare(other)
+		switch c.Operator {
+		case FilterOperatorGt:
+			return cmp > 0
+		case FilterOperatorGte:
+			return cmp >= 0
+		case FilterOperatorLt:
+			return cmp < 0
+		default:
+			return cmp <= 0
+		}
+	}
+	return false
+}
+
+func (c FilterCondition) equals(v ComparableValue, value string) bool {
+	if s, ok := v.(StdComparableString); ok && caseInsensitiveProperties[c.Property] {
+		return strings.EqualFold(string(s), value)
+	}
+	other := parseLike(v, value)
+	return other != nil && v.Compare(other) == 0
+}
+
+// parseLike 按照属性值的类型解析过滤值，无法解析时返回 nil
+func parseLike(v ComparableValue, raw string) ComparableValue {
+	switch v.(type) {
+	case StdComparableInt:
+		i, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil
+		}
+		return StdComparableInt(i)
+	case StdComparableTime:
+		t, ok := parseFilterTime(raw)
+		if !ok {
+			return nil
+		}
+		return StdComparableTime(t)
+	case StdComparableString:
+		return StdComparableString(raw)
+	case StdComparableFloat:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil
+		}
+		return StdComparableFloat(f)
+	default:
+		return nil
+	}
+}
+
+func parseFilterTime(raw string) (time.Time, bool) {
+	for _, layout := range filterTimeLayouts {
+		if t, err := time.ParseInLocation(layout, raw, time.Local); err == nil {
+			return t, true
+		}
+	}
+	// 兼容 unix 时间戳
+	if sec, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(sec, 0), true
+	}
+	return time.Time{}, false
+}
+
+func comparableToString(v ComparableValue) string {
+	switch value := v.(type) {
+	case StdComparableString:
+		return string(value)
+	case StdComparableInt:
+		return strconv.Itoa(int(value))
+	case StdComparableTime:
+		return time.Time(value).Format(time.RFC3339)
+	case StdComparableFloat:
+		return strconv.FormatFloat(float64(value), 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
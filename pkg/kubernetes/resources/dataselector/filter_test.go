@@ -0,0 +1,116 @@
+package dataselector
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JLPAY/gwayne/pkg/pagequery"
+)
+
+type testCell map[PropertyName]ComparableValue
+
+func (c testCell) GetProperty(name PropertyName) ComparableValue {
+	return c[name]
+}
+
+func newTestCells() []DataCell {
+	return []DataCell{
+		testCell{
+			NameProperty:              StdComparableString("web-1"),
+			StatusPhaseProperty:       StdComparableString("Running"),
+			CountProperty:             StdComparableInt(3),
+			CreationTimestampProperty: StdComparableTime(time.Date(2026, 9, 20, 0, 0, 0, 0, time.Local)),
+		},
+		testCell{
+			NameProperty:              StdComparableString("web-2"),
+			StatusPhaseProperty:       StdComparableString("Pending"),
+			CountProperty:             StdComparableInt(10),
+			CreationTimestampProperty: StdComparableTime(time.Date(2026, 10, 5, 0, 0, 0, 0, time.Local)),
+			PodIPProperty:             StdComparableString("10.0.0.2"),
+		},
+		testCell{
+			NameProperty:              StdComparableString("db-1"),
+			StatusPhaseProperty:       StdComparableString("Failed"),
+			CountProperty:             StdComparableInt(1),
+			CreationTimestampProperty: StdComparableTime(time.Date(2026, 10, 10, 0, 0, 0, 0, time.Local)),
+		},
+	}
+}
+
+func names(cells []DataCell) []string {
+	result := make([]string, 0, len(cells))
+	for _, cell := range cells {
+		result = append(result, string(cell.GetProperty(NameProperty).(StdComparableString)))
+	}
+	return result
+}
+
+func TestDataSelectorFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   map[string]interface{}
+		orQuery []map[string]interface{}
+		want    []string
+	}{
+		{"contains", map[string]interface{}{"name": "web"}, nil, []string{"web-1", "web-2"}},
+		{"contains ignores operator-less case for statusPhase", map[string]interface{}{"statusPhase": "run"}, nil, []string{"web-1"}},
+		{"eq", map[string]interface{}{"name__eq": "web-1"}, nil, []string{"web-1"}},
+		{"ne", map[string]interface{}{"name__ne": "web-1"}, nil, []string{"web-2", "db-1"}},
+		{"in", map[string]interface{}{"statusPhase__in": "running|failed"}, nil, []string{"web-1", "db-1"}},
+		{"regex", map[string]interface{}{"name__regex": "^web-[0-9]$"}, nil, []string{"web-1", "web-2"}},
+		{"int gt", map[string]interface{}{"count__gt": "2"}, nil, []string{"web-1", "web-2"}},
+		{"int lte", map[string]interface{}{"count__lte": "3"}, nil, []string{"web-1", "db-1"}},
+		{"time gt", map[string]interface{}{"creationTimestamp__gt": "2026-10-01"}, nil, []string{"web-2", "db-1"}},
+		{"time lt", map[string]interface{}{"creationTimestamp__lt": "2026-10-06T00:00:00"}, nil, []string{"web-1", "web-2"}},
+		{"exists", map[string]interface{}{"podIP__exists": "true"}, nil, []string{"web-2"}},
+		{"not exists", map[string]interface{}{"podIP__exists": "false"}, nil, []string{"web-1", "db-1"}},
+		{"and", map[string]interface{}{"name": "web", "count__gt": "5"}, nil, []string{"web-2"}},
+		{
+			"or groups",
+			map[string]interface{}{"name__eq": "web-1"},
+			[]map[string]interface{}{{"statusPhase__eq": "Failed"}},
+			[]string{"web-1", "db-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &pagequery.QueryParam{PageNo: 1, PageSize: 10, Query: tt.query, OrQuery: tt.orQuery}
+			page, err := DataSelectPage(newTestCells(), q)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := names(page.List.([]DataCell))
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFilterConditionErrors(t *testing.T) {
+	tests := map[string]interface{}{
+		"name__like":    "web",
+		"name__regex":   "([",
+		"podIP__exists": "maybe",
+		"name__in":      "|",
+		"__eq":          "web",
+	}
+
+	for key, value := range tests {
+		if _, err := ParseFilterCondition(key, value); !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("%s=%v: expected ErrInvalidFilter, got %v", key, value, err)
+		}
+	}
+
+	q := &pagequery.QueryParam{PageNo: 1, PageSize: 10, Query: map[string]interface{}{"name__like": "web"}}
+	if _, err := DataSelectPage(newTestCells(), q); !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("expected DataSelectPage to report ErrInvalidFilter, got %v", err)
+	}
+}
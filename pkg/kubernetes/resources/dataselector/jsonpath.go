@@ -0,0 +1,235 @@
+package dataselector
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// JSONPathPropertyPrefix jsonpath 属性前缀，例如 jsonpath:.spec.replicas
+const JSONPathPropertyPrefix = "jsonpath:"
+
+// StdComparableFloat 浮点数比较类型，用于 jsonpath 取到的非整数数值
+type StdComparableFloat float64
+
+// Compare 实现 ComparableValue 接口，比较两个 StdComparableFloat 值
+func (self StdComparableFloat) Compare(otherV ComparableValue) int {
+	other, ok := otherV.(StdComparableFloat)
+	if !ok {
+		return -1
+	}
+	switch {
+	case self > other:
+		return 1
+	case self == other:
+		return 0
+	default:
+		return -1
+	}
+}
+
+// Contains 判断两个 StdComparableFloat 是否相等
+func (self StdComparableFloat) Contains(otherV ComparableValue) bool {
+	return self.Compare(otherV) == 0
+}
+
+// IsJSONPathProperty 判断属性是否为 jsonpath 属性
+func IsJSONPathProperty(name PropertyName) bool {
+	return strings.HasPrefix(string(name), JSONPathPropertyPrefix)
+}
+
+// jsonPathEvaluator 缓存一次请求内解析过的 jsonpath 表达式。
+// jsonpath.JSONPath 执行时会修改自身状态，不能在多个请求之间共享。
+type jsonPathEvaluator struct {
+	paths map[string]*jsonpath.JSONPath
+}
+
+func newJSONPathEvaluator() *jsonPathEvaluator {
+	return &jsonPathEvaluator{paths: map[string]*jsonpath.JSONPath{}}
+}
+
+// compile 解析 jsonpath 表达式，支持 .spec.replicas 和 {.spec.replicas} 两种写法
+func (e *jsonPathEvaluator) compile(path string) (*jsonpath.JSONPath, error) {
+	if jp, ok := e.paths[path]; ok {
+		return jp, nil
+	}
+	jp, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	e.paths[path] = jp
+	return jp, nil
+}
+
+// find 在对象上执行 jsonpath，返回所有匹配的值，表达式不存在的字段时返回空
+func (e *jsonPathEvaluator) find(path string, data interface{}) ([]interface{}, error) {
+	jp, err := e.compile(path)
+	if err != nil {
+		return nil, err
+	}
+	results, err := jp.FindResults(data)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, 0)
+	for _, result := range results {
+		for _, v := range result {
+			if !v.IsValid() || (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) && v.IsNil() {
+				continue
+			}
+			values = append(values, v.Interface())
+		}
+	}
+	return values, nil
+}
+
+func parseJSONPath(path string) (*jsonpath.JSONPath, error) {
+	if !strings.HasPrefix(path, "{") {
+		path = "{" + path + "}"
+	}
+	jp := jsonpath.New("dataselector").AllowMissingKeys(true)
+	if err := jp.Parse(path); err != nil {
+		return nil, fmt.Errorf("%w: invalid jsonpath %q: %v", ErrInvalidQuery, path, err)
+	}
+	return jp, nil
+}
+
+// jsonPathCell 缓存数据单元的 JSON 表示，使 jsonpath 属性可以在任意资源上求值
+type jsonPathCell struct {
+	DataCell
+	data      interface{}
+	evaluator *jsonPathEvaluator
+}
+
+func newJSONPathCell(cell DataCell, evaluator *jsonPathEvaluator) (jsonPathCell, error) {
+	objByte, err := json.Marshal(cell)
+	if err != nil {
+		return jsonPathCell{}, err
+	}
+	var data interface{}
+	if err := json.Unmarshal(objByte, &data); err != nil {
+		return jsonPathCell{}, err
+	}
+	return jsonPathCell{DataCell: cell, data: data, evaluator: evaluator}, nil
+}
+
+// GetProperty jsonpath 属性在 JSON 表示上求值，其它属性交给原数据单元处理
+func (c jsonPathCell) GetProperty(name PropertyName) ComparableValue {
+	if !IsJSONPathProperty(name) {
+		return c.DataCell.GetProperty(name)
+	}
+	values, err := c.evaluator.find(strings.TrimPrefix(string(name), JSONPathPropertyPrefix), c.data)
+	if err != nil || len(values) == 0 {
+		return nil
+	}
+	return jsonValuesToComparable(values)
+}
+
+// MarshalJSON 保持原数据单元的序列化结果
+func (c jsonPathCell) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.data)
+}
+
+// jsonValuesToComparable 将 jsonpath 的结果转换为可比较的值，多个结果以逗号拼接为字符串
+func jsonValuesToComparable(values []interface{}) ComparableValue {
+	if len(values) == 1 {
+		switch v := values[0].(type) {
+		case float64:
+			if v == math.Trunc(v) && math.Abs(v) < math.MaxInt32 {
+				return StdComparableInt(int(v))
+			}
+			return StdComparableFloat(v)
+		case string:
+			// 时间字段在 JSON 中为 RFC3339 字符串
+			if t, err := time.Parse(time.RFC3339, v); err == nil {
+				return StdComparableTime(t)
+			}
+			return StdComparableString(v)
+		}
+	}
+
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		switch value := v.(type) {
+		case string:
+			parts = append(parts, value)
+		default:
+			b, err := json.Marshal(value)
+			if err != nil {
+				parts = append(parts, fmt.Sprint(value))
+				continue
+			}
+			parts = append(parts, string(b))
+		}
+	}
+	return StdComparableString(strings.Join(parts, ","))
+}
+
+// Column 列投影，Name 为返回结果中的键，Path 为 jsonpath 表达式
+type Column struct {
+	Name string
+	Path string
+}
+
+// ParseColumns 解析列投影参数，格式为 .metadata.name 或 name:.metadata.name
+func ParseColumns(fields []string) ([]Column, error) {
+	columns := make([]Column, 0, len(fields))
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		name, path := "", field
+		if !strings.HasPrefix(field, ".") && !strings.HasPrefix(field, "{") {
+			idx := strings.Index(field, ":")
+			if idx <= 0 {
+				return nil, fmt.Errorf("%w: invalid field %q, expected .path or name:.path", ErrInvalidQuery, field)
+			}
+			name, path = field[:idx], field[idx+1:]
+		}
+		if name == "" {
+			name = strings.Trim(path, "{}.")
+		}
+		if _, err := parseJSONPath(path); err != nil {
+			return nil, err
+		}
+		columns = append(columns, Column{Name: name, Path: path})
+	}
+	return columns, nil
+}
+
+// project 按列投影数据单元，单个结果直接返回值，多个结果返回列表
+func project(cells []DataCell, columns []Column, evaluator *jsonPathEvaluator) ([]map[string]interface{}, error) {
+	rows := make([]map[string]interface{}, 0, len(cells))
+	for _, cell := range cells {
+		jc, ok := cell.(jsonPathCell)
+		if !ok {
+			var err error
+			if jc, err = newJSONPathCell(cell, evaluator); err != nil {
+				return nil, err
+			}
+		}
+		row := make(map[string]interface{}, len(columns))
+		for _, column := range columns {
+			values, err := evaluator.find(column.Path, jc.data)
+			if err != nil {
+				return nil, err
+			}
+			switch len(values) {
+			case 0:
+				row[column.Name] = nil
+			case 1:
+				row[column.Name] = values[0]
+			default:
+				row[column.Name] = values
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,85 @@
+package dataselector
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/JLPAY/gwayne/pkg/pagequery"
+)
+
+type testObjectCell struct {
+	Metadata map[string]interface{} `json:"metadata"`
+	Spec     map[string]interface{} `json:"spec"`
+}
+
+func (c testObjectCell) GetProperty(name PropertyName) ComparableValue {
+	if name == NameProperty {
+		return StdComparableString(c.Metadata["name"].(string))
+	}
+	return nil
+}
+
+func newTestObjectCells() []DataCell {
+	return []DataCell{
+		testObjectCell{
+			Metadata: map[string]interface{}{"name": "a", "labels": map[string]interface{}{"app": "web"}},
+			Spec:     map[string]interface{}{"replicas": 3},
+		},
+		testObjectCell{
+			Metadata: map[string]interface{}{"name": "b", "labels": map[string]interface{}{"app": "db"}},
+			Spec:     map[string]interface{}{"replicas": 1},
+		},
+		testObjectCell{
+			Metadata: map[string]interface{}{"name": "c"},
+			Spec:     map[string]interface{}{"replicas": 5},
+		},
+	}
+}
+
+func TestDataSelectPageJSONPath(t *testing.T) {
+	q := &pagequery.QueryParam{
+		PageNo:   1,
+		PageSize: 10,
+		Query:    map[string]interface{}{"jsonpath:.spec.replicas__gte": "2"},
+		Sortby:   "-jsonpath:.spec.replicas",
+	}
+	page, err := DataSelectPage(newTestObjectCells(), q)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := names(page.List.([]DataCell))
+	if len(got) != 2 || got[0] != "c" || got[1] != "a" {
+		t.Fatalf("expected [c a], got %v", got)
+	}
+
+	q = &pagequery.QueryParam{
+		PageNo:   1,
+		PageSize: 10,
+		Query:    map[string]interface{}{"jsonpath:.metadata.labels.app__exists": "true"},
+		Fields:   []string{".metadata.name", "app:.metadata.labels.app"},
+	}
+	page, err = DataSelectPage(newTestObjectCells(), q)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rows := page.List.([]map[string]interface{})
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %v", rows)
+	}
+	if rows[0]["metadata.name"] != "a" || rows[0]["app"] != "web" {
+		t.Fatalf("unexpected projection %v", rows[0])
+	}
+}
+
+func TestDataSelectPageJSONPathErrors(t *testing.T) {
+	queries := []*pagequery.QueryParam{
+		{PageNo: 1, PageSize: 10, Query: map[string]interface{}{"jsonpath:.spec[__eq": "1"}},
+		{PageNo: 1, PageSize: 10, Fields: []string{"replicas"}},
+		{PageNo: 1, PageSize: 10, Fields: []string{"replicas:.spec["}},
+	}
+	for _, q := range queries {
+		if _, err := DataSelectPage(newTestObjectCells(), q); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("%+v: expected ErrInvalidQuery, got %v", q, err)
+		}
+	}
+}
@@ -1,19 +1,19 @@
 package dataselector
 
-import "github.com/JLPAY/gwayne/pkg/pagequery"
+import (
+	"strings"
 
-func DataSelectPage(dataList []DataCell, q *pagequery.QueryParam) *pagequery.Page {
-	// 创建一个 DataSelector，包含了原始数据和查询参数
-	SelectableData := DataSelector{
-		GenericDataList: dataList,
-		DataSelectQuery: q,
-	}
+	"github.com/JLPAY/gwayne/pkg/pagequery"
+)
 
-	// Pipeline: Filter -> Sort -> Paginate
+func DataSelectPage(dataList []DataCell, q *pagequery.QueryParam) (*pagequery.Page, error) {
 	// 先过滤，再排序
-	filtered := SelectableData.Filter().Sort()
+	filtered, columns, evaluator, err := selectData(dataList, q)
+	if err != nil {
+		return nil, err
+	}
 	// 获取过滤后的数据总数
-	filteredTotal := len(filtered.GenericDataList)
+	filteredTotal := len(filtered)
 
 	// 计算分页的起始和结束位置
 	start, end := q.Offset(), q.Offset()+q.Limit()
@@ -27,8 +27,92 @@ func DataSelectPage(dataList []DataCell, q *pagequery.QueryParam) *pagequery.Pag
 	}
 
 	// 根据分页区间切片数据
-	pagedList := filtered.GenericDataList[start:end]
+	pagedList := filtered[start:end]
+
+	// 列投影，只返回指定的字段
+	if len(columns) > 0 {
+		rows, err := project(pagedList, columns, evaluator)
+		if err != nil {
+			return nil, err
+		}
+		return q.NewPage(int64(filteredTotal), rows), nil
+	}
 
 	// 返回分页结果
-	return q.NewPage(int64(filteredTotal), pagedList)
+	return q.NewPage(int64(filteredTotal), pagedList), nil
+}
+
+// DataSelectContinuePage 对游标分页取回的一页数据进行过滤、排序和列投影，不再按页码切片。
+// 过滤发生在服务端分页之后，因此返回的条数可能少于 PageSize，排序也只在当前页内生效。
+func DataSelectContinuePage(dataList []DataCell, q *pagequery.QueryParam, next string, remaining *int64) (*pagequery.Page, error) {
+	filtered, columns, evaluator, err := selectData(dataList, q)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(columns) > 0 {
+		rows, err := project(filtered, columns, evaluator)
+		if err != nil {
+			return nil, err
+		}
+		return q.NewContinuePage(rows, next, remaining), nil
+	}
+	return q.NewContinuePage(filtered, next, remaining), nil
+}
+
+// selectData 解析查询参数并对数据进行过滤和排序，返回结果以及列投影所需的参数
+func selectData(dataList []DataCell, q *pagequery.QueryParam) ([]DataCell, []Column, *jsonPathEvaluator, error) {
+	// 解析过滤条件，解析失败时返回 ErrInvalidFilter
+	filterGroups, err := ParseFilterGroups(q)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	columns, err := ParseColumns(q.Fields)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// 使用了 jsonpath 属性时，预先将数据单元转换为 JSON 表示，避免排序时重复转换
+	evaluator := newJSONPathEvaluator()
+	if usesJSONPath(filterGroups, q.Sortby) {
+		if dataList, err = toJSONPathCells(dataList, evaluator); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	// 创建一个 DataSelector，包含了原始数据和查询参数
+	SelectableData := DataSelector{
+		GenericDataList: dataList,
+		DataSelectQuery: q,
+		FilterGroups:    filterGroups,
+	}
+
+	// Pipeline: Filter -> Sort -> Paginate
+	return SelectableData.Filter().Sort().GenericDataList, columns, evaluator, nil
+}
+
+func usesJSONPath(filterGroups []FilterGroup, sortby string) bool {
+	if strings.Contains(sortby, JSONPathPropertyPrefix) {
+		return true
+	}
+	for _, group := range filterGroups {
+		for _, cond := range group {
+			if IsJSONPathProperty(cond.Property) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func toJSONPathCells(dataList []DataCell, evaluator *jsonPathEvaluator) ([]DataCell, error) {
+	cells := make([]DataCell, 0, len(dataList))
+	for _, cell := range dataList {
+		jc, err := newJSONPathCell(cell, evaluator)
+		if err != nil {
+			return nil, err
+		}
+		cells = append(cells, jc)
+	}
+	return cells, nil
 }
@@ -0,0 +1,59 @@
+package dataselector
+
+import "strings"
+
+// 多个排序字段之间的分隔符，例如 sortby=-creationTimestamp,name
+const ListSortKeySep = ","
+
+// SortKey 单个排序字段
+type SortKey struct {
+	Property  PropertyName
+	Ascending bool
+}
+
+// ParseSortKeys 解析 sortby 参数，字段前的 "-" 表示降序，多个字段依次作为排序依据
+func ParseSortKeys(sortby string) []SortKey {
+	keys := make([]SortKey, 0)
+	for _, field := range strings.Split(sortby, ListSortKeySep) {
+		field = strings.TrimSpace(field)
+		ascending := true
+		if strings.HasPrefix(field, "-") {
+			ascending = false
+			field = field[1:]
+		} else if strings.HasPrefix(field, "+") {
+			field = field[1:]
+		}
+		if field == "" {
+			continue
+		}
+		keys = append(keys, SortKey{Property: PropertyName(field), Ascending: ascending})
+	}
+	return keys
+}
+
+// compareByKeys 依次按排序字段比较两个数据单元，返回 -1 表示 a 排在 b 前面。
+// 属性缺失的数据单元总是排在最后。
+func compareByKeys(a, b DataCell, keys []SortKey) int {
+	for _, key := range keys {
+		av := a.GetProperty(key.Property)
+		bv := b.GetProperty(key.Property)
+		switch {
+		case av == nil && bv == nil:
+			continue
+		case av == nil:
+			return 1
+		case bv == nil:
+			return -1
+		}
+
+		cmp := av.Compare(bv)
+		if cmp == 0 {
+			continue
+		}
+		if !key.Ascending {
+			cmp = -cmp
+		}
+		return cmp
+	}
+	return 0
+}
@@ -0,0 +1,44 @@
+package dataselector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JLPAY/gwayne/pkg/pagequery"
+)
+
+func TestDataSelectorSort(t *testing.T) {
+	cells := append(newTestCells(), testCell{
+		NameProperty:              StdComparableString("api-1"),
+		CreationTimestampProperty: StdComparableTime(time.Date(2026, 10, 10, 0, 0, 0, 0, time.Local)),
+	}, testCell{
+		NameProperty: StdComparableString("no-time"),
+	})
+
+	tests := []struct {
+		sortby string
+		want   []string
+	}{
+		{"name", []string{"api-1", "db-1", "no-time", "web-1", "web-2"}},
+		{"-name", []string{"web-2", "web-1", "no-time", "db-1", "api-1"}},
+		{"-creationTimestamp,name", []string{"api-1", "db-1", "web-2", "web-1", "no-time"}},
+		{"creationTimestamp,-name", []string{"web-1", "web-2", "db-1", "api-1", "no-time"}},
+		{"", []string{"web-1", "web-2", "db-1", "api-1", "no-time"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sortby, func(t *testing.T) {
+			q := &pagequery.QueryParam{PageNo: 1, PageSize: 10, Sortby: tt.sortby}
+			page, err := DataSelectPage(append([]DataCell{}, cells...), q)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := names(page.List.([]DataCell))
+			for i := range tt.want {
+				if i >= len(got) || got[i] != tt.want[i] {
+					t.Fatalf("expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
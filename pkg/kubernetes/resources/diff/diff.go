@@ -0,0 +1,182 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/common"
+	"sigs.k8s.io/yaml"
+)
+
+// ChangeType 字段变更类型
+type ChangeType string
+
+const (
+	Added    ChangeType = "added"
+	Removed  ChangeType = "removed"
+	Modified ChangeType = "modified"
+)
+
+// 比较时忽略的注解，由客户端工具或控制器维护
+var ignoredAnnotations = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+	"deployment.kubernetes.io/revision",
+}
+
+// Change 字段级别的变更，Path 形如 .spec.template.spec.containers[0].image
+type Change struct {
+	Path string      `json:"path"`
+	Type ChangeType  `json:"type"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// Result 线上对象与变更后对象的差异
+type Result struct {
+	// unified diff 格式的 YAML 差异，没有差异时为空
+	Diff    string   `json:"diff"`
+	Changes []Change `json:"changes"`
+}
+
+// Compare 比较线上对象和 dry-run 得到的变更后对象，live 为 nil 表示新建资源。
+// 两者都经过服务端默认值填充，status 及服务端维护的 metadata 字段不参与比较。
+func Compare(live, proposed interface{}) (*Result, error) {
+	liveObj, err := normalize(live)
+	if err != nil {
+		return nil, err
+	}
+	proposedObj, err := normalize(proposed)
+	if err != nil {
+		return nil, err
+	}
+
+	liveYAML, err := toYAML(liveObj)
+	if err != nil {
+		return nil, err
+	}
+	proposedYAML, err := toYAML(proposedObj)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Changes: make([]Change, 0)}
+	compareValue("", asValue(liveObj), asValue(proposedObj), &result.Changes)
+	result.Diff, err = Unified("live", "proposed", liveYAML, proposedYAML, 3)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func normalize(obj interface{}) (map[string]interface{}, error) {
+	if obj == nil || reflect.ValueOf(obj).Kind() == reflect.Ptr && reflect.ValueOf(obj).IsNil() {
+		return nil, nil
+	}
+	exported, err := common.ExportObject(obj)
+	if err != nil {
+		return nil, err
+	}
+	if metadata, ok := exported["metadata"].(map[string]interface{}); ok {
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			for _, annotation := range ignoredAnnotations {
+				delete(annotations, annotation)
+			}
+			if len(annotations) == 0 {
+				delete(metadata, "annotations")
+			}
+		}
+	}
+	return exported, nil
+}
+
+// asValue 避免 nil map 被当作非 nil 的 interface 值
+func asValue(obj map[string]interface{}) interface{} {
+	if obj == nil {
+		return nil
+	}
+	return obj
+}
+
+func toYAML(obj map[string]interface{}) (string, error) {
+	if obj == nil {
+		return "", nil
+	}
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func compareValue(path string, old, new interface{}, changes *[]Change) {
+	switch {
+	case old == nil && new == nil:
+		return
+	case old == nil:
+		*changes = append(*changes, Change{Path: rootPath(path), Type: Added, New: new})
+		return
+	case new == nil:
+		*changes = append(*changes, Change{Path: rootPath(path), Type: Removed, Old: old})
+		return
+	}
+
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		keys := make([]string, 0, len(oldMap)+len(newMap))
+		for key := range oldMap {
+			keys = append(keys, key)
+		}
+		for key := range newMap {
+			if _, ok := oldMap[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			compareValue(path+fieldPath(key), oldMap[key], newMap[key], changes)
+		}
+		return
+	}
+
+	oldList, oldIsList := old.([]interface{})
+	newList, newIsList := new.([]interface{})
+	if oldIsList && newIsList {
+		length := len(oldList)
+		if len(newList) > length {
+			length = len(newList)
+		}
+		for i := 0; i < length; i++ {
+			var oldItem, newItem interface{}
+			if i < len(oldList) {
+				oldItem = oldList[i]
+			}
+			if i < len(newList) {
+				newItem = newList[i]
+			}
+			compareValue(fmt.Sprintf("%s[%d]", path, i), oldItem, newItem, changes)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(old, new) {
+		*changes = append(*changes, Change{Path: rootPath(path), Type: Modified, Old: old, New: new})
+	}
+}
+
+// fieldPath 字段名中包含 . 或 / 等字符时（例如注解）使用 ["key"] 形式
+func fieldPath(key string) string {
+	if strings.ContainsAny(key, "./[]\" ") {
+		return fmt.Sprintf("[%q]", key)
+	}
+	return "." + key
+}
+
+func rootPath(path string) string {
+	if path == "" {
+		return "."
+	}
+	return path
+}
@@ -0,0 +1,131 @@
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func deployment(image string, replicas int64, rv string) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":            "web",
+			"resourceVersion": rv,
+			"annotations": map[string]interface{}{
+				"deployment.kubernetes.io/revision": rv,
+			},
+		},
+		"spec": map[string]interface{}{
+			"replicas": replicas,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "web", "image": image},
+					},
+				},
+			},
+		},
+		"status": map[string]interface{}{"replicas": replicas},
+	}
+}
+
+func TestCompare(t *testing.T) {
+	result, err := Compare(deployment("nginx:1.25", 1, "10"), deployment("nginx:1.27", 1, "11"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Changes) != 1 {
+		t.Fatalf("expected 1 change, got %+v", result.Changes)
+	}
+	change := result.Changes[0]
+	if change.Path != ".spec.template.spec.containers[0].image" || change.Type != Modified ||
+		change.Old != "nginx:1.25" || change.New != "nginx:1.27" {
+		t.Fatalf("unexpected change %+v", change)
+	}
+	if !strings.HasPrefix(result.Diff, "--- live\n+++ proposed\n@@ ") ||
+		!strings.Contains(result.Diff, "\n-      - image: nginx:1.25\n+      - image: nginx:1.27\n") {
+		t.Fatalf("unexpected diff:\n%s", result.Diff)
+	}
+
+	result, err = Compare(deployment("nginx:1.25", 1, "10"), deployment("nginx:1.25", 1, "12"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Diff != "" || len(result.Changes) != 0 {
+		t.Fatalf("expected no difference, got %+v", result)
+	}
+
+	result, err = Compare(nil, deployment("nginx:1.25", 1, "1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Changes) != 1 || result.Changes[0].Path != "." || result.Changes[0].Type != Added {
+		t.Fatalf("expected the whole object added, got %+v", result.Changes)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	from := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	to := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+	want := "--- old\n+++ new\n" +
+		"@@ -1,5 +1,5 @@\n a\n-b\n+B\n c\n d\n e\n" +
+		"@@ -11,3 +11,4 @@\n k\n l\n m\n+n\n"
+	if got, err := Unified("old", "new", from, to, 3); err != nil || got != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedLarge(t *testing.T) {
+	// 大对象只改动中间一行时，按首尾相同的行裁剪后很快完成
+	var from, to strings.Builder
+	for i := 0; i < 200000; i++ {
+		fmt.Fprintf(&from, "key%d: value%d\n", i, i)
+		if i == 100000 {
+			to.WriteString("key100000: changed\n")
+			continue
+		}
+		fmt.Fprintf(&to, "key%d: value%d\n", i, i)
+	}
+	got, err := Unified("old", "new", from.String(), to.String(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "--- old\n+++ new\n" +
+		"@@ -100000,3 +100000,3 @@\n key99999: value99999\n-key100000: value100000\n+key100000: changed\n key100001: value100001\n"
+	if got != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+
+	// 分散的改动使用线性空间的 Myers 算法
+	from.Reset()
+	to.Reset()
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&from, "line%d\n", i)
+		if i%10 == 0 {
+			fmt.Fprintf(&to, "changed%d\n", i)
+		} else {
+			fmt.Fprintf(&to, "line%d\n", i)
+		}
+	}
+	got, err = Unified("old", "new", from.String(), to.String(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed := strings.Count(got, "\n-line"); removed != 500 {
+		t.Fatalf("expected 500 removed lines, got %d", removed)
+	}
+
+	// 差异部分超过上限时返回 ErrTooLarge
+	from.Reset()
+	to.Reset()
+	for i := 0; i <= MaxChangedLines/2; i++ {
+		fmt.Fprintf(&from, "old%d\n", i)
+		fmt.Fprintf(&to, "new%d\n", i)
+	}
+	if _, err := Unified("old", "new", from.String(), to.String(), 3); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxChangedLines 去掉首尾相同的行后，两边剩余行数之和的上限，超过时不计算差异
+const MaxChangedLines = 20000
+
+// ErrTooLarge 差异部分过大
+var ErrTooLarge = errors.New("diff: too many changed lines")
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type lineOp struct {
+	kind opKind
+	line string
+}
+
+// Unified 返回两段文本按行比较的 unified diff，context 为变更前后保留的上下文行数
+func Unified(fromName, toName, from, to string, context int) (string, error) {
+	ops, err := diffLines(splitLines(from), splitLines(to))
+	if err != nil {
+		return "", err
+	}
+
+	changed := false
+	for _, op := range ops {
+		if op.kind != opEqual {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return "", nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+
+	// 每个操作对应的新旧文件行号（从 0 开始）
+	fromLine := make([]int, len(ops)+1)
+	toLine := make([]int, len(ops)+1)
+	for i, op := range ops {
+		fromLine[i+1], toLine[i+1] = fromLine[i], toLine[i]
+		if op.kind != opInsert {
+			fromLine[i+1]++
+		}
+		if op.kind != opDelete {
+			toLine[i+1]++
+		}
+	}
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == opEqual {
+			i++
+			continue
+		}
+		// 向后合并间隔不超过 2*context 行的变更
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != opEqual {
+				end = j + 1
+				continue
+			}
+			if j-end >= 2*context {
+				break
+			}
+		}
+		stop := end + context
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n",
+			hunkRange(fromLine[start], fromLine[stop]-fromLine[start]),
+			hunkRange(toLine[start], toLine[stop]-toLine[start]))
+		for _, op := range ops[start:stop] {
+			switch op.kind {
+			case opEqual:
+				b.WriteString(" ")
+			case opDelete:
+				b.WriteString("-")
+			case opInsert:
+				b.WriteString("+")
+			}
+			b.WriteString(op.line)
+			b.WriteString("\n")
+		}
+		i = stop
+	}
+	return b.String(), nil
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines 使用线性空间的 Myers 算法计算行级别的编辑操作，先去掉首尾相同的行，
+// 内存占用与行数成正比，时间复杂度为 O((n+m)*d)，d 为编辑行数
+func diffLines(from, to []string) ([]lineOp, error) {
+	prefix := 0
+	for prefix < len(from) && prefix < len(to) && from[prefix] == to[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for prefix+suffix < len(from) && prefix+suffix < len(to) && from[len(from)-1-suffix] == to[len(to)-1-suffix] {
+		suffix++
+	}
+	if len(from)+len(to)-2*(prefix+suffix) > MaxChangedLines {
+		return nil, ErrTooLarge
+	}
+	ops := make([]lineOp, 0, len(from)+len(to))
+	return appendDiff(ops, from, to), nil
+}
+
+func appendDiff(ops []lineOp, from, to []string) []lineOp {
+	// 去掉相同的前缀和后缀
+	prefix := 0
+	for prefix < len(from) && prefix < len(to) && from[prefix] == to[prefix] {
+		ops = append(ops, lineOp{opEqual, from[prefix]})
+		prefix++
+	}
+	from, to = from[prefix:], to[prefix:]
+	suffix := 0
+	for suffix < len(from) && suffix < len(to) && from[len(from)-1-suffix] == to[len(to)-1-suffix] {
+		suffix++
+	}
+	common := from[len(from)-suffix:]
+	from, to = from[:len(from)-suffix], to[:len(to)-suffix]
+
+	switch {
+	case len(from) == 0:
+		for _, line := range to {
+			ops = append(ops, lineOp{opInsert, line})
+		}
+	case len(to) == 0:
+		for _, line := range from {
+			ops = append(ops, lineOp{opDelete, line})
+		}
+	default:
+		// 去掉首尾后两边都不为空时编辑距离至少为 2，按 middle snake 拆成编辑距离更小的两部分
+		x, y, u, v := middleSnake(from, to)
+		ops = appendDiff(ops, from[:x], to[:y])
+		for _, line := range from[x:u] {
+			ops = append(ops, lineOp{opEqual, line})
+		}
+		ops = appendDiff(ops, from[u:], to[v:])
+	}
+
+	for _, line := range common {
+		ops = append(ops, lineOp{opEqual, line})
+	}
+	return ops
+}
+
+// middleSnake 返回最短编辑路径中间的一段相同行 from[x:u] == to[y:v]，
+// 从两端同时搜索，只保存每条对角线上走到的最远位置
+func middleSnake(from, to []string) (x, y, u, v int) {
+	n, m := len(from), len(to)
+	delta := n - m
+	max := (n + m + 1) / 2
+	offset := max + 1
+	// forward[k] 为正向在对角线 k (x-y=k) 上的最远 x，backward[k] 为反向从末尾起算的最远距离
+	forward := make([]int, 2*max+3)
+	backward := make([]int, 2*max+3)
+
+	for d := 0; d <= max; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && from[x] == to[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			// 反向在对角线 delta-k 上已经走了 d-1 步
+			if rk := delta - k; delta%2 != 0 && rk >= -(d-1) && rk <= d-1 && x+backward[offset+rk] >= n {
+				return startX, startY, x, y
+			}
+		}
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && from[n-1-x] == to[m-1-y] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			if fk := delta - k; delta%2 == 0 && fk >= -d && fk <= d && forward[offset+fk]+x >= n {
+				return n - x, m - y, n - startX, m - startY
+			}
+		}
+	}
+	// 两端的搜索最多各走 max 步一定会相遇
+	panic("diff: middle snake not found")
+}
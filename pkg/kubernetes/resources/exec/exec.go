@@ -0,0 +1,73 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// 命令失败时错误信息中保留的 stderr 长度
+const maxStderrBytes = 4096
+
+// NewExecutor 创建在容器中执行命令的 SPDY executor
+func NewExecutor(cli kubernetes.Interface, cfg *rest.Config, namespace, pod string, opt *corev1.PodExecOptions) (remotecommand.Executor, error) {
+	req := cli.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(pod).
+		Namespace(namespace).
+		SubResource("exec").
+		VersionedParams(opt, scheme.ParameterCodec)
+
+	return remotecommand.NewSPDYExecutor(cfg, "POST", req.URL())
+}
+
+// Run 在容器中执行非交互命令，stdin 为 nil 时不打开标准输入。
+// 命令执行失败时返回的错误包含 stderr 的输出，例如 tar 提示文件不存在。
+func Run(ctx context.Context, cli kubernetes.Interface, cfg *rest.Config, namespace, pod, container string,
+	cmd []string, stdin io.Reader, stdout io.Writer) error {
+	executor, err := NewExecutor(cli, cfg, namespace, pod, &corev1.PodExecOptions{
+		Container: container,
+		Command:   cmd,
+		Stdin:     stdin != nil,
+		Stdout:    stdout != nil,
+		Stderr:    true,
+	})
+	if err != nil {
+		return err
+	}
+
+	stderr := &limitedBuffer{max: maxStderrBytes}
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %v", msg, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// limitedBuffer 只保留前 max 个字节，超出的部分直接丢弃
+type limitedBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.max - b.Len(); remaining > 0 {
+		b.Buffer.Write(p[:min(len(p), remaining)])
+	}
+	return len(p), nil
+}
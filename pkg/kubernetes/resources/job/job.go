@@ -0,0 +1,169 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// InstantiateAnnotation 手动触发 CronJob 时在 Job 上添加的注解，与 kubectl create job --from 一致
+	InstantiateAnnotation = "cronjob.kubernetes.io/instantiate"
+	// RerunOfAnnotation 重新运行的 Job 记录原 Job 名称
+	RerunOfAnnotation = "gwayne.io/rerun-of"
+
+	// 名称后缀的随机字符长度
+	suffixLength = 5
+	// Job 名称会作为 Pod 的 job-name 标签值，不能超过 63 个字符
+	maxNameLength = 63
+)
+
+var ErrJobNotFinished = errors.New("job is still running, only finished jobs can be rerun")
+
+// Job 创建时由控制器自动生成的标签，重新运行时需要去掉，否则新 Job 的 selector 与 Pod 标签冲突
+var generatedLabels = []string{
+	"controller-uid",
+	"job-name",
+	batchv1.ControllerUidLabel,
+	batchv1.JobNameLabel,
+}
+
+// generateName 生成 "<base>-<infix>-<随机字符>" 形式的名称，base 过长时截断
+func generateName(base, infix string) string {
+	suffix := fmt.Sprintf("-%s-%s", infix, utilrand.String(suffixLength))
+	if len(base)+len(suffix) > maxNameLength {
+		base = strings.TrimRight(base[:maxNameLength-len(suffix)], "-.")
+	}
+	return base + suffix
+}
+
+// TriggerCronJob 按照 CronJob 的 jobTemplate 立即创建一个 Job
+func TriggerCronJob(ctx context.Context, clientset kubernetes.Interface, namespace, name string) (*batchv1.Job, error) {
+	cronJob, err := clientset.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := map[string]string{InstantiateAnnotation: "manual"}
+	for k, v := range cronJob.Spec.JobTemplate.Annotations {
+		annotations[k] = v
+	}
+	isController := true
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        generateName(cronJob.Name, "manual"),
+			Namespace:   namespace,
+			Labels:      cronJob.Spec.JobTemplate.Labels,
+			Annotations: annotations,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion:         batchv1.SchemeGroupVersion.String(),
+				Kind:               "CronJob",
+				Name:               cronJob.Name,
+				UID:                cronJob.UID,
+				Controller:         &isController,
+				BlockOwnerDeletion: &isController,
+			}},
+		},
+		Spec: cronJob.Spec.JobTemplate.Spec,
+	}
+	return clientset.BatchV1().Jobs(namespace).Create(ctx, job, metav1.CreateOptions{})
+}
+
+// SetCronJobSuspend 暂停或恢复 CronJob 的调度
+func SetCronJobSuspend(ctx context.Context, clientset kubernetes.Interface, namespace, name string, suspend bool) (*batchv1.CronJob, error) {
+	patch := []byte(fmt.Sprintf(`{"spec":{"suspend":%t}}`, suspend))
+	return clientset.BatchV1().CronJobs(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+}
+
+// IsFinished Job 是否已经执行完成（成功或失败）
+func IsFinished(job *batchv1.Job) bool {
+	for _, cond := range job.Status.Conditions {
+		if (cond.Type == batchv1.JobComplete || cond.Type == batchv1.JobFailed) && cond.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// RerunJob 使用已完成 Job 的配置创建一个新名称的 Job，保留原 Job 的 ownerReferences，手动触发的 CronJob 任务重新运行后仍归属于该 CronJob
+func RerunJob(ctx context.Context, clientset kubernetes.Interface, namespace, name string) (*batchv1.Job, error) {
+	old, err := clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if !IsFinished(old) {
+		return nil, ErrJobNotFinished
+	}
+
+	// 多次重新运行时基于最初的 Job 名称生成新名称
+	base := old.Name
+	if original, ok := old.Annotations[RerunOfAnnotation]; ok {
+		base = original
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            generateName(base, "rerun"),
+			Namespace:       namespace,
+			Labels:          withoutGeneratedLabels(old.Labels),
+			Annotations:     map[string]string{RerunOfAnnotation: base},
+			OwnerReferences: old.OwnerReferences,
+		},
+		Spec: *old.Spec.DeepCopy(),
+	}
+	for k, v := range old.Annotations {
+		if k != RerunOfAnnotation && !strings.HasPrefix(k, "batch.kubernetes.io/") {
+			job.Annotations[k] = v
+		}
+	}
+	// 未手动指定 selector 时由控制器重新生成
+	if old.Spec.ManualSelector == nil || !*old.Spec.ManualSelector {
+		job.Spec.Selector = nil
+		job.Spec.Template.Labels = withoutGeneratedLabels(job.Spec.Template.Labels)
+	}
+	job.Spec.Suspend = nil
+	return clientset.BatchV1().Jobs(namespace).Create(ctx, job, metav1.CreateOptions{})
+}
+
+func withoutGeneratedLabels(labels map[string]string) map[string]string {
+	result := make(map[string]string, len(labels))
+	for k, v := range labels {
+		result[k] = v
+	}
+	for _, label := range generatedLabels {
+		delete(result, label)
+	}
+	return result
+}
+
+// ListCronJobJobs 返回 CronJob 创建的 Job，按创建时间倒序排列
+func ListCronJobJobs(ctx context.Context, clientset kubernetes.Interface, namespace, name string) ([]batchv1.Job, error) {
+	cronJob, err := clientset.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	list, err := clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]batchv1.Job, 0)
+	for _, job := range list.Items {
+		if ref := metav1.GetControllerOf(&job); ref != nil && ref.UID == cronJob.UID {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[j].CreationTimestamp.Before(&jobs[i].CreationTimestamp)
+	})
+	return jobs, nil
+}
@@ -0,0 +1,132 @@
+package job
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testCronJob() *batchv1.CronJob {
+	return &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default", UID: types.UID("cron-uid")},
+		Spec: batchv1.CronJobSpec{
+			Schedule: "0 * * * *",
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"app": "backup"},
+					Annotations: map[string]string{"team": "ops"},
+				},
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							RestartPolicy: corev1.RestartPolicyNever,
+							Containers:    []corev1.Container{{Name: "backup", Image: "busybox"}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestTriggerCronJob(t *testing.T) {
+	clientset := fake.NewSimpleClientset(testCronJob())
+
+	job, err := TriggerCronJob(context.TODO(), clientset, "default", "backup")
+	if err != nil {
+		t.Fatalf("TriggerCronJob error: %v", err)
+	}
+	if !strings.HasPrefix(job.Name, "backup-manual-") {
+		t.Errorf("unexpected job name %s", job.Name)
+	}
+	if job.Annotations[InstantiateAnnotation] != "manual" || job.Annotations["team"] != "ops" {
+		t.Errorf("unexpected annotations %v", job.Annotations)
+	}
+	if job.Labels["app"] != "backup" {
+		t.Errorf("unexpected labels %v", job.Labels)
+	}
+	ref := metav1.GetControllerOf(job)
+	if ref == nil || ref.UID != "cron-uid" || ref.Kind != "CronJob" {
+		t.Errorf("unexpected controller reference %v", ref)
+	}
+
+	jobs, err := ListCronJobJobs(context.TODO(), clientset, "default", "backup")
+	if err != nil {
+		t.Fatalf("ListCronJobJobs error: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].Name != job.Name {
+		t.Errorf("unexpected jobs %v", jobs)
+	}
+}
+
+func TestSetCronJobSuspend(t *testing.T) {
+	clientset := fake.NewSimpleClientset(testCronJob())
+
+	cronJob, err := SetCronJobSuspend(context.TODO(), clientset, "default", "backup", true)
+	if err != nil {
+		t.Fatalf("SetCronJobSuspend error: %v", err)
+	}
+	if cronJob.Spec.Suspend == nil || !*cronJob.Spec.Suspend {
+		t.Errorf("cronjob should be suspended")
+	}
+}
+
+func TestRerunJob(t *testing.T) {
+	running := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: "default"},
+	}
+	finished := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "migrate",
+			Namespace: "default",
+			Labels:    map[string]string{"app": "migrate", batchv1.ControllerUidLabel: "old-uid", "controller-uid": "old-uid"},
+		},
+		Spec: batchv1.JobSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{batchv1.ControllerUidLabel: "old-uid"}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"app": "migrate", batchv1.ControllerUidLabel: "old-uid", batchv1.JobNameLabel: "migrate"},
+				},
+			},
+		},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(time.Now())}},
+		},
+	}
+	clientset := fake.NewSimpleClientset(running, finished)
+
+	if _, err := RerunJob(context.TODO(), clientset, "default", "running"); err != ErrJobNotFinished {
+		t.Errorf("expected ErrJobNotFinished, got %v", err)
+	}
+
+	job, err := RerunJob(context.TODO(), clientset, "default", "migrate")
+	if err != nil {
+		t.Fatalf("RerunJob error: %v", err)
+	}
+	if !strings.HasPrefix(job.Name, "migrate-rerun-") {
+		t.Errorf("unexpected job name %s", job.Name)
+	}
+	if job.Spec.Selector != nil {
+		t.Errorf("selector should be regenerated, got %v", job.Spec.Selector)
+	}
+	if _, ok := job.Spec.Template.Labels[batchv1.ControllerUidLabel]; ok {
+		t.Errorf("generated labels should be removed, got %v", job.Spec.Template.Labels)
+	}
+	if job.Labels["app"] != "migrate" || job.Annotations[RerunOfAnnotation] != "migrate" {
+		t.Errorf("unexpected metadata %v %v", job.Labels, job.Annotations)
+	}
+}
+
+func TestGenerateName(t *testing.T) {
+	name := generateName(strings.Repeat("a", 70), "manual")
+	if len(name) > maxNameLength {
+		t.Errorf("name %s is longer than %d", name, maxNameLength)
+	}
+}
@@ -0,0 +1,387 @@
+package log
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	defaultMaxStreams       = 10
+	defaultDiscoverInterval = 5 * time.Second
+	// 不同 Pod 的日志到达顺序不一致，在窗口内按时间排序后再输出
+	defaultReorderWindow = time.Second
+)
+
+// EventType 聚合日志流的事件类型
+type EventType string
+
+const (
+	EventLog EventType = "log"
+	// EventJoin 开始读取容器日志
+	EventJoin EventType = "join"
+	// EventLeave 容器日志流结束
+	EventLeave EventType = "leave"
+	// EventLimit 日志流数量达到上限，容器需要等待其他日志流结束
+	EventLimit EventType = "limit"
+	EventError EventType = "error"
+)
+
+// Target 需要读取日志的容器
+type Target struct {
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+}
+
+// Event 聚合日志流中的一条日志或通知
+type Event struct {
+	Type      EventType `json:"type"`
+	Pod       string    `json:"pod"`
+	Container string    `json:"container"`
+	Time      time.Time `json:"time"`
+	Line      string    `json:"line,omitempty"`
+	Message   string    `json:"message,omitempty"`
+
+	seq uint64
+}
+
+// AggregateOptions 聚合日志的选项
+type AggregateOptions struct {
+	// 日志选项，Container 字段不生效，Timestamps 为 false 时输出的日志行不带时间戳
+	LogOptions corev1.PodLogOptions
+	// 同时读取的日志流数量上限，默认 10
+	MaxStreams int
+	// 缓存的日志行数
+	BufferLines int
+	// Discover 返回需要读取日志的容器，follow 模式下定期调用，新启动的 Pod 会自动加入
+	Discover func() ([]Target, error)
+	// 调用 Discover 的间隔，默认 5 秒
+	DiscoverInterval time.Duration
+	// 排序窗口，默认 1 秒，follow 模式下超过该时间没有新日志的日志流不再阻塞其他日志流的输出
+	ReorderWindow time.Duration
+}
+
+// Aggregator 将多个容器的日志按时间合并为一个日志流
+type Aggregator struct {
+	cli       kubernetes.Interface
+	namespace string
+	opts      AggregateOptions
+
+	events chan Event
+	input  chan Event
+	ended  chan Target
+
+	active   map[Target]bool
+	started  map[Target]bool
+	pending  []Target
+	lastTime map[Target]time.Time
+	// 日志流最后一次收到日志的时间，用于判断日志流是否已经追上
+	lastReceive map[Target]time.Time
+	buffer      eventHeap
+	seq         uint64
+
+	// 打开容器的日志流，测试时替换
+	openStream func(ctx context.Context, pod string, opt *corev1.PodLogOptions) (*Stream, error)
+}
+
+// Aggregate 开始聚合日志，ctx 取消后所有日志流关闭。
+// 非 follow 模式下所有容器的日志读取完后按时间顺序统一输出，然后结束。
+func Aggregate(ctx context.Context, cli kubernetes.Interface, namespace string, opts AggregateOptions) *Aggregator {
+	a := newAggregator(cli, namespace, opts)
+	go a.run(ctx)
+	return a
+}
+
+func newAggregator(cli kubernetes.Interface, namespace string, opts AggregateOptions) *Aggregator {
+	if opts.MaxStreams <= 0 {
+		opts.MaxStreams = defaultMaxStreams
+	}
+	if opts.BufferLines <= 0 {
+		opts.BufferLines = defaultBufferLines
+	}
+	if opts.DiscoverInterval <= 0 {
+		opts.DiscoverInterval = defaultDiscoverInterval
+	}
+	if opts.ReorderWindow <= 0 {
+		opts.ReorderWindow = defaultReorderWindow
+	}
+	a := &Aggregator{
+		cli:         cli,
+		namespace:   namespace,
+		opts:        opts,
+		events:      make(chan Event, opts.BufferLines),
+		input:       make(chan Event, opts.BufferLines),
+		ended:       make(chan Target),
+		active:      map[Target]bool{},
+		started:     map[Target]bool{},
+		lastTime:    map[Target]time.Time{},
+		lastReceive: map[Target]time.Time{},
+	}
+	a.openStream = func(ctx context.Context, pod string, opt *corev1.PodLogOptions) (*Stream, error) {
+		return NewStream(ctx, a.cli, a.namespace, pod, opt, a.opts.BufferLines)
+	}
+	return a
+}
+
+// Events 返回聚合后的事件，日志流结束后关闭
+func (a *Aggregator) Events() <-chan Event {
+	return a.events
+}
+
+func (a *Aggregator) run(ctx context.Context) {
+	defer close(a.events)
+
+	a.discover(ctx)
+
+	// 非 follow 模式下慢的日志流和排队的容器可能还有更早的日志，所有日志读取完后再统一输出
+	var flushC, discoverC <-chan time.Time
+	if a.opts.LogOptions.Follow {
+		flushTicker := time.NewTicker(a.opts.ReorderWindow / 2)
+		defer flushTicker.Stop()
+		flushC = flushTicker.C
+		discoverTicker := time.NewTicker(a.opts.DiscoverInterval)
+		defer discoverTicker.Stop()
+		discoverC = discoverTicker.C
+	}
+
+	for {
+		// 非 follow 模式下所有日志流结束后输出剩余的日志
+		if !a.opts.LogOptions.Follow && len(a.active) == 0 {
+			a.drainInput(ctx)
+			a.flushAll(ctx)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-a.input:
+			a.receive(ctx, event)
+		case target := <-a.ended:
+			delete(a.active, target)
+			if a.opts.LogOptions.Follow {
+				// 容器重启后重新加入，从最后一条日志的时间继续读取
+				delete(a.started, target)
+			}
+			a.notify(ctx, Event{Type: EventLeave, Pod: target.Pod, Container: target.Container, Time: time.Now()})
+			a.startPending(ctx)
+		case <-flushC:
+			a.flush(ctx, a.watermark(time.Now()))
+		case <-discoverC:
+			a.discover(ctx)
+		}
+	}
+}
+
+func (a *Aggregator) discover(ctx context.Context) {
+	if a.opts.Discover == nil {
+		return
+	}
+	targets, err := a.opts.Discover()
+	if err != nil {
+		a.emit(ctx, Event{Type: EventError, Time: time.Now(), Message: err.Error()})
+		return
+	}
+	for _, target := range targets {
+		if a.started[target] || a.isPending(target) {
+			continue
+		}
+		if len(a.active) >= a.opts.MaxStreams {
+			a.pending = append(a.pending, target)
+			a.emit(ctx, Event{Type: EventLimit, Pod: target.Pod, Container: target.Container, Time: time.Now(),
+				Message: fmt.Sprintf("max %d streams reached, waiting for other streams to end", a.opts.MaxStreams)})
+			continue
+		}
+		a.start(ctx, target)
+	}
+}
+
+func (a *Aggregator) isPending(target Target) bool {
+	for _, t := range a.pending {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Aggregator) startPending(ctx context.Context) {
+	for len(a.pending) > 0 && len(a.active) < a.opts.MaxStreams {
+		target := a.pending[0]
+		a.pending = a.pending[1:]
+		a.start(ctx, target)
+	}
+}
+
+func (a *Aggregator) start(ctx context.Context, target Target) {
+	a.started[target] = true
+	a.active[target] = true
+	a.lastReceive[target] = time.Now()
+
+	opt := a.opts.LogOptions.DeepCopy()
+	opt.Container = target.Container
+	// 需要时间戳用于排序，输出时再按照用户的选项去掉
+	opt.Timestamps = true
+	if last, ok := a.lastTime[target]; ok {
+		opt.TailLines = nil
+		opt.SinceSeconds = nil
+		sinceTime := metav1.NewTime(last)
+		opt.SinceTime = &sinceTime
+	}
+
+	a.notify(ctx, Event{Type: EventJoin, Pod: target.Pod, Container: target.Container, Time: time.Now()})
+	go a.read(ctx, target, opt)
+}
+
+func (a *Aggregator) read(ctx context.Context, target Target, opt *corev1.PodLogOptions) {
+	defer func() {
+		select {
+		case a.ended <- target:
+		case <-ctx.Done():
+		}
+	}()
+
+	stream, err := a.openStream(ctx, target.Pod, opt)
+	if err != nil {
+		a.send(ctx, Event{Type: EventError, Pod: target.Pod, Container: target.Container, Time: time.Now(), Message: err.Error()})
+		return
+	}
+	defer stream.Close()
+
+	for line := range stream.Lines() {
+		event := Event{Type: EventLog, Pod: target.Pod, Container: target.Container}
+		event.Time, event.Line = SplitTimestamp(line)
+		if a.opts.LogOptions.Timestamps {
+			event.Line = line
+		}
+		if !a.send(ctx, event) {
+			return
+		}
+	}
+	if err := stream.Err(); err != nil {
+		a.send(ctx, Event{Type: EventError, Pod: target.Pod, Container: target.Container, Time: time.Now(), Message: err.Error()})
+	}
+}
+
+func (a *Aggregator) send(ctx context.Context, event Event) bool {
+	select {
+	case a.input <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (a *Aggregator) receive(ctx context.Context, event Event) {
+	if event.Type != EventLog {
+		a.emit(ctx, event)
+		return
+	}
+	target := Target{Pod: event.Pod, Container: event.Container}
+	if event.Time.After(a.lastTime[target]) {
+		a.lastTime[target] = event.Time
+	}
+	a.lastReceive[target] = time.Now()
+	a.seq++
+	event.seq = a.seq
+	heap.Push(&a.buffer, event)
+}
+
+// drainInput 读取已经进入缓存的日志，所有日志流结束后调用
+func (a *Aggregator) drainInput(ctx context.Context) {
+	for {
+		select {
+		case event := <-a.input:
+			a.receive(ctx, event)
+		default:
+			return
+		}
+	}
+}
+
+// watermark 返回可以输出的日志时间上限，follow 模式下使用。
+// 仍在读取日志的日志流（例如正在读取历史日志）读到的最后时间之前的日志才会输出，
+// 超过排序窗口没有新日志的日志流视为已经追上，之后的日志不会早于 now 减去排序窗口。
+// 排队的容器可能一直等待，不阻塞输出，加入后读取的历史日志会晚于其他容器较新的日志输出。
+func (a *Aggregator) watermark(now time.Time) time.Time {
+	mark := now.Add(-a.opts.ReorderWindow)
+	for target := range a.active {
+		if now.Sub(a.lastReceive[target]) >= a.opts.ReorderWindow {
+			continue
+		}
+		if last := a.lastTime[target]; last.Before(mark) {
+			mark = last
+		}
+	}
+	return mark
+}
+
+// flush 按时间顺序输出不晚于 before 的日志
+func (a *Aggregator) flush(ctx context.Context, before time.Time) {
+	for a.buffer.Len() > 0 && !a.buffer[0].Time.After(before) {
+		if !a.emit(ctx, heap.Pop(&a.buffer).(Event)) {
+			return
+		}
+	}
+}
+
+// flushAll 按时间顺序输出全部日志
+func (a *Aggregator) flushAll(ctx context.Context) {
+	for a.buffer.Len() > 0 {
+		if !a.emit(ctx, heap.Pop(&a.buffer).(Event)) {
+			return
+		}
+	}
+}
+
+// notify 输出容器加入和离开的通知，非 follow 模式下日志会在最后统一输出，不需要通知
+func (a *Aggregator) notify(ctx context.Context, event Event) {
+	if a.opts.LogOptions.Follow {
+		a.emit(ctx, event)
+	}
+}
+
+func (a *Aggregator) emit(ctx context.Context, event Event) bool {
+	select {
+	case a.events <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// SplitTimestamp 拆分 kubelet 添加的 RFC3339 时间戳，没有时间戳时返回当前时间
+func SplitTimestamp(line string) (time.Time, string) {
+	if t, rest, ok := splitTimestamp([]byte(line)); ok {
+		return t, string(rest)
+	}
+	return time.Now(), line
+}
+
+// eventHeap 按日志时间排序，时间相同时按到达顺序
+type eventHeap []Event
+
+func (h eventHeap) Len() int { return len(h) }
+func (h eventHeap) Less(i, j int) bool {
+	if h[i].Time.Equal(h[j].Time) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].Time.Before(h[j].Time)
+}
+func (h eventHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *eventHeap) Push(x interface{}) {
+	*h = append(*h, x.(Event))
+}
+
+func (h *eventHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	event := old[n-1]
+	*h = old[:n-1]
+	return event
+}
@@ -0,0 +1,158 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAggregate(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	aggregator := Aggregate(context.TODO(), clientset, "default", AggregateOptions{
+		MaxStreams: 1,
+		Discover: func() ([]Target, error) {
+			return []Target{{Pod: "web-1", Container: "web"}, {Pod: "web-2", Container: "web"}}, nil
+		},
+	})
+
+	counts := map[EventType]int{}
+	pods := map[string]bool{}
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case event, ok := <-aggregator.Events():
+			if !ok {
+				done = true
+				break
+			}
+			counts[event.Type]++
+			if event.Type == EventLog {
+				pods[event.Pod] = true
+				if event.Line != "fake logs" {
+					t.Errorf("unexpected line %q", event.Line)
+				}
+			}
+		case <-timeout:
+			t.Fatal("aggregator did not finish")
+		}
+	}
+	// 第二个容器需要等待第一个日志流结束
+	if counts[EventLimit] != 1 || counts[EventLog] != 2 || len(pods) != 2 {
+		t.Errorf("unexpected events %v, pods %v", counts, pods)
+	}
+}
+
+func TestAggregateDelayedStream(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	logs := map[string][]int{"web-1": {1, 3}, "web-2": {0, 2}}
+	aggregator := newAggregator(fake.NewSimpleClientset(), "default", AggregateOptions{
+		ReorderWindow: 100 * time.Millisecond,
+		Discover: func() ([]Target, error) {
+			return []Target{{Pod: "web-1", Container: "web"}, {Pod: "web-2", Container: "web"}}, nil
+		},
+	})
+	// web-2 的日志更早，但是在排序窗口过去之后才到达
+	aggregator.openStream = func(ctx context.Context, pod string, opt *corev1.PodLogOptions) (*Stream, error) {
+		reader, writer := io.Pipe()
+		go func() {
+			if pod == "web-2" {
+				time.Sleep(500 * time.Millisecond)
+			}
+			for _, second := range logs[pod] {
+				fmt.Fprintf(writer, "%s line %d\n", base.Add(time.Duration(second)*time.Second).Format(time.RFC3339Nano), second)
+			}
+			writer.Close()
+		}()
+		return newStream(ctx, reader, 0), nil
+	}
+	go aggregator.run(context.TODO())
+
+	lines := make([]string, 0)
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case event, ok := <-aggregator.Events():
+			if !ok {
+				done = true
+				break
+			}
+			if event.Type == EventLog {
+				lines = append(lines, event.Line)
+			}
+		case <-timeout:
+			t.Fatal("aggregator did not finish")
+		}
+	}
+	if got := strings.Join(lines, ","); got != "line 0,line 1,line 2,line 3" {
+		t.Errorf("unexpected order %s", got)
+	}
+}
+
+func TestAggregatorOrder(t *testing.T) {
+	a := &Aggregator{events: make(chan Event, 10), lastTime: map[Target]time.Time{}, lastReceive: map[Target]time.Time{}}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, line := range []string{
+		base.Add(2*time.Second).Format(time.RFC3339Nano) + " third",
+		base.Format(time.RFC3339Nano) + " first",
+		base.Add(time.Second).Format(time.RFC3339Nano) + " second",
+	} {
+		event := Event{Type: EventLog, Pod: "web"}
+		event.Time, event.Line = SplitTimestamp(line)
+		a.receive(context.TODO(), event)
+	}
+
+	// 只输出早于窗口的日志
+	a.flush(context.TODO(), base.Add(time.Second))
+	if len(a.events) != 2 {
+		t.Fatalf("expected 2 flushed events, got %d", len(a.events))
+	}
+	a.flushAll(context.TODO())
+	close(a.events)
+
+	expected := []string{"first", "second", "third"}
+	i := 0
+	for event := range a.events {
+		if event.Line != expected[i] {
+			t.Errorf("event %d: expected %q, got %q", i, expected[i], event.Line)
+		}
+		i++
+	}
+	if last := a.lastTime[Target{Pod: "web"}]; !last.Equal(base.Add(2 * time.Second)) {
+		t.Errorf("unexpected last time %v", last)
+	}
+}
+
+func TestAggregatorWatermark(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC)
+	reading, idle := Target{Pod: "web-1"}, Target{Pod: "web-2"}
+	a := &Aggregator{
+		opts:        AggregateOptions{ReorderWindow: time.Second},
+		active:      map[Target]bool{reading: true, idle: true},
+		lastTime:    map[Target]time.Time{reading: now.Add(-time.Minute), idle: now.Add(-2 * time.Minute)},
+		lastReceive: map[Target]time.Time{reading: now, idle: now.Add(-time.Minute)},
+	}
+	// 正在读取历史日志的日志流阻塞较新的日志，空闲的日志流不阻塞
+	if mark := a.watermark(now); !mark.Equal(now.Add(-time.Minute)) {
+		t.Errorf("unexpected watermark %v", mark)
+	}
+	a.lastReceive[reading] = now.Add(-time.Minute)
+	if mark := a.watermark(now); !mark.Equal(now.Add(-time.Second)) {
+		t.Errorf("unexpected watermark %v", mark)
+	}
+}
+
+func TestSplitTimestamp(t *testing.T) {
+	ts, line := SplitTimestamp("2024-01-02T03:04:05.123456789Z hello world")
+	if line != "hello world" || ts.Nanosecond() != 123456789 {
+		t.Errorf("unexpected result %v %q", ts, line)
+	}
+	if _, line := SplitTimestamp("no timestamp here"); line != "no timestamp here" {
+		t.Errorf("unexpected line %q", line)
+	}
+}
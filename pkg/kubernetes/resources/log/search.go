@@ -0,0 +1,265 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	defaultSearchContext    = 2
+	maxSearchContext        = 20
+	defaultSearchMaxMatches = 100
+	maxSearchMaxMatches     = 1000
+)
+
+// FilterOptions 按行过滤日志，Until 不为零值时需要以 timestamps=true 读取日志
+type FilterOptions struct {
+	// 只保留该时间之前的日志，日志按时间顺序输出，遇到更晚的日志后停止读取
+	Until time.Time
+	// 去掉 kubelet 添加的时间戳
+	StripTimestamps bool
+}
+
+// ParseUntilTime 解析 untilTime 参数（RFC3339），Kubernetes 日志接口不支持结束时间，由服务端过滤
+func ParseUntilTime(query url.Values) (time.Time, error) {
+	value := query.Get("untilTime")
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid untilTime %q, expected RFC3339", value)
+	}
+	return t, nil
+}
+
+// CapLimitBytes 限制读取的日志大小，未指定 limitBytes 或者超过上限时使用上限
+func CapLimitBytes(opt *corev1.PodLogOptions, max int64) {
+	if max > 0 && (opt.LimitBytes == nil || *opt.LimitBytes > max) {
+		opt.LimitBytes = &max
+	}
+}
+
+// splitTimestamp 拆分行首的 RFC3339 时间戳
+func splitTimestamp(line []byte) (time.Time, []byte, bool) {
+	if i := bytes.IndexByte(line, ' '); i > 0 {
+		if t, err := time.Parse(time.RFC3339Nano, string(line[:i])); err == nil {
+			return t, line[i+1:], true
+		}
+	}
+	return time.Time{}, line, false
+}
+
+// filter 处理一行日志的开头部分，返回需要输出的内容，stop 表示已经超过结束时间
+func (o FilterOptions) filter(line []byte) (out []byte, stop bool) {
+	t, rest, ok := splitTimestamp(line)
+	if !ok {
+		return line, false
+	}
+	if !o.Until.IsZero() && t.After(o.Until) {
+		return nil, true
+	}
+	if o.StripTimestamps {
+		return rest, false
+	}
+	return line, false
+}
+
+// Copy 将日志写入 dst，不按行缓存整个日志，超长的行也原样输出
+func Copy(dst io.Writer, src io.Reader, opts FilterOptions) (int64, error) {
+	if opts.Until.IsZero() && !opts.StripTimestamps {
+		return io.Copy(dst, src)
+	}
+
+	reader := bufio.NewReaderSize(src, maxLineBytes)
+	var written int64
+	lineStart := true
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if len(chunk) > 0 {
+			out := chunk
+			// 超长的行会分多次读取，只有行首带有时间戳
+			if lineStart {
+				var stop bool
+				if out, stop = opts.filter(chunk); stop {
+					return written, nil
+				}
+			}
+			n, werr := dst.Write(out)
+			written += int64(n)
+			if werr != nil {
+				return written, werr
+			}
+			lineStart = chunk[len(chunk)-1] == '\n'
+		}
+		switch {
+		case err == nil, errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case errors.Is(err, io.EOF):
+			return written, nil
+		default:
+			return written, err
+		}
+	}
+}
+
+// SearchOptions 日志搜索选项
+type SearchOptions struct {
+	FilterOptions
+	Pattern *regexp.Regexp
+	// 匹配行前后输出的行数，最大 20
+	Context int
+	// 最多返回的匹配数，默认 100，最大 1000
+	MaxMatches int
+}
+
+// ParseSearchOptions 解析搜索参数：q（正则表达式）、ignoreCase、context、maxMatches
+func ParseSearchOptions(query url.Values) (*SearchOptions, error) {
+	pattern := query.Get("q")
+	if pattern == "" {
+		return nil, errors.New("q is required")
+	}
+	ignoreCase, err := parseBool(query, "ignoreCase")
+	if err != nil {
+		return nil, err
+	}
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %v", err)
+	}
+
+	opts := &SearchOptions{Pattern: re, Context: defaultSearchContext}
+	if value := query.Get("context"); value != "" {
+		if opts.Context, err = strconv.Atoi(value); err != nil || opts.Context < 0 {
+			return nil, fmt.Errorf("invalid context %q", value)
+		}
+	}
+	if value := query.Get("maxMatches"); value != "" {
+		if opts.MaxMatches, err = strconv.Atoi(value); err != nil || opts.MaxMatches <= 0 {
+			return nil, fmt.Errorf("invalid maxMatches %q", value)
+		}
+	}
+	return opts, nil
+}
+
+// Match 匹配的日志行，Line 为行号（从 1 开始，相对于本次读取的日志）
+type Match struct {
+	Line   int      `json:"line"`
+	Text   string   `json:"text"`
+	Before []string `json:"before,omitempty"`
+	After  []string `json:"after,omitempty"`
+}
+
+// SearchResult 日志搜索结果，Truncated 表示匹配数达到上限后停止了搜索
+type SearchResult struct {
+	Matches      []*Match `json:"matches"`
+	Truncated    bool     `json:"truncated"`
+	ScannedLines int      `json:"scannedLines"`
+}
+
+// Search 按行搜索日志，只在内存中保留上下文需要的行
+func Search(src io.Reader, opts SearchOptions) (*SearchResult, error) {
+	if opts.Pattern == nil {
+		return nil, errors.New("search pattern is required")
+	}
+	if opts.Context > maxSearchContext {
+		opts.Context = maxSearchContext
+	}
+	if opts.MaxMatches <= 0 {
+		opts.MaxMatches = defaultSearchMaxMatches
+	}
+	if opts.MaxMatches > maxSearchMaxMatches {
+		opts.MaxMatches = maxSearchMaxMatches
+	}
+
+	result := &SearchResult{Matches: make([]*Match, 0)}
+	reader := bufio.NewReaderSize(src, maxLineBytes)
+	before := make([]string, 0, opts.Context)
+	// 还需要补充后续上下文的匹配
+	open := make([]*Match, 0)
+
+	for {
+		line, err := readLine(reader)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if line == nil {
+			return result, nil
+		}
+
+		out, stop := opts.filter(line)
+		if stop {
+			return result, nil
+		}
+		text := string(out)
+		result.ScannedLines++
+
+		remaining := open[:0]
+		for _, m := range open {
+			m.After = append(m.After, text)
+			if len(m.After) < opts.Context {
+				remaining = append(remaining, m)
+			}
+		}
+		open = remaining
+
+		if !result.Truncated && opts.Pattern.MatchString(text) {
+			if len(result.Matches) >= opts.MaxMatches {
+				result.Truncated = true
+			} else {
+				m := &Match{Line: result.ScannedLines, Text: text, Before: append([]string(nil), before...)}
+				result.Matches = append(result.Matches, m)
+				if opts.Context > 0 {
+					open = append(open, m)
+				}
+			}
+		}
+		// 达到上限并且上下文已经补齐后不再读取剩余的日志
+		if result.Truncated && len(open) == 0 {
+			return result, nil
+		}
+
+		if opts.Context > 0 {
+			if len(before) == opts.Context {
+				before = before[1:]
+			}
+			before = append(before, text)
+		}
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+	}
+}
+
+// readLine 读取一行日志（不含换行符），超过 maxLineBytes 的部分被丢弃。日志结束时返回 nil 和 io.EOF
+func readLine(reader *bufio.Reader) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if len(line) < maxLineBytes {
+			line = append(line, chunk[:min(len(chunk), maxLineBytes-len(line))]...)
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if len(line) == 0 && errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return bytes.TrimRight(line, "\r\n"), err
+	}
+}
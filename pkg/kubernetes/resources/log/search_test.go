@@ -0,0 +1,88 @@
+package log
+
+import (
+	"bytes"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+const timestampedLog = `2024-01-01T00:00:01Z starting
+2024-01-01T00:00:02Z connected
+2024-01-01T00:00:03Z java.lang.NullPointerException
+2024-01-01T00:00:04Z 	at Foo.bar
+2024-01-01T00:00:05Z retrying
+2024-01-01T00:00:06Z NullPointerException again
+`
+
+func TestCopy(t *testing.T) {
+	var buf bytes.Buffer
+	until := time.Date(2024, 1, 1, 0, 0, 2, 0, time.UTC)
+	if _, err := Copy(&buf, strings.NewReader(timestampedLog), FilterOptions{Until: until, StripTimestamps: true}); err != nil {
+		t.Fatalf("Copy error: %v", err)
+	}
+	if buf.String() != "starting\nconnected\n" {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+
+	buf.Reset()
+	if _, err := Copy(&buf, strings.NewReader(timestampedLog), FilterOptions{}); err != nil {
+		t.Fatalf("Copy error: %v", err)
+	}
+	if buf.String() != timestampedLog {
+		t.Errorf("log should be copied unchanged")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	opts, err := ParseSearchOptions(url.Values{"q": {"nullpointer"}, "ignoreCase": {"true"}, "context": {"1"}})
+	if err != nil {
+		t.Fatalf("ParseSearchOptions error: %v", err)
+	}
+	opts.StripTimestamps = true
+
+	result, err := Search(strings.NewReader(timestampedLog), *opts)
+	if err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	if len(result.Matches) != 2 || result.Truncated || result.ScannedLines != 6 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	first := result.Matches[0]
+	if first.Line != 3 || first.Text != "java.lang.NullPointerException" ||
+		len(first.Before) != 1 || first.Before[0] != "connected" ||
+		len(first.After) != 1 || first.After[0] != "\tat Foo.bar" {
+		t.Errorf("unexpected first match %+v", first)
+	}
+	if last := result.Matches[1]; last.Line != 6 || len(last.After) != 0 {
+		t.Errorf("unexpected last match %+v", last)
+	}
+
+	opts.MaxMatches = 1
+	result, err = Search(strings.NewReader(timestampedLog), *opts)
+	if err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	if len(result.Matches) != 1 || !result.Truncated {
+		t.Errorf("expected truncated result, got %+v", result)
+	}
+
+	for _, query := range []url.Values{{}, {"q": {"("}}, {"q": {"a"}, "context": {"-1"}}} {
+		if _, err := ParseSearchOptions(query); err == nil {
+			t.Errorf("expected error for %v", query)
+		}
+	}
+}
+
+func TestReadLineTruncatesLongLines(t *testing.T) {
+	long := strings.Repeat("a", maxLineBytes*2)
+	result, err := Search(strings.NewReader(long+"\nshort"), SearchOptions{Pattern: regexp.MustCompile("short")})
+	if err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	if result.ScannedLines != 2 || len(result.Matches) != 1 || result.Matches[0].Line != 2 {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
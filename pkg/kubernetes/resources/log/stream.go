@@ -0,0 +1,183 @@
+package log
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/watch"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	defaultBufferLines = 1000
+	// 单行日志的最大长度，超过后拆分为多行，避免没有换行符的输出占用过多内存
+	maxLineBytes = 64 * 1024
+)
+
+// StreamLimiter 每个用户同时打开的日志流数量，单个容器的日志流和聚合日志流共用
+var StreamLimiter = watch.NewConnectionLimiter()
+
+// ParseOptions 从查询参数解析日志选项：follow、timestamps、previous、sinceSeconds、sinceTime（RFC3339）、tailLines、limitBytes
+func ParseOptions(query url.Values) (*corev1.PodLogOptions, error) {
+	opt := &corev1.PodLogOptions{}
+	var err error
+	if opt.Follow, err = parseBool(query, "follow"); err != nil {
+		return nil, err
+	}
+	if opt.Timestamps, err = parseBool(query, "timestamps"); err != nil {
+		return nil, err
+	}
+	if opt.Previous, err = parseBool(query, "previous"); err != nil {
+		return nil, err
+	}
+	if opt.SinceSeconds, err = parsePositive(query, "sinceSeconds"); err != nil {
+		return nil, err
+	}
+	if opt.LimitBytes, err = parsePositive(query, "limitBytes"); err != nil {
+		return nil, err
+	}
+	if value := query.Get("tailLines"); value != "" {
+		lines, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || lines < 0 {
+			return nil, fmt.Errorf("invalid tailLines %q", value)
+		}
+		opt.TailLines = &lines
+	}
+	if value := query.Get("sinceTime"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sinceTime %q, expected RFC3339", value)
+		}
+		sinceTime := metav1.NewTime(t)
+		opt.SinceTime = &sinceTime
+	}
+	if opt.SinceSeconds != nil && opt.SinceTime != nil {
+		return nil, errors.New("at most one of sinceSeconds or sinceTime may be specified")
+	}
+	return opt, nil
+}
+
+func parseBool(query url.Values, key string) (bool, error) {
+	value := query.Get(key)
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q", key, value)
+	}
+	return b, nil
+}
+
+func parsePositive(query url.Values, key string) (*int64, error) {
+	value := query.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("invalid %s %q", key, value)
+	}
+	return &n, nil
+}
+
+// Stream 按行读取容器日志。
+// 行缓存写满时停止读取 apiserver 的响应，由 TCP 流控将背压传递到 kubelet，不会丢弃日志也不会无限占用内存。
+type Stream struct {
+	lines chan string
+	done  chan struct{}
+	err   error
+
+	body      io.ReadCloser
+	closeOnce sync.Once
+}
+
+// NewStream 打开容器日志流，ctx 取消（例如浏览器断开连接）时关闭日志流。
+// 请求日志失败（Pod 不存在、容器名错误等）时直接返回错误。
+func NewStream(ctx context.Context, cli kubernetes.Interface, namespace, pod string, opt *corev1.PodLogOptions, bufferLines int) (*Stream, error) {
+	body, err := cli.CoreV1().Pods(namespace).GetLogs(pod, opt).Stream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newStream(ctx, body, bufferLines), nil
+}
+
+func newStream(ctx context.Context, body io.ReadCloser, bufferLines int) *Stream {
+	if bufferLines <= 0 {
+		bufferLines = defaultBufferLines
+	}
+	s := &Stream{
+		lines: make(chan string, bufferLines),
+		done:  make(chan struct{}),
+		body:  body,
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.close()
+		case <-s.done:
+		}
+	}()
+	go s.run(ctx)
+	return s
+}
+
+func (s *Stream) close() {
+	s.closeOnce.Do(func() {
+		_ = s.body.Close()
+	})
+}
+
+func (s *Stream) run(ctx context.Context) {
+	defer close(s.done)
+	defer close(s.lines)
+	defer s.close()
+
+	reader := bufio.NewReaderSize(s.body, maxLineBytes)
+	for {
+		line, err := reader.ReadSlice('\n')
+		if len(line) > 0 {
+			text := strings.TrimRight(string(line), "\r\n")
+			select {
+			case s.lines <- text:
+			case <-ctx.Done():
+				return
+			}
+		}
+		switch {
+		case err == nil, errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case errors.Is(err, io.EOF), ctx.Err() != nil:
+			// 正常结束或者客户端主动断开
+		default:
+			s.err = err
+		}
+		return
+	}
+}
+
+// Lines 返回日志行，日志流结束后关闭
+func (s *Stream) Lines() <-chan string {
+	return s.lines
+}
+
+// Err 日志流异常结束时的错误，需要在 Lines 关闭后调用
+func (s *Stream) Err() error {
+	<-s.done
+	return s.err
+}
+
+// Close 主动关闭日志流
+func (s *Stream) Close() {
+	s.close()
+}
@@ -0,0 +1,102 @@
+package log
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseOptions(t *testing.T) {
+	opt, err := ParseOptions(url.Values{
+		"follow":     {"true"},
+		"timestamps": {"1"},
+		"tailLines":  {"0"},
+		"limitBytes": {"1024"},
+		"sinceTime":  {"2024-01-02T03:04:05Z"},
+	})
+	if err != nil {
+		t.Fatalf("ParseOptions error: %v", err)
+	}
+	if !opt.Follow || !opt.Timestamps || opt.Previous {
+		t.Errorf("unexpected bool options %+v", opt)
+	}
+	if opt.TailLines == nil || *opt.TailLines != 0 || opt.LimitBytes == nil || *opt.LimitBytes != 1024 {
+		t.Errorf("unexpected numeric options %+v", opt)
+	}
+	if opt.SinceTime == nil || opt.SinceTime.Year() != 2024 {
+		t.Errorf("unexpected sinceTime %v", opt.SinceTime)
+	}
+
+	for _, query := range []url.Values{
+		{"follow": {"yes"}},
+		{"sinceSeconds": {"0"}},
+		{"sinceTime": {"yesterday"}},
+		{"sinceSeconds": {"10"}, "sinceTime": {"2024-01-02T03:04:05Z"}},
+	} {
+		if _, err := ParseOptions(query); err == nil {
+			t.Errorf("expected error for %v", query)
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	stream, err := NewStream(context.TODO(), clientset, "default", "web", nil, 0)
+	if err != nil {
+		t.Fatalf("NewStream error: %v", err)
+	}
+	lines := make([]string, 0)
+	for line := range stream.Lines() {
+		lines = append(lines, line)
+	}
+	if err := stream.Err(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	// fake clientset 返回固定的 "fake logs"
+	if len(lines) != 1 || lines[0] != "fake logs" {
+		t.Errorf("unexpected lines %v", lines)
+	}
+}
+
+func TestStreamSplitLongLines(t *testing.T) {
+	long := strings.Repeat("a", maxLineBytes+10)
+	stream := newStream(context.TODO(), io.NopCloser(strings.NewReader("first\r\n"+long+"\nlast")), 1)
+
+	lines := make([]string, 0)
+	for line := range stream.Lines() {
+		lines = append(lines, line)
+	}
+	if len(lines) != 4 || lines[0] != "first" || len(lines[1]) != maxLineBytes || len(lines[2]) != 10 || lines[3] != "last" {
+		t.Errorf("unexpected lines count %d", len(lines))
+	}
+}
+
+func TestStreamCancel(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer writer.Close()
+	ctx, cancel := context.WithCancel(context.TODO())
+	stream := newStream(ctx, reader, 1)
+
+	go func() {
+		_, _ = writer.Write([]byte("line\n"))
+	}()
+	if line := <-stream.Lines(); line != "line" {
+		t.Errorf("unexpected line %q", line)
+	}
+
+	// 取消后阻塞在读取上的日志流应当结束
+	cancel()
+	select {
+	case <-stream.done:
+	case <-time.After(time.Second):
+		t.Fatal("stream was not closed after cancel")
+	}
+	if err := stream.Err(); err != nil {
+		t.Errorf("cancel should not be reported as error, got %v", err)
+	}
+}
@@ -10,7 +10,6 @@ import (
 	"github.com/JLPAY/gwayne/pkg/slice"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"sort"
 )
 
 func ListKubePod(indexer *client.CacheFactory, namespace string, label map[string]string) ([]*corev1.Pod, error) {
@@ -26,7 +25,7 @@ func GetPodListPageByType(kubeClient client.ResourceHandler, namespace, resource
 	if err != nil {
 		return nil, err
 	}
-	return pageResult(relatePod, q), nil
+	return pageResult(relatePod, q)
 }
 
 func GetPodListByType(kubeClient client.ResourceHandler, namespace, resourceName string, resourceType api.ResourceName) ([]*corev1.Pod, error) {
@@ -123,16 +122,16 @@ func getRelatedPodByTypeAndIntermediateType(kubeClient client.ResourceHandler, n
 	return relatePod, nil
 }
 
-func pageResult(relatePod []*corev1.Pod, q *pagequery.QueryParam) *pagequery.Page {
+func pageResult(relatePod []*corev1.Pod, q *pagequery.QueryParam) (*pagequery.Page, error) {
 	commonObjs := make([]dataselector.DataCell, 0)
 	for _, pod := range relatePod {
 		commonObjs = append(commonObjs, ObjectCell(*pod))
 	}
 
-	sort.Slice(commonObjs, func(i, j int) bool {
-		return commonObjs[j].GetProperty(dataselector.NameProperty).
-			Compare(commonObjs[i].GetProperty(dataselector.NameProperty)) == -1
-	})
+	// 未指定排序字段时默认按名称降序
+	if q.Sortby == "" {
+		q.Sortby = "-" + string(dataselector.NameProperty)
+	}
 
 	return dataselector.DataSelectPage(commonObjs, q)
 }
@@ -29,13 +29,13 @@ func GetPage(kubeClient client.ResourceHandler, kind string, namespace string, q
 		commonObjs = append(commonObjs, objCell)
 	}
 
-	sort.Slice(commonObjs, func(i, j int) bool {
-		// 使用 NameProperty 对资源对象进行比较，排序方式是降序
-		return commonObjs[j].GetProperty(dataselector.NameProperty).Compare(commonObjs[i].GetProperty(dataselector.NameProperty)) == 1
-	})
+	// 未指定排序字段时默认按名称升序
+	if q.Sortby == "" {
+		q.Sortby = string(dataselector.NameProperty)
+	}
 
 	// 分页，返回一个 common.Page 类型的分页数据
-	return dataselector.DataSelectPage(commonObjs, q), nil
+	return dataselector.DataSelectPage(commonObjs, q)
 }
 
 // 根据资源名称（name）和对象（object）返回一个 DataCell 类型的值
@@ -0,0 +1,436 @@
+package relation
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/JLPAY/gwayne/pkg/kubernetes/client/api"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// RelationResolves Service 对应的 Endpoints
+	RelationResolves Relation = "resolves"
+	// RelationTargets Endpoints 中的地址指向 Pod
+	RelationTargets Relation = "targets"
+	// RelationReferences Pod 引用 ConfigMap、Secret 或 ServiceAccount
+	RelationReferences Relation = "references"
+	// RelationBinds PVC 绑定 PV
+	RelationBinds Relation = "binds"
+	// RelationUses PV 或 PVC 使用 StorageClass
+	RelationUses Relation = "uses"
+)
+
+// GraphNode 拓扑图的节点，ID 为 "<Kind>/<Name>"
+type GraphNode struct {
+	ID     string `json:"id"`
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Status string `json:"status,omitempty"`
+	// Missing 被引用的资源不存在
+	Missing bool `json:"missing,omitempty"`
+	// Broken 资源存在异常，例如 Service 没有选中任何 Pod，Reason 为原因
+	Broken bool   `json:"broken,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Edge 拓扑图的边，Broken 表示指向的资源不存在
+type Edge struct {
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	Relation Relation `json:"relation"`
+	Broken   bool     `json:"broken,omitempty"`
+}
+
+// Graph 命名空间的资源依赖图
+type Graph struct {
+	Namespace string       `json:"namespace"`
+	Nodes     []*GraphNode `json:"nodes"`
+	Edges     []*Edge      `json:"edges"`
+
+	index map[string]*GraphNode
+	edges map[string]bool
+}
+
+// graphObjects 在 Objects 之外拓扑图还需要的资源
+type graphObjects struct {
+	*Objects
+	Endpoints       []*corev1.Endpoints
+	ConfigMaps      []*corev1.ConfigMap
+	Secrets         []*corev1.Secret
+	ServiceAccounts []*corev1.ServiceAccount
+	Deployments     []*appsv1.Deployment
+	StatefulSets    []*appsv1.StatefulSet
+	PVs             []*corev1.PersistentVolume
+	StorageClasses  []*storagev1.StorageClass
+}
+
+// listOptional 获取失败时只记录日志，返回空列表
+func listOptional[T any](store Store, kind, namespace string) []*T {
+	result, err := list[T](store, kind, namespace)
+	if err != nil {
+		klog.Warningf("List %s in namespace %s error: %v", kind, namespace, err)
+		return nil
+	}
+	return result
+}
+
+func loadGraphObjects(store Store, namespace string) (*graphObjects, error) {
+	objs, err := LoadObjects(store, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return &graphObjects{
+		Objects:         objs,
+		Endpoints:       listOptional[corev1.Endpoints](store, api.ResourceNameEndpoint, namespace),
+		ConfigMaps:      listOptional[corev1.ConfigMap](store, api.ResourceNameConfigMap, namespace),
+		Secrets:         listOptional[corev1.Secret](store, api.ResourceNameSecret, namespace),
+		ServiceAccounts: listOptional[corev1.ServiceAccount](store, api.ResourceNameServiceAccount, namespace),
+		Deployments:     listOptional[appsv1.Deployment](store, api.ResourceNameDeployment, namespace),
+		StatefulSets:    listOptional[appsv1.StatefulSet](store, api.ResourceNameStatefulSet, namespace),
+		// PV 和 StorageClass 是集群级别的资源
+		PVs:            listOptional[corev1.PersistentVolume](store, api.ResourceNamePersistentVolume, ""),
+		StorageClasses: listOptional[storagev1.StorageClass](store, api.ResourceNameStorageClass, ""),
+	}, nil
+}
+
+// NamespaceGraph 根据缓存计算命名空间的资源依赖图：Ingress → Service → Endpoints → Pod，
+// Pod → ConfigMap/Secret/PVC/ServiceAccount，HPA → 目标工作负载，PVC → PV → StorageClass
+func NamespaceGraph(store Store, namespace string) (*Graph, error) {
+	objs, err := loadGraphObjects(store, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return buildGraph(namespace, objs), nil
+}
+
+func nodeID(kind, name string) string {
+	return kind + "/" + name
+}
+
+func (g *Graph) addNode(kind, name, status string) *GraphNode {
+	id := nodeID(kind, name)
+	if node, ok := g.index[id]; ok {
+		return node
+	}
+	node := &GraphNode{ID: id, Kind: kind, Name: name, Status: status}
+	g.index[id] = node
+	g.Nodes = append(g.Nodes, node)
+	return node
+}
+
+func (g *Graph) addEdge(from *GraphNode, kind, name string, relation Relation) {
+	to, ok := g.index[nodeID(kind, name)]
+	if !ok {
+		// 引用的资源不存在时添加一个缺失节点
+		to = g.addNode(kind, name, "")
+		to.Missing = true
+		to.Broken = true
+		to.Reason = fmt.Sprintf("%s %s not found", kind, name)
+	}
+	key := from.ID + "|" + to.ID + "|" + string(relation)
+	if g.edges[key] {
+		return
+	}
+	g.edges[key] = true
+	g.Edges = append(g.Edges, &Edge{From: from.ID, To: to.ID, Relation: relation, Broken: to.Missing})
+}
+
+func markBroken(node *GraphNode, reason string) {
+	node.Broken = true
+	node.Reason = reason
+}
+
+func buildGraph(namespace string, objs *graphObjects) *Graph {
+	g := &Graph{Namespace: namespace, index: map[string]*GraphNode{}, edges: map[string]bool{}}
+
+	// 被引用时才加入图中的资源，先记录下来用于判断是否缺失
+	configMaps := make(map[string]bool)
+	for _, cm := range objs.ConfigMaps {
+		configMaps[cm.Name] = true
+	}
+	secrets := make(map[string]bool)
+	for _, secret := range objs.Secrets {
+		secrets[secret.Name] = true
+	}
+	serviceAccounts := make(map[string]bool)
+	for _, sa := range objs.ServiceAccounts {
+		serviceAccounts[sa.Name] = true
+	}
+	pvs := make(map[string]*corev1.PersistentVolume)
+	for _, pv := range objs.PVs {
+		pvs[pv.Name] = pv
+	}
+	storageClasses := make(map[string]bool)
+	for _, sc := range objs.StorageClasses {
+		storageClasses[sc.Name] = true
+	}
+
+	for _, pod := range objs.Pods {
+		g.addNode("Pod", pod.Name, string(pod.Status.Phase))
+	}
+	for _, pvc := range objs.PVCs {
+		g.addNode("PersistentVolumeClaim", pvc.Name, string(pvc.Status.Phase))
+	}
+	for _, d := range objs.Deployments {
+		g.addNode("Deployment", d.Name, replicasStatus(d.Status.ReadyReplicas, d.Spec.Replicas))
+	}
+	for _, s := range objs.StatefulSets {
+		g.addNode("StatefulSet", s.Name, replicasStatus(s.Status.ReadyReplicas, s.Spec.Replicas))
+	}
+	for _, rs := range objs.ReplicaSets {
+		g.addNode("ReplicaSet", rs.Name, replicasStatus(rs.Status.ReadyReplicas, rs.Spec.Replicas))
+	}
+
+	// Service → Endpoints → Pod
+	endpoints := make(map[string]*corev1.Endpoints)
+	for _, ep := range objs.Endpoints {
+		endpoints[ep.Name] = ep
+	}
+	for _, svc := range objs.Services {
+		node := g.addNode("Service", svc.Name, string(svc.Spec.Type))
+		if len(svc.Spec.Selector) > 0 && !selectsAnyPod(svc, objs.Pods) {
+			markBroken(node, "selector matches no pods")
+		}
+		ep, ok := endpoints[svc.Name]
+		if !ok {
+			continue
+		}
+		ready, total := endpointCount(ep)
+		epNode := g.addNode("Endpoints", ep.Name, fmt.Sprintf("%d/%d", ready, total))
+		if len(svc.Spec.Selector) > 0 && total > 0 && ready == 0 {
+			markBroken(epNode, "no ready addresses")
+		}
+		g.addEdge(node, "Endpoints", ep.Name, RelationResolves)
+		for _, subset := range ep.Subsets {
+			for _, addresses := range [][]corev1.EndpointAddress{subset.Addresses, subset.NotReadyAddresses} {
+				for _, address := range addresses {
+					if address.TargetRef != nil && address.TargetRef.Kind == "Pod" {
+						g.addEdge(epNode, "Pod", address.TargetRef.Name, RelationTargets)
+					}
+				}
+			}
+		}
+	}
+
+	// Ingress → Service
+	for _, ing := range objs.Ingresses {
+		node := g.addNode("Ingress", ing.Name, "")
+		for _, name := range IngressServices(ing) {
+			g.addEdge(node, "Service", name, RelationRoutes)
+		}
+	}
+
+	// Pod → ConfigMap/Secret/PVC/ServiceAccount
+	for _, pod := range objs.Pods {
+		node := g.index[nodeID("Pod", pod.Name)]
+		for _, ref := range podReferences(&pod.Spec) {
+			exists := true
+			switch ref.kind {
+			case "ConfigMap":
+				exists = configMaps[ref.name]
+			case "Secret":
+				exists = secrets[ref.name]
+			case "ServiceAccount":
+				exists = serviceAccounts[ref.name]
+			}
+			// 可选的引用不存在时不影响 Pod 运行，不在图中显示
+			if !exists && ref.optional {
+				continue
+			}
+			if exists {
+				g.addNode(ref.kind, ref.name, "")
+			}
+			g.addEdge(node, ref.kind, ref.name, ref.relation)
+		}
+	}
+
+	// HPA → 目标工作负载，只校验已加载的资源类型，其他类型（例如 CRD）直接添加节点
+	for _, hpa := range objs.HPAs {
+		node := g.addNode("HorizontalPodAutoscaler", hpa.Name, fmt.Sprintf("%d/%d", hpa.Status.CurrentReplicas, hpa.Spec.MaxReplicas))
+		target := hpa.Spec.ScaleTargetRef
+		switch target.Kind {
+		case "Deployment", "StatefulSet", "ReplicaSet":
+		default:
+			g.addNode(target.Kind, target.Name, "")
+		}
+		g.addEdge(node, target.Kind, target.Name, RelationScales)
+	}
+
+	// PVC → PV → StorageClass
+	for _, pvc := range objs.PVCs {
+		node := g.index[nodeID("PersistentVolumeClaim", pvc.Name)]
+		if pvc.Spec.VolumeName != "" {
+			if pv, ok := pvs[pvc.Spec.VolumeName]; ok {
+				pvNode := g.addNode("PersistentVolume", pv.Name, string(pv.Status.Phase))
+				if pv.Spec.StorageClassName != "" {
+					if storageClasses[pv.Spec.StorageClassName] {
+						g.addNode("StorageClass", pv.Spec.StorageClassName, "")
+					}
+					g.addEdge(pvNode, "StorageClass", pv.Spec.StorageClassName, RelationUses)
+				}
+			}
+			g.addEdge(node, "PersistentVolume", pvc.Spec.VolumeName, RelationBinds)
+			continue
+		}
+		// 未绑定的 PVC 指向申请使用的 StorageClass
+		if name := pvc.Spec.StorageClassName; name != nil && *name != "" {
+			if storageClasses[*name] {
+				g.addNode("StorageClass", *name, "")
+			}
+			g.addEdge(node, "StorageClass", *name, RelationUses)
+		}
+	}
+
+	sort.SliceStable(g.Nodes, func(i, j int) bool {
+		return g.Nodes[i].ID < g.Nodes[j].ID
+	})
+	sort.SliceStable(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+	return g
+}
+
+func selectsAnyPod(svc *corev1.Service, pods []*corev1.Pod) bool {
+	selector := labels.SelectorFromSet(svc.Spec.Selector)
+	for _, pod := range pods {
+		if selector.Matches(labels.Set(pod.Labels)) {
+			return true
+		}
+	}
+	return false
+}
+
+func endpointCount(ep *corev1.Endpoints) (ready, total int) {
+	for _, subset := range ep.Subsets {
+		ready += len(subset.Addresses)
+		total += len(subset.Addresses) + len(subset.NotReadyAddresses)
+	}
+	return ready, total
+}
+
+type reference struct {
+	kind     string
+	name     string
+	relation Relation
+	optional bool
+}
+
+func isOptional(optional *bool) bool {
+	return optional != nil && *optional
+}
+
+// podReferences 返回 Pod 引用的 ConfigMap、Secret、PVC 和 ServiceAccount
+func podReferences(spec *corev1.PodSpec) []reference {
+	refs := make([]reference, 0)
+	configMap := func(name string, optional bool) {
+		refs = append(refs, reference{kind: "ConfigMap", name: name, relation: RelationReferences, optional: optional})
+	}
+	secret := func(name string, optional bool) {
+		refs = append(refs, reference{kind: "Secret", name: name, relation: RelationReferences, optional: optional})
+	}
+
+	for _, volume := range spec.Volumes {
+		switch {
+		case volume.ConfigMap != nil:
+			configMap(volume.ConfigMap.Name, isOptional(volume.ConfigMap.Optional))
+		case volume.Secret != nil:
+			secret(volume.Secret.SecretName, isOptional(volume.Secret.Optional))
+		case volume.PersistentVolumeClaim != nil:
+			refs = append(refs, reference{kind: "PersistentVolumeClaim", name: volume.PersistentVolumeClaim.ClaimName, relation: RelationMounts})
+		case volume.Projected != nil:
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					configMap(source.ConfigMap.Name, isOptional(source.ConfigMap.Optional))
+				}
+				if source.Secret != nil {
+					secret(source.Secret.Name, isOptional(source.Secret.Optional))
+				}
+			}
+		}
+	}
+
+	containers := make([]corev1.Container, 0, len(spec.InitContainers)+len(spec.Containers))
+	containers = append(containers, spec.InitContainers...)
+	containers = append(containers, spec.Containers...)
+	for _, container := range containers {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.ConfigMapRef != nil {
+				configMap(envFrom.ConfigMapRef.Name, isOptional(envFrom.ConfigMapRef.Optional))
+			}
+			if envFrom.SecretRef != nil {
+				secret(envFrom.SecretRef.Name, isOptional(envFrom.SecretRef.Optional))
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+				configMap(ref.Name, isOptional(ref.Optional))
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+				secret(ref.Name, isOptional(ref.Optional))
+			}
+		}
+	}
+
+	for _, pullSecret := range spec.ImagePullSecrets {
+		// 拉取镜像的 Secret 不存在时 kubelet 只记录事件，按可选处理
+		secret(pullSecret.Name, true)
+	}
+
+	serviceAccount := spec.ServiceAccountName
+	if serviceAccount == "" {
+		serviceAccount = "default"
+	}
+	refs = append(refs, reference{kind: "ServiceAccount", name: serviceAccount, relation: RelationReferences})
+	return refs
+}
+
+// DOT 将拓扑图转换为 Graphviz DOT 格式，异常的节点和边标记为红色，缺失的节点使用虚线
+func (g *Graph) DOT() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", strconv.Quote(g.Namespace))
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, node := range g.Nodes {
+		attrs := []string{"label=" + strconv.Quote(dotLabel(node))}
+		if node.Broken {
+			attrs = append(attrs, "color=red")
+		}
+		if node.Missing {
+			attrs = append(attrs, "style=dashed")
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", strconv.Quote(node.ID), strings.Join(attrs, ", "))
+	}
+	for _, edge := range g.Edges {
+		attrs := []string{"label=" + strconv.Quote(string(edge.Relation))}
+		if edge.Broken {
+			attrs = append(attrs, "color=red", "style=dashed")
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", strconv.Quote(edge.From), strconv.Quote(edge.To), strings.Join(attrs, ", "))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func dotLabel(node *GraphNode) string {
+	label := node.Kind + "\n" + node.Name
+	if node.Status != "" {
+		label += "\n" + node.Status
+	}
+	if node.Reason != "" {
+		label += "\n" + node.Reason
+	}
+	return label
+}
@@ -0,0 +1,427 @@
+package relation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/JLPAY/gwayne/pkg/kubernetes/client/api"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+var ErrUnsupportedKind = errors.New("unsupported kind, expected deployments, statefulsets, daemonsets, replicasets, jobs or cronjobs")
+
+// Relation 子节点与父节点之间的关系
+type Relation string
+
+const (
+	// RelationOwns 父节点通过 ownerReferences 管理子节点
+	RelationOwns Relation = "owns"
+	// RelationSelects Service 通过标签选择工作负载的 Pod
+	RelationSelects Relation = "selects"
+	// RelationRoutes Ingress 将流量转发到 Service
+	RelationRoutes Relation = "routes"
+	// RelationScales HPA 的 scaleTargetRef 指向工作负载
+	RelationScales Relation = "scales"
+	// RelationMounts 工作负载挂载 PVC
+	RelationMounts Relation = "mounts"
+)
+
+// Node 资源关系树的节点
+type Node struct {
+	Kind     string   `json:"kind"`
+	Name     string   `json:"name"`
+	Relation Relation `json:"relation,omitempty"`
+	Status   string   `json:"status,omitempty"`
+	// Missing 被引用的资源不存在，例如 Pod 模板中挂载了不存在的 PVC
+	Missing  bool    `json:"missing,omitempty"`
+	Children []*Node `json:"children,omitempty"`
+}
+
+// Store 按资源名称获取资源，client.ResourceHandler 满足该接口，数据来自 informer 缓存
+type Store interface {
+	Get(kind string, namespace string, name string) (runtime.Object, error)
+	List(kind string, namespace string, labelSelector string) ([]runtime.Object, error)
+}
+
+// Objects 命名空间下用于计算关系的资源
+type Objects struct {
+	ReplicaSets []*appsv1.ReplicaSet
+	Jobs        []*batchv1.Job
+	Pods        []*corev1.Pod
+	Services    []*corev1.Service
+	Ingresses   []*networkingv1.Ingress
+	HPAs        []*autoscalingv2.HorizontalPodAutoscaler
+	PVCs        []*corev1.PersistentVolumeClaim
+}
+
+// decode 将缓存中的对象转换为指定类型，集群首选版本不同（例如 autoscaling/v1 的 HPA）时通过 JSON 转换
+func decode[T any](obj runtime.Object) (*T, error) {
+	if typed, ok := any(obj).(*T); ok {
+		return typed, nil
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	result := new(T)
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func list[T any](store Store, kind, namespace string) ([]*T, error) {
+	objs, err := store.List(kind, namespace, labels.Everything().String())
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*T, 0, len(objs))
+	for _, obj := range objs {
+		typed, err := decode[T](obj)
+		if err != nil {
+			return nil, fmt.Errorf("convert %s object error: %v", kind, err)
+		}
+		result = append(result, typed)
+	}
+	return result, nil
+}
+
+// LoadObjects 从缓存中加载命名空间下的资源，Pod 和 Service 之外的资源获取失败时（例如集群未开启对应 API）只记录日志
+func LoadObjects(store Store, namespace string) (*Objects, error) {
+	var err error
+	objs := &Objects{}
+	if objs.Pods, err = list[corev1.Pod](store, api.ResourceNamePod, namespace); err != nil {
+		return nil, err
+	}
+	if objs.Services, err = list[corev1.Service](store, api.ResourceNameService, namespace); err != nil {
+		return nil, err
+	}
+	if objs.ReplicaSets, err = list[appsv1.ReplicaSet](store, api.ResourceNameReplicaSet, namespace); err != nil {
+		klog.Warningf("List replicasets in namespace %s error: %v", namespace, err)
+	}
+	if objs.Jobs, err = list[batchv1.Job](store, api.ResourceNameJob, namespace); err != nil {
+		klog.Warningf("List jobs in namespace %s error: %v", namespace, err)
+	}
+	if objs.Ingresses, err = list[networkingv1.Ingress](store, api.ResourceNameIngress, namespace); err != nil {
+		klog.Warningf("List ingresses in namespace %s error: %v", namespace, err)
+	}
+	if objs.HPAs, err = list[autoscalingv2.HorizontalPodAutoscaler](store, api.ResourceNameHorizontalPodAutoscaler, namespace); err != nil {
+		klog.Warningf("List horizontalpodautoscalers in namespace %s error: %v", namespace, err)
+	}
+	if objs.PVCs, err = list[corev1.PersistentVolumeClaim](store, api.ResourceNamePersistentVolumeClaim, namespace); err != nil {
+		klog.Warningf("List persistentvolumeclaims in namespace %s error: %v", namespace, err)
+	}
+	return objs, nil
+}
+
+// workload 关系计算需要的工作负载信息
+type workload struct {
+	kind     string
+	name     string
+	uid      types.UID
+	status   string
+	template corev1.PodTemplateSpec
+	// StatefulSet 的 volumeClaimTemplates 名称
+	claimTemplates []string
+}
+
+// SupportedKind 是否支持计算资源关系树
+func SupportedKind(kind string) bool {
+	switch kind {
+	case api.ResourceNameDeployment, api.ResourceNameStatefulSet, api.ResourceNameDaemonSet,
+		api.ResourceNameReplicaSet, api.ResourceNameJob, api.ResourceNameCronJob:
+		return true
+	}
+	return false
+}
+
+func getWorkload(store Store, kind, namespace, name string) (*workload, error) {
+	if !SupportedKind(kind) {
+		return nil, ErrUnsupportedKind
+	}
+	obj, err := store.Get(kind, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case api.ResourceNameDeployment:
+		d, err := decode[appsv1.Deployment](obj)
+		if err != nil {
+			return nil, err
+		}
+		return &workload{kind: "Deployment", name: d.Name, uid: d.UID, template: d.Spec.Template,
+			status: replicasStatus(d.Status.ReadyReplicas, d.Spec.Replicas)}, nil
+	case api.ResourceNameStatefulSet:
+		s, err := decode[appsv1.StatefulSet](obj)
+		if err != nil {
+			return nil, err
+		}
+		w := &workload{kind: "StatefulSet", name: s.Name, uid: s.UID, template: s.Spec.Template,
+			status: replicasStatus(s.Status.ReadyReplicas, s.Spec.Replicas)}
+		for _, claim := range s.Spec.VolumeClaimTemplates {
+			w.claimTemplates = append(w.claimTemplates, claim.Name)
+		}
+		return w, nil
+	case api.ResourceNameDaemonSet:
+		d, err := decode[appsv1.DaemonSet](obj)
+		if err != nil {
+			return nil, err
+		}
+		return &workload{kind: "DaemonSet", name: d.Name, uid: d.UID, template: d.Spec.Template,
+			status: fmt.Sprintf("%d/%d", d.Status.NumberReady, d.Status.DesiredNumberScheduled)}, nil
+	case api.ResourceNameReplicaSet:
+		r, err := decode[appsv1.ReplicaSet](obj)
+		if err != nil {
+			return nil, err
+		}
+		return &workload{kind: "ReplicaSet", name: r.Name, uid: r.UID, template: r.Spec.Template,
+			status: replicasStatus(r.Status.ReadyReplicas, r.Spec.Replicas)}, nil
+	case api.ResourceNameJob:
+		j, err := decode[batchv1.Job](obj)
+		if err != nil {
+			return nil, err
+		}
+		return &workload{kind: "Job", name: j.Name, uid: j.UID, template: j.Spec.Template, status: jobStatus(j)}, nil
+	case api.ResourceNameCronJob:
+		c, err := decode[batchv1.CronJob](obj)
+		if err != nil {
+			return nil, err
+		}
+		status := "Active"
+		if c.Spec.Suspend != nil && *c.Spec.Suspend {
+			status = "Suspended"
+		}
+		return &workload{kind: "CronJob", name: c.Name, uid: c.UID, template: c.Spec.JobTemplate.Spec.Template, status: status}, nil
+	}
+	return nil, ErrUnsupportedKind
+}
+
+// Tree 计算工作负载的资源关系树：通过 ownerReferences 管理的 ReplicaSet/Job/Pod，
+// 以及选择其 Pod 的 Service（含转发到该 Service 的 Ingress）、指向它的 HPA 和挂载的 PVC
+func Tree(store Store, kind, namespace, name string) (*Node, error) {
+	w, err := getWorkload(store, kind, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	objs, err := LoadObjects(store, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return buildTree(w, objs), nil
+}
+
+func buildTree(w *workload, objs *Objects) *Node {
+	root := &Node{Kind: w.kind, Name: w.name, Status: w.status}
+
+	switch w.kind {
+	case "Deployment":
+		for _, rs := range objs.ReplicaSets {
+			if ownedBy(rs.OwnerReferences, w.uid) {
+				node := &Node{Kind: "ReplicaSet", Name: rs.Name, Relation: RelationOwns,
+					Status: replicasStatus(rs.Status.ReadyReplicas, rs.Spec.Replicas)}
+				node.Children = podNodes(objs.Pods, rs.UID)
+				root.Children = append(root.Children, node)
+			}
+		}
+	case "CronJob":
+		for _, job := range objs.Jobs {
+			if ownedBy(job.OwnerReferences, w.uid) {
+				node := &Node{Kind: "Job", Name: job.Name, Relation: RelationOwns, Status: jobStatus(job)}
+				node.Children = podNodes(objs.Pods, job.UID)
+				root.Children = append(root.Children, node)
+			}
+		}
+	default:
+		root.Children = podNodes(objs.Pods, w.uid)
+	}
+	sortNodes(root.Children)
+
+	root.Children = append(root.Children, serviceNodes(objs, w.template.Labels)...)
+	root.Children = append(root.Children, hpaNodes(objs.HPAs, w.kind, w.name)...)
+	root.Children = append(root.Children, pvcNodes(objs, w)...)
+	return root
+}
+
+func ownedBy(refs []metav1.OwnerReference, uid types.UID) bool {
+	for _, ref := range refs {
+		if ref.UID == uid {
+			return true
+		}
+	}
+	return false
+}
+
+func podNodes(pods []*corev1.Pod, owner types.UID) []*Node {
+	nodes := make([]*Node, 0)
+	for _, pod := range pods {
+		if ownedBy(pod.OwnerReferences, owner) {
+			nodes = append(nodes, &Node{Kind: "Pod", Name: pod.Name, Relation: RelationOwns, Status: string(pod.Status.Phase)})
+		}
+	}
+	sortNodes(nodes)
+	return nodes
+}
+
+// serviceNodes 返回选择器匹配 Pod 模板标签的 Service 及转发到它们的 Ingress
+func serviceNodes(objs *Objects, podLabels map[string]string) []*Node {
+	nodes := make([]*Node, 0)
+	for _, svc := range objs.Services {
+		if !Selects(svc, podLabels) {
+			continue
+		}
+		node := &Node{Kind: "Service", Name: svc.Name, Relation: RelationSelects, Status: string(svc.Spec.Type)}
+		for _, ing := range objs.Ingresses {
+			if routesTo(ing, svc.Name) {
+				node.Children = append(node.Children, &Node{Kind: "Ingress", Name: ing.Name, Relation: RelationRoutes})
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	sortNodes(nodes)
+	return nodes
+}
+
+// Selects Service 的选择器是否匹配给定的 Pod 标签，没有选择器的 Service 不选择任何 Pod
+func Selects(svc *corev1.Service, podLabels map[string]string) bool {
+	if len(svc.Spec.Selector) == 0 {
+		return false
+	}
+	return labels.SelectorFromSet(svc.Spec.Selector).Matches(labels.Set(podLabels))
+}
+
+// IngressServices 返回 Ingress 引用的所有 Service 名称（包括默认后端）
+func IngressServices(ing *networkingv1.Ingress) []string {
+	names := make([]string, 0)
+	if backend := ing.Spec.DefaultBackend; backend != nil && backend.Service != nil {
+		names = append(names, backend.Service.Name)
+	}
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.Service != nil {
+				names = append(names, path.Backend.Service.Name)
+			}
+		}
+	}
+	return names
+}
+
+func routesTo(ing *networkingv1.Ingress, service string) bool {
+	for _, name := range IngressServices(ing) {
+		if name == service {
+			return true
+		}
+	}
+	return false
+}
+
+func hpaNodes(hpas []*autoscalingv2.HorizontalPodAutoscaler, kind, name string) []*Node {
+	nodes := make([]*Node, 0)
+	for _, hpa := range hpas {
+		if hpa.Spec.ScaleTargetRef.Kind == kind && hpa.Spec.ScaleTargetRef.Name == name {
+			nodes = append(nodes, &Node{Kind: "HorizontalPodAutoscaler", Name: hpa.Name, Relation: RelationScales,
+				Status: fmt.Sprintf("%d/%d", hpa.Status.CurrentReplicas, hpa.Spec.MaxReplicas)})
+		}
+	}
+	sortNodes(nodes)
+	return nodes
+}
+
+// pvcNodes 返回 Pod 模板和 Pod 中挂载的 PVC，以及 StatefulSet volumeClaimTemplates 创建的 PVC
+func pvcNodes(objs *Objects, w *workload) []*Node {
+	claims := make(map[string]*corev1.PersistentVolumeClaim)
+	for _, pvc := range objs.PVCs {
+		claims[pvc.Name] = pvc
+	}
+
+	referenced := make(map[string]bool)
+	for _, name := range ClaimNames(&w.template.Spec) {
+		referenced[name] = true
+	}
+	for _, pod := range objs.Pods {
+		if ownedBy(pod.OwnerReferences, w.uid) {
+			for _, name := range ClaimNames(&pod.Spec) {
+				referenced[name] = true
+			}
+		}
+	}
+	for _, tmpl := range w.claimTemplates {
+		// StatefulSet 创建的 PVC 名称为 <模板名>-<StatefulSet 名>-<序号>
+		pattern := regexp.MustCompile("^" + regexp.QuoteMeta(tmpl+"-"+w.name+"-") + "[0-9]+$")
+		for name := range claims {
+			if pattern.MatchString(name) {
+				referenced[name] = true
+			}
+		}
+	}
+
+	nodes := make([]*Node, 0, len(referenced))
+	for name := range referenced {
+		node := &Node{Kind: "PersistentVolumeClaim", Name: name, Relation: RelationMounts}
+		if pvc, ok := claims[name]; ok {
+			node.Status = string(pvc.Status.Phase)
+		} else {
+			node.Missing = true
+		}
+		nodes = append(nodes, node)
+	}
+	sortNodes(nodes)
+	return nodes
+}
+
+// ClaimNames 返回 Pod 中通过 persistentVolumeClaim 挂载的 PVC 名称
+func ClaimNames(spec *corev1.PodSpec) []string {
+	names := make([]string, 0)
+	for _, volume := range spec.Volumes {
+		if volume.PersistentVolumeClaim != nil {
+			names = append(names, volume.PersistentVolumeClaim.ClaimName)
+		}
+	}
+	return names
+}
+
+func replicasStatus(ready int32, desired *int32) string {
+	want := int32(1)
+	if desired != nil {
+		want = *desired
+	}
+	return fmt.Sprintf("%d/%d", ready, want)
+}
+
+func jobStatus(job *batchv1.Job) string {
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batchv1.JobComplete:
+			return "Complete"
+		case batchv1.JobFailed:
+			return "Failed"
+		}
+	}
+	if job.Spec.Suspend != nil && *job.Spec.Suspend {
+		return "Suspended"
+	}
+	return "Running"
+}
+
+func sortNodes(nodes []*Node) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+}
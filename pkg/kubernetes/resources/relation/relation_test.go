@@ -0,0 +1,210 @@
+package relation
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type fakeStore map[string][]runtime.Object
+
+func (s fakeStore) Get(kind string, namespace string, name string) (runtime.Object, error) {
+	for _, obj := range s[kind] {
+		if obj.(metav1.Object).GetName() == name {
+			return obj, nil
+		}
+	}
+	return nil, fmt.Errorf("%s %s not found", kind, name)
+}
+
+func (s fakeStore) List(kind string, namespace string, labelSelector string) ([]runtime.Object, error) {
+	return s[kind], nil
+}
+
+func owner(kind, name string, uid types.UID) []metav1.OwnerReference {
+	isController := true
+	return []metav1.OwnerReference{{Kind: kind, Name: name, UID: uid, Controller: &isController}}
+}
+
+func testStore() fakeStore {
+	replicas := int32(2)
+	podLabels := map[string]string{"app": "web"}
+	claim := corev1.Volume{Name: "data", VolumeSource: corev1.VolumeSource{
+		PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "web-data"}}}
+	missing := corev1.Volume{Name: "cache", VolumeSource: corev1.VolumeSource{
+		PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "web-cache"}}}
+
+	return fakeStore{
+		"deployments": {&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", UID: "deploy-uid"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: podLabels},
+					Spec:       corev1.PodSpec{Volumes: []corev1.Volume{claim, missing}},
+				},
+			},
+		}},
+		"replicasets": {
+			&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "web-1", UID: "rs-1", OwnerReferences: owner("Deployment", "web", "deploy-uid")}},
+			&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "other-1", UID: "rs-2", OwnerReferences: owner("Deployment", "other", "other-uid")}},
+		},
+		"pods": {
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1-a", OwnerReferences: owner("ReplicaSet", "web-1", "rs-1")},
+				Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other-1-a", OwnerReferences: owner("ReplicaSet", "other-1", "rs-2")}},
+		},
+		"services": {
+			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web"}, Spec: corev1.ServiceSpec{Selector: podLabels}},
+			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "external"}},
+		},
+		"ingresses": {&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "web"},
+			Spec: networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{
+				IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{Backend: networkingv1.IngressBackend{
+						Service: &networkingv1.IngressServiceBackend{Name: "web"}}}},
+				}},
+			}}},
+		}},
+		// 集群首选 autoscaling/v1 时缓存中是 v1 对象
+		"horizontalpodautoscalers": {&autoscalingv1.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: "web"},
+			Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{Kind: "Deployment", Name: "web"},
+				MaxReplicas:    5,
+			},
+		}},
+		"persistentvolumeclaims": {&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-data"},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
+		}},
+	}
+}
+
+func TestTree(t *testing.T) {
+	root, err := Tree(testStore(), "deployments", "default", "web")
+	if err != nil {
+		t.Fatalf("Tree error: %v", err)
+	}
+
+	children := make(map[string]*Node)
+	for _, child := range root.Children {
+		children[child.Kind+"/"+child.Name] = child
+	}
+	if len(root.Children) != 5 {
+		t.Fatalf("expected 5 children, got %d: %v", len(root.Children), children)
+	}
+
+	rs := children["ReplicaSet/web-1"]
+	if rs == nil || len(rs.Children) != 1 || rs.Children[0].Name != "web-1-a" || rs.Children[0].Status != "Running" {
+		t.Errorf("unexpected replicaset node %+v", rs)
+	}
+	svc := children["Service/web"]
+	if svc == nil || svc.Relation != RelationSelects || len(svc.Children) != 1 || svc.Children[0].Kind != "Ingress" {
+		t.Errorf("unexpected service node %+v", svc)
+	}
+	if hpa := children["HorizontalPodAutoscaler/web"]; hpa == nil || hpa.Relation != RelationScales {
+		t.Errorf("unexpected hpa node %+v", hpa)
+	}
+	if pvc := children["PersistentVolumeClaim/web-data"]; pvc == nil || pvc.Missing || pvc.Status != "Bound" {
+		t.Errorf("unexpected pvc node %+v", pvc)
+	}
+	if pvc := children["PersistentVolumeClaim/web-cache"]; pvc == nil || !pvc.Missing {
+		t.Errorf("missing pvc should be flagged, got %+v", pvc)
+	}
+}
+
+func TestTreeUnsupportedKind(t *testing.T) {
+	if _, err := Tree(testStore(), "services", "default", "web"); err != ErrUnsupportedKind {
+		t.Errorf("expected ErrUnsupportedKind, got %v", err)
+	}
+}
+
+func TestNamespaceGraph(t *testing.T) {
+	store := testStore()
+	store["services"] = append(store["services"],
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "orphan"}, Spec: corev1.ServiceSpec{Selector: map[string]string{"app": "none"}}})
+	store["endpoints"] = []runtime.Object{&corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "web"},
+		Subsets: []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{
+			{IP: "10.0.0.1", TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "web-1-a"}}}}},
+	}}
+	pod := store["pods"][0].(*corev1.Pod)
+	pod.Labels = map[string]string{"app": "web"}
+	pod.Spec.Volumes = []corev1.Volume{
+		{Name: "data", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "web-data"}}},
+		{Name: "tls", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "web-tls"}}},
+	}
+	optional := true
+	pod.Spec.Containers = []corev1.Container{{Name: "web", EnvFrom: []corev1.EnvFromSource{
+		{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "web-config"}}},
+		{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "optional"}, Optional: &optional}},
+	}}}
+	store["configmaps"] = []runtime.Object{&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "web-config"}}}
+	store["serviceaccounts"] = []runtime.Object{&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "default"}}}
+	pvc := store["persistentvolumeclaims"][0].(*corev1.PersistentVolumeClaim)
+	pvc.Spec.VolumeName = "pv-1"
+	store["persistentvolumes"] = []runtime.Object{&corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-1"},
+		Spec:       corev1.PersistentVolumeSpec{StorageClassName: "fast"},
+	}}
+
+	graph, err := NamespaceGraph(store, "default")
+	if err != nil {
+		t.Fatalf("NamespaceGraph error: %v", err)
+	}
+
+	nodes := make(map[string]*GraphNode)
+	for _, node := range graph.Nodes {
+		nodes[node.ID] = node
+	}
+	edges := make(map[string]*Edge)
+	for _, edge := range graph.Edges {
+		edges[edge.From+" -> "+edge.To] = edge
+	}
+
+	for _, key := range []string{
+		"Ingress/web -> Service/web",
+		"Service/web -> Endpoints/web",
+		"Endpoints/web -> Pod/web-1-a",
+		"Pod/web-1-a -> ConfigMap/web-config",
+		"Pod/web-1-a -> ServiceAccount/default",
+		"Pod/web-1-a -> PersistentVolumeClaim/web-data",
+		"HorizontalPodAutoscaler/web -> Deployment/web",
+		"PersistentVolumeClaim/web-data -> PersistentVolume/pv-1",
+		"PersistentVolume/pv-1 -> StorageClass/fast",
+	} {
+		if edge, ok := edges[key]; !ok {
+			t.Errorf("expected edge %s", key)
+		} else if edge.Broken && key != "PersistentVolume/pv-1 -> StorageClass/fast" {
+			t.Errorf("edge %s should not be broken", key)
+		}
+	}
+	if edge := edges["Pod/web-1-a -> Secret/web-tls"]; edge == nil || !edge.Broken || !nodes["Secret/web-tls"].Missing {
+		t.Errorf("missing secret should be a broken link, got %+v", edge)
+	}
+	if _, ok := nodes["Secret/optional"]; ok {
+		t.Errorf("missing optional secret should be ignored")
+	}
+	if node := nodes["Service/orphan"]; node == nil || !node.Broken {
+		t.Errorf("service selecting no pods should be broken, got %+v", node)
+	}
+	// StorageClass fast 不存在
+	if node := nodes["StorageClass/fast"]; node == nil || !node.Missing {
+		t.Errorf("missing storageclass should be flagged, got %+v", node)
+	}
+
+	dot := graph.DOT()
+	if !strings.HasPrefix(dot, `digraph "default" {`) || !strings.Contains(dot, `"Service/web" -> "Endpoints/web" [label="resolves"];`) {
+		t.Errorf("unexpected dot output:\n%s", dot)
+	}
+}
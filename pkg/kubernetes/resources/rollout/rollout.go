@@ -0,0 +1,317 @@
+package rollout
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/diff"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/polymorphichelpers"
+)
+
+const (
+	// RevisionAnnotation Deployment 及其 ReplicaSet 上记录版本号的注解
+	RevisionAnnotation = "deployment.kubernetes.io/revision"
+	// 发布超过 progressDeadlineSeconds 仍未完成时 Progressing 条件的 reason
+	timedOutReason = "ProgressDeadlineExceeded"
+)
+
+var (
+	ErrUnsupportedKind  = errors.New("rollout is only supported for deployments, statefulsets and daemonsets")
+	ErrRevisionNotFound = errors.New("revision not found")
+	ErrPauseUnsupported = errors.New("only deployments support pause and resume")
+	ErrAlreadyPaused    = errors.New("deployment is already paused")
+	ErrNotPaused        = errors.New("deployment is not paused")
+)
+
+// 支持发布操作的资源与 Kind 的对应关系
+var kinds = map[string]string{
+	"deployments":  "Deployment",
+	"statefulsets": "StatefulSet",
+	"daemonsets":   "DaemonSet",
+}
+
+// Revision 工作负载的一个历史版本，Deployment 对应 ReplicaSet，StatefulSet/DaemonSet 对应 ControllerRevision
+type Revision struct {
+	Revision          int64                   `json:"revision"`
+	Name              string                  `json:"name"`
+	ChangeCause       string                  `json:"changeCause,omitempty"`
+	CreationTimestamp metav1.Time             `json:"creationTimestamp"`
+	Current           bool                    `json:"current"`
+	Template          *corev1.PodTemplateSpec `json:"template,omitempty"`
+}
+
+// Status 发布状态
+type Status struct {
+	Revision          int64 `json:"revision,omitempty"`
+	Replicas          int32 `json:"replicas"`
+	UpdatedReplicas   int32 `json:"updatedReplicas"`
+	ReadyReplicas     int32 `json:"readyReplicas"`
+	AvailableReplicas int32 `json:"availableReplicas"`
+	Paused            bool  `json:"paused"`
+	Done              bool  `json:"done"`
+	// 超过 progressDeadlineSeconds 仍未完成
+	Stuck   bool   `json:"stuck"`
+	Message string `json:"message"`
+}
+
+func groupKind(kind string) (schema.GroupKind, error) {
+	k, ok := kinds[kind]
+	if !ok {
+		return schema.GroupKind{}, ErrUnsupportedKind
+	}
+	return schema.GroupKind{Group: appsv1.GroupName, Kind: k}, nil
+}
+
+// getWorkload 获取工作负载对象
+func getWorkload(ctx context.Context, clientset kubernetes.Interface, kind, namespace, name string) (runtime.Object, error) {
+	switch kind {
+	case "deployments":
+		return clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "statefulsets":
+		return clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "daemonsets":
+		return clientset.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	}
+	return nil, ErrUnsupportedKind
+}
+
+// History 返回工作负载的历史版本，按版本号升序排列
+func History(ctx context.Context, clientset kubernetes.Interface, kind, namespace, name string) ([]Revision, error) {
+	obj, err := getWorkload(ctx, clientset, kind, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var revisions []Revision
+	switch workload := obj.(type) {
+	case *appsv1.Deployment:
+		revisions, err = deploymentHistory(ctx, clientset, workload)
+	case *appsv1.StatefulSet:
+		revisions, err = controllerRevisionHistory(ctx, clientset, workload, workload.Spec.Selector, workload.Status.UpdateRevision)
+	case *appsv1.DaemonSet:
+		revisions, err = controllerRevisionHistory(ctx, clientset, workload, workload.Spec.Selector, "")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].Revision < revisions[j].Revision })
+	// DaemonSet 没有记录当前版本，最新的版本即为当前版本
+	if kind == "daemonsets" && len(revisions) > 0 {
+		revisions[len(revisions)-1].Current = true
+	}
+	return revisions, nil
+}
+
+func deploymentHistory(ctx context.Context, clientset kubernetes.Interface, deployment *appsv1.Deployment) ([]Revision, error) {
+	viewer, err := polymorphichelpers.HistoryViewerFor(schema.GroupKind{Group: appsv1.GroupName, Kind: "Deployment"}, clientset)
+	if err != nil {
+		return nil, err
+	}
+	history, err := viewer.GetHistory(deployment.Namespace, deployment.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	current := deployment.Annotations[RevisionAnnotation]
+	revisions := make([]Revision, 0, len(history))
+	for revision, obj := range history {
+		rs, ok := obj.(*appsv1.ReplicaSet)
+		if !ok {
+			continue
+		}
+		template := rs.Spec.Template.DeepCopy()
+		// pod-template-hash 由控制器添加，不属于用户修改的内容
+		delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+		revisions = append(revisions, Revision{
+			Revision:          revision,
+			Name:              rs.Name,
+			ChangeCause:       rs.Annotations[polymorphichelpers.ChangeCauseAnnotation],
+			CreationTimestamp: rs.CreationTimestamp,
+			Current:           strconv.FormatInt(revision, 10) == current,
+			Template:          template,
+		})
+	}
+	return revisions, nil
+}
+
+// controllerRevisionHistory 通过 ControllerRevision 获取 StatefulSet/DaemonSet 的历史版本
+func controllerRevisionHistory(ctx context.Context, clientset kubernetes.Interface, owner metav1.Object, selector *metav1.LabelSelector, currentName string) ([]Revision, error) {
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	list, err := clientset.AppsV1().ControllerRevisions(owner.GetNamespace()).List(ctx, metav1.ListOptions{LabelSelector: labelSelector.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]Revision, 0, len(list.Items))
+	for i := range list.Items {
+		history := &list.Items[i]
+		if ref := metav1.GetControllerOf(history); ref == nil || ref.UID != owner.GetUID() {
+			continue
+		}
+		// ControllerRevision 中保存的是 {"spec":{"template":{...}}} 形式的 patch
+		var data struct {
+			Spec struct {
+				Template corev1.PodTemplateSpec `json:"template"`
+			} `json:"spec"`
+		}
+		if err := json.Unmarshal(history.Data.Raw, &data); err != nil {
+			return nil, fmt.Errorf("failed to decode controller revision %s: %v", history.Name, err)
+		}
+		revisions = append(revisions, Revision{
+			Revision:          history.Revision,
+			Name:              history.Name,
+			ChangeCause:       history.Annotations[polymorphichelpers.ChangeCauseAnnotation],
+			CreationTimestamp: history.CreationTimestamp,
+			Current:           currentName != "" && history.Name == currentName,
+			Template:          &data.Spec.Template,
+		})
+	}
+	return revisions, nil
+}
+
+// FindRevision 在历史版本中查找指定版本，revision 为 0 时返回当前版本
+func FindRevision(revisions []Revision, revision int64) (*Revision, error) {
+	for i := range revisions {
+		if revision == 0 && revisions[i].Current || revision != 0 && revisions[i].Revision == revision {
+			return &revisions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d", ErrRevisionNotFound, revision)
+}
+
+// DiffRevisions 比较两个版本的 Pod 模板
+func DiffRevisions(from, to *Revision) (*diff.Result, error) {
+	return diff.Compare(from.Template, to.Template)
+}
+
+// Rollback 回滚到指定版本，toRevision 为 0 时回滚到上一个版本，返回 kubectl 的提示信息
+func Rollback(ctx context.Context, clientset kubernetes.Interface, kind, namespace, name string, toRevision int64) (string, error) {
+	gk, err := groupKind(kind)
+	if err != nil {
+		return "", err
+	}
+	obj, err := getWorkload(ctx, clientset, kind, namespace, name)
+	if err != nil {
+		return "", err
+	}
+	rollbacker, err := polymorphichelpers.RollbackerFor(gk, clientset)
+	if err != nil {
+		return "", err
+	}
+	return rollbacker.Rollback(obj, nil, toRevision, cmdutil.DryRunNone)
+}
+
+// SetPaused 暂停或恢复 Deployment 的发布，只有 Deployment 支持暂停
+func SetPaused(ctx context.Context, clientset kubernetes.Interface, kind, namespace, name string, paused bool) error {
+	if kind != "deployments" {
+		return ErrPauseUnsupported
+	}
+	deployment, err := clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if deployment.Spec.Paused == paused {
+		if paused {
+			return ErrAlreadyPaused
+		}
+		return ErrNotPaused
+	}
+
+	patch := []byte(fmt.Sprintf(`{"spec":{"paused":%t}}`, paused))
+	_, err = clientset.AppsV1().Deployments(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// GetStatus 获取发布状态，使用 kubectl rollout status 的判断逻辑
+func GetStatus(ctx context.Context, clientset kubernetes.Interface, kind, namespace, name string) (*Status, error) {
+	gk, err := groupKind(kind)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := getWorkload(ctx, clientset, kind, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{}
+	switch workload := obj.(type) {
+	case *appsv1.Deployment:
+		status.Replicas = workload.Status.Replicas
+		status.UpdatedReplicas = workload.Status.UpdatedReplicas
+		status.ReadyReplicas = workload.Status.ReadyReplicas
+		status.AvailableReplicas = workload.Status.AvailableReplicas
+		status.Paused = workload.Spec.Paused
+		status.Revision, _ = strconv.ParseInt(workload.Annotations[RevisionAnnotation], 10, 64)
+		for _, cond := range workload.Status.Conditions {
+			if cond.Type == appsv1.DeploymentProgressing && cond.Reason == timedOutReason {
+				status.Stuck = true
+			}
+		}
+	case *appsv1.StatefulSet:
+		status.Replicas = workload.Status.Replicas
+		status.UpdatedReplicas = workload.Status.UpdatedReplicas
+		status.ReadyReplicas = workload.Status.ReadyReplicas
+		status.AvailableReplicas = workload.Status.AvailableReplicas
+	case *appsv1.DaemonSet:
+		status.Replicas = workload.Status.DesiredNumberScheduled
+		status.UpdatedReplicas = workload.Status.UpdatedNumberScheduled
+		status.ReadyReplicas = workload.Status.NumberReady
+		status.AvailableReplicas = workload.Status.NumberAvailable
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	viewer, err := polymorphichelpers.StatusViewerFor(gk)
+	if err != nil {
+		return nil, err
+	}
+	message, done, err := viewer.Status(&unstructured.Unstructured{Object: content}, 0)
+	if err != nil {
+		// 超过发布期限、OnDelete 更新策略等情况 kubectl 以错误的形式返回
+		message = err.Error()
+	}
+	status.Message = message
+	status.Done = done
+	return status, nil
+}
+
+// Restart 使用 kubectl rollout restart 的方式重启工作负载（修改 Pod 模板中的重启时间注解）
+func Restart(ctx context.Context, clientset kubernetes.Interface, kind, namespace, name string) error {
+	obj, err := getWorkload(ctx, clientset, kind, namespace, name)
+	if err != nil {
+		return err
+	}
+	patch, err := polymorphichelpers.ObjectRestarterFn(obj)
+	if err != nil {
+		return err
+	}
+
+	switch kind {
+	case "deployments":
+		_, err = clientset.AppsV1().Deployments(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	case "statefulsets":
+		_, err = clientset.AppsV1().StatefulSets(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	case "daemonsets":
+		_, err = clientset.AppsV1().DaemonSets(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	}
+	return err
+}
@@ -0,0 +1,137 @@
+package rollout
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func podTemplate(image string) corev1.PodTemplateSpec {
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: image}}},
+	}
+}
+
+func replicaSet(deployment *appsv1.Deployment, name, revision, image string) *appsv1.ReplicaSet {
+	isController := true
+	template := podTemplate(image)
+	template.Labels[appsv1.DefaultDeploymentUniqueLabelKey] = name
+	return &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			UID:       types.UID("uid-" + name),
+			Labels:    template.Labels,
+			Annotations: map[string]string{
+				RevisionAnnotation:           revision,
+				"kubernetes.io/change-cause": "set image " + image,
+			},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1", Kind: "Deployment", Name: deployment.Name, UID: deployment.UID, Controller: &isController,
+			}},
+		},
+		Spec: appsv1.ReplicaSetSpec{Replicas: deployment.Spec.Replicas, Selector: deployment.Spec.Selector, Template: template},
+	}
+}
+
+func TestDeploymentHistoryAndStatus(t *testing.T) {
+	replicas := int32(2)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "web", Namespace: "default", UID: types.UID("uid-web"), Generation: 2,
+			Annotations: map[string]string{RevisionAnnotation: "2"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: podTemplate("nginx:1.27"),
+		},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 1, AvailableReplicas: 1,
+			Conditions: []appsv1.DeploymentCondition{{
+				Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: timedOutReason,
+			}},
+		},
+	}
+	clientset := fake.NewSimpleClientset(deployment,
+		replicaSet(deployment, "web-1", "1", "nginx:1.25"),
+		replicaSet(deployment, "web-2", "2", "nginx:1.27"),
+	)
+	ctx := context.TODO()
+
+	revisions, err := History(ctx, clientset, "deployments", "default", "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 || revisions[0].Revision != 1 || revisions[1].Revision != 2 ||
+		revisions[0].Current || !revisions[1].Current || revisions[1].ChangeCause != "set image nginx:1.27" {
+		t.Fatalf("unexpected revisions %+v", revisions)
+	}
+
+	from, err := FindRevision(revisions, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := FindRevision(revisions, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := DiffRevisions(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Changes) != 1 || result.Changes[0].Path != ".spec.containers[0].image" {
+		t.Fatalf("expected only the image to change, got %+v", result.Changes)
+	}
+
+	status, err := GetStatus(ctx, clientset, "deployments", "default", "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Stuck || status.Done || status.Revision != 2 || status.UpdatedReplicas != 1 {
+		t.Fatalf("unexpected status %+v", status)
+	}
+}
+
+func TestStatefulSetHistory(t *testing.T) {
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", UID: types.UID("uid-db")},
+		Spec: appsv1.StatefulSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+		},
+		Status: appsv1.StatefulSetStatus{UpdateRevision: "db-b"},
+	}
+	isController := true
+	revision := func(name string, number int64, image string) *appsv1.ControllerRevision {
+		return &appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name, Namespace: "default", Labels: map[string]string{"app": "db"},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: "apps/v1", Kind: "StatefulSet", Name: "db", UID: statefulSet.UID, Controller: &isController,
+				}},
+			},
+			Data:     runtime.RawExtension{Raw: []byte(`{"spec":{"template":{"spec":{"containers":[{"name":"db","image":"` + image + `"}]},"$patch":"replace"}}}`)},
+			Revision: number,
+		}
+	}
+	clientset := fake.NewSimpleClientset(statefulSet, revision("db-b", 2, "mysql:8.4"), revision("db-a", 1, "mysql:8.0"))
+
+	revisions, err := History(context.TODO(), clientset, "statefulsets", "default", "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 || revisions[0].Name != "db-a" || !revisions[1].Current ||
+		revisions[1].Template.Spec.Containers[0].Image != "mysql:8.4" {
+		t.Fatalf("unexpected revisions %+v", revisions)
+	}
+	if _, err := History(context.TODO(), clientset, "pods", "default", "db"); err != ErrUnsupportedKind {
+		t.Fatalf("expected ErrUnsupportedKind, got %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+package scale
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+const (
+	subresource = "scale"
+	// ReplicasAnnotation 缩容到 0 时记录原副本数的注解，恢复后删除
+	ReplicasAnnotation = "gwayne.io/parked-replicas"
+)
+
+var ErrInvalidReplicas = errors.New("replicas must not be negative")
+
+// GetScale 获取资源的 scale 子资源，支持声明了 scale 子资源的 CRD
+func GetScale(ctx context.Context, dynamicClient dynamic.Interface, gvr schema.GroupVersionResource, namespace, name string) (*autoscalingv1.Scale, error) {
+	obj, err := resourceInterface(dynamicClient, gvr, namespace).Get(ctx, name, metav1.GetOptions{}, subresource)
+	if err != nil {
+		return nil, err
+	}
+	return toScale(obj)
+}
+
+// UpdateScale 修改副本数。resourceVersion 不为空时使用 update 以检测并发修改，否则使用 patch 直接修改。
+func UpdateScale(ctx context.Context, dynamicClient dynamic.Interface, gvr schema.GroupVersionResource, namespace, name string, replicas int32, resourceVersion string) (*autoscalingv1.Scale, error) {
+	if replicas < 0 {
+		return nil, ErrInvalidReplicas
+	}
+	client := resourceInterface(dynamicClient, gvr, namespace)
+
+	var obj *unstructured.Unstructured
+	var err error
+	if resourceVersion == "" {
+		patch := []byte(fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas))
+		obj, err = client.Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}, subresource)
+	} else {
+		scale := &autoscalingv1.Scale{
+			TypeMeta:   metav1.TypeMeta{APIVersion: autoscalingv1.SchemeGroupVersion.String(), Kind: "Scale"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, ResourceVersion: resourceVersion},
+			Spec:       autoscalingv1.ScaleSpec{Replicas: replicas},
+		}
+		content, convertErr := runtime.DefaultUnstructuredConverter.ToUnstructured(scale)
+		if convertErr != nil {
+			return nil, convertErr
+		}
+		obj, err = client.Update(ctx, &unstructured.Unstructured{Object: content}, metav1.UpdateOptions{}, subresource)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toScale(obj)
+}
+
+func resourceInterface(dynamicClient dynamic.Interface, gvr schema.GroupVersionResource, namespace string) dynamic.ResourceInterface {
+	if namespace == "" {
+		return dynamicClient.Resource(gvr)
+	}
+	return dynamicClient.Resource(gvr).Namespace(namespace)
+}
+
+func toScale(obj *unstructured.Unstructured) (*autoscalingv1.Scale, error) {
+	scale := &autoscalingv1.Scale{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), scale); err != nil {
+		return nil, err
+	}
+	return scale, nil
+}
+
+// Action 批量操作的类型
+type Action string
+
+const (
+	// ActionPark 缩容到 0 并记录原副本数
+	ActionPark Action = "park"
+	// ActionRestore 恢复记录的副本数
+	ActionRestore Action = "restore"
+)
+
+// Status 单个工作负载的处理结果
+type Status string
+
+const (
+	StatusDone    Status = "done"
+	StatusSkipped Status = "skipped"
+	StatusFailed  Status = "failed"
+)
+
+// Result 单个工作负载的处理结果
+type Result struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// 操作前后的副本数
+	From   int32  `json:"from"`
+	To     int32  `json:"to"`
+	Status Status `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// 批量缩容的工作负载
+type workload struct {
+	kind        string
+	name        string
+	replicas    int32
+	annotations map[string]string
+	patch       func(ctx context.Context, data []byte) error
+}
+
+// Namespace 对命名空间下的 Deployment 和 StatefulSet 批量缩容到 0 或恢复原副本数，
+// 原副本数记录在 ReplicasAnnotation 注解中，单个工作负载失败不影响其它工作负载。
+func Namespace(ctx context.Context, clientset kubernetes.Interface, namespace string, action Action) ([]Result, error) {
+	workloads, err := listWorkloads(ctx, clientset, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(workloads))
+	for _, w := range workloads {
+		result := Result{Kind: w.kind, Name: w.name, From: w.replicas, To: w.replicas}
+		patch, err := buildPatch(w, action, &result)
+		if err != nil {
+			result.Status = StatusFailed
+			result.Error = err.Error()
+		} else if patch == nil {
+			result.Status = StatusSkipped
+		} else if err := w.patch(ctx, patch); err != nil {
+			klog.Errorf("%s %s %s/%s error: %v", action, w.kind, namespace, w.name, err)
+			result.Status = StatusFailed
+			result.Error = err.Error()
+			result.To = result.From
+		} else {
+			result.Status = StatusDone
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// buildPatch 返回需要提交的 merge patch，不需要修改时返回 nil
+func buildPatch(w workload, action Action, result *Result) ([]byte, error) {
+	recorded, parked := w.annotations[ReplicasAnnotation]
+	switch action {
+	case ActionPark:
+		// 已经缩容或副本数本来就为 0 的工作负载不处理，避免覆盖记录的副本数；
+		// 带有注解但已被手动扩容的工作负载按当前副本数重新记录
+		if w.replicas == 0 {
+			return nil, nil
+		}
+		result.To = 0
+		return json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{ReplicasAnnotation: strconv.Itoa(int(w.replicas))},
+			},
+			"spec": map[string]interface{}{"replicas": 0},
+		})
+	case ActionRestore:
+		if !parked {
+			return nil, nil
+		}
+		replicas, err := strconv.ParseInt(recorded, 10, 32)
+		if err != nil || replicas < 0 {
+			return nil, fmt.Errorf("invalid %s annotation %q", ReplicasAnnotation, recorded)
+		}
+		result.To = int32(replicas)
+		return json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{ReplicasAnnotation: nil},
+			},
+			"spec": map[string]interface{}{"replicas": replicas},
+		})
+	}
+	return nil, fmt.Errorf("unsupported action %q", action)
+}
+
+func listWorkloads(ctx context.Context, clientset kubernetes.Interface, namespace string) ([]workload, error) {
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	statefulSets, err := clientset.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	workloads := make([]workload, 0, len(deployments.Items)+len(statefulSets.Items))
+	for _, item := range deployments.Items {
+		name := item.Name
+		workloads = append(workloads, workload{
+			kind:        "Deployment",
+			name:        name,
+			replicas:    replicasOf(item.Spec.Replicas),
+			annotations: item.Annotations,
+			patch: func(ctx context.Context, data []byte) error {
+				_, err := clientset.AppsV1().Deployments(namespace).Patch(ctx, name, types.MergePatchType, data, metav1.PatchOptions{})
+				return err
+			},
+		})
+	}
+	for _, item := range statefulSets.Items {
+		name := item.Name
+		workloads = append(workloads, workload{
+			kind:        "StatefulSet",
+			name:        name,
+			replicas:    replicasOf(item.Spec.Replicas),
+			annotations: item.Annotations,
+			patch: func(ctx context.Context, data []byte) error {
+				_, err := clientset.AppsV1().StatefulSets(namespace).Patch(ctx, name, types.MergePatchType, data, metav1.PatchOptions{})
+				return err
+			},
+		})
+	}
+	return workloads, nil
+}
+
+// replicasOf 未指定副本数时 Kubernetes 默认为 1
+func replicasOf(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
@@ -0,0 +1,99 @@
+package scale
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParkAndRestoreNamespace(t *testing.T) {
+	replicas := func(n int32) *int32 { return &n }
+	clientset := fake.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "dev"},
+			Spec:       appsv1.DeploymentSpec{Replicas: replicas(3)},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "idle", Namespace: "dev"},
+			Spec:       appsv1.DeploymentSpec{Replicas: replicas(0)},
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "dev"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: replicas(1)},
+		},
+	)
+	ctx := context.TODO()
+
+	results, err := Namespace(ctx, clientset, "dev", ActionPark)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := 0
+	for _, result := range results {
+		if result.Status == StatusDone {
+			done++
+		}
+	}
+	if len(results) != 3 || done != 2 {
+		t.Fatalf("expected 2 of 3 workloads parked, got %+v", results)
+	}
+
+	web, _ := clientset.AppsV1().Deployments("dev").Get(ctx, "web", metav1.GetOptions{})
+	if *web.Spec.Replicas != 0 || web.Annotations[ReplicasAnnotation] != "3" {
+		t.Fatalf("expected web parked with 3 recorded replicas, got %d %v", *web.Spec.Replicas, web.Annotations)
+	}
+
+	// 再次缩容不会覆盖记录的副本数
+	if _, err := Namespace(ctx, clientset, "dev", ActionPark); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Namespace(ctx, clientset, "dev", ActionRestore); err != nil {
+		t.Fatal(err)
+	}
+
+	web, _ = clientset.AppsV1().Deployments("dev").Get(ctx, "web", metav1.GetOptions{})
+	if *web.Spec.Replicas != 3 {
+		t.Fatalf("expected web restored to 3 replicas, got %d", *web.Spec.Replicas)
+	}
+	if _, ok := web.Annotations[ReplicasAnnotation]; ok {
+		t.Fatalf("expected annotation removed, got %v", web.Annotations)
+	}
+	db, _ := clientset.AppsV1().StatefulSets("dev").Get(ctx, "db", metav1.GetOptions{})
+	idle, _ := clientset.AppsV1().Deployments("dev").Get(ctx, "idle", metav1.GetOptions{})
+	if *db.Spec.Replicas != 1 || *idle.Spec.Replicas != 0 {
+		t.Fatalf("unexpected replicas db=%d idle=%d", *db.Spec.Replicas, *idle.Spec.Replicas)
+	}
+}
+
+func TestParkScaledUpAfterPark(t *testing.T) {
+	replicas := int32(4)
+	// 缩容后被手动扩容，注解仍然保留旧的副本数
+	clientset := fake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "dev", Annotations: map[string]string{ReplicasAnnotation: "2"}},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	})
+	ctx := context.TODO()
+
+	results, err := Namespace(ctx, clientset, "dev", ActionPark)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Status != StatusDone || results[0].From != 4 || results[0].To != 0 {
+		t.Fatalf("expected web parked from 4 replicas, got %+v", results)
+	}
+	web, _ := clientset.AppsV1().Deployments("dev").Get(ctx, "web", metav1.GetOptions{})
+	if *web.Spec.Replicas != 0 || web.Annotations[ReplicasAnnotation] != "4" {
+		t.Fatalf("expected web parked with 4 recorded replicas, got %d %v", *web.Spec.Replicas, web.Annotations)
+	}
+
+	if _, err := Namespace(ctx, clientset, "dev", ActionRestore); err != nil {
+		t.Fatal(err)
+	}
+	web, _ = clientset.AppsV1().Deployments("dev").Get(ctx, "web", metav1.GetOptions{})
+	if *web.Spec.Replicas != 4 {
+		t.Fatalf("expected web restored to 4 replicas, got %d", *web.Spec.Replicas)
+	}
+}
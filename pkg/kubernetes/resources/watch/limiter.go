@@ -0,0 +1,40 @@
+package watch
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrTooManyConnections 连接数超过上限
+var ErrTooManyConnections = errors.New("too many watch connections")
+
+// ConnectionLimiter 按 key（例如用户名）限制同时打开的连接数
+type ConnectionLimiter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func NewConnectionLimiter() *ConnectionLimiter {
+	return &ConnectionLimiter{counts: map[string]int{}}
+}
+
+// Acquire 占用一个连接，返回释放函数。max 小于等于 0 表示不限制。
+func (l *ConnectionLimiter) Acquire(key string, max int) (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if max > 0 && l.counts[key] >= max {
+		return nil, ErrTooManyConnections
+	}
+	l.counts[key]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if l.counts[key]--; l.counts[key] <= 0 {
+				delete(l.counts, key)
+			}
+		})
+	}, nil
+}
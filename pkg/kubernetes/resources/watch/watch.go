@@ -0,0 +1,235 @@
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// EventType 资源变化事件类型，与 Kubernetes watch 保持一致
+type EventType string
+
+const (
+	Added    EventType = "ADDED"
+	Modified EventType = "MODIFIED"
+	Deleted  EventType = "DELETED"
+	Error    EventType = "ERROR"
+)
+
+const defaultBufferSize = 100
+
+var (
+	// ErrTooSlow 客户端消费过慢，事件缓存已满
+	ErrTooSlow = errors.New("watch consumer is too slow")
+	// ErrInvalidResourceVersion resourceVersion 不是合法的版本号
+	ErrInvalidResourceVersion = errors.New("invalid resourceVersion")
+)
+
+// Event 资源变化事件
+type Event struct {
+	Type            EventType   `json:"type"`
+	ResourceVersion string      `json:"-"`
+	Object          interface{} `json:"object"`
+}
+
+// Options watch 的过滤条件
+type Options struct {
+	// 命名空间，为空表示所有命名空间
+	Namespace string
+	// 标签选择器，为空表示不过滤
+	LabelSelector labels.Selector
+	// 断线重连时客户端收到的最后一个 resourceVersion，重连后只推送比该版本新的对象
+	ResourceVersion string
+	// 缓存的事件数，默认 100
+	BufferSize int
+}
+
+// Watcher 基于 informer 监听资源变化，不会额外请求 apiserver
+type Watcher struct {
+	informer cache.SharedIndexInformer
+	// 事件处理函数在 AddEventHandler 返回前就可能被调用，registration 需要加锁访问
+	mu           sync.Mutex
+	registration cache.ResourceEventHandlerRegistration
+	namespace    string
+	selector     labels.Selector
+	resumeRV     uint64
+
+	result   chan Event
+	done     chan struct{}
+	stopOnce sync.Once
+	err      error
+}
+
+// NewWatcher 在 informer 上注册事件处理函数。
+// 注册时 informer 会将缓存中已有的对象作为 ADDED 事件推送，指定 ResourceVersion 时只推送版本更新的对象。
+// 由于 informer 不保存历史，断线期间被删除的对象无法补发。
+func NewWatcher(informer cache.SharedIndexInformer, opts Options) (*Watcher, error) {
+	w := &Watcher{
+		informer:  informer,
+		namespace: opts.Namespace,
+		selector:  opts.LabelSelector,
+		done:      make(chan struct{}),
+	}
+	if w.selector == nil {
+		w.selector = labels.Everything()
+	}
+	if opts.ResourceVersion != "" {
+		rv, err := strconv.ParseUint(opts.ResourceVersion, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidResourceVersion, opts.ResourceVersion)
+		}
+		w.resumeRV = rv
+	}
+	bufferSize := opts.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+	w.result = make(chan Event, bufferSize)
+
+	registration, err := informer.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc:    w.onAdd,
+		UpdateFunc: w.onUpdate,
+		DeleteFunc: w.onDelete,
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.mu.Lock()
+	w.registration = registration
+	w.mu.Unlock()
+	// 注册过程中已经停止（例如初始对象过多导致缓存写满）
+	select {
+	case <-w.done:
+		w.removeHandler()
+	default:
+	}
+	return w, nil
+}
+
+// ResultChan 返回事件通道
+func (w *Watcher) ResultChan() <-chan Event {
+	return w.result
+}
+
+// Done watcher 停止时关闭
+func (w *Watcher) Done() <-chan struct{} {
+	return w.done
+}
+
+// Err 返回 watcher 停止的原因，主动调用 Stop 时为 nil
+func (w *Watcher) Err() error {
+	select {
+	case <-w.done:
+		return w.err
+	default:
+		return nil
+	}
+}
+
+// Stop 停止监听并移除事件处理函数
+func (w *Watcher) Stop() {
+	w.stop(nil)
+}
+
+func (w *Watcher) stop(err error) {
+	w.stopOnce.Do(func() {
+		w.err = err
+		close(w.done)
+		// 在单独的 goroutine 中移除，避免在事件处理函数中调用时阻塞 informer
+		go w.removeHandler()
+	})
+}
+
+func (w *Watcher) removeHandler() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.registration != nil {
+		_ = w.informer.RemoveEventHandler(w.registration)
+		w.registration = nil
+	}
+}
+
+func (w *Watcher) onAdd(obj interface{}, isInInitialList bool) {
+	if !w.match(obj) {
+		return
+	}
+	rv := resourceVersion(obj)
+	if isInInitialList && w.resumeRV > 0 {
+		// 重连时跳过客户端已经收到过的对象
+		if v, err := strconv.ParseUint(rv, 10, 64); err == nil && v <= w.resumeRV {
+			return
+		}
+	}
+	event := Event{Type: Added, ResourceVersion: rv, Object: obj}
+	if isInInitialList {
+		// 初始对象等待客户端读取，informer 为每个事件处理函数单独排队，阻塞不会影响其它 watcher
+		select {
+		case <-w.done:
+		case w.result <- event:
+		}
+		return
+	}
+	w.send(event)
+}
+
+func (w *Watcher) onUpdate(oldObj, newObj interface{}) {
+	rv := resourceVersion(newObj)
+	// informer 定期 resync 时对象没有变化，忽略
+	if rv == resourceVersion(oldObj) {
+		return
+	}
+	// 与 Kubernetes 一致，对象不再满足过滤条件时推送 DELETED，开始满足时推送 ADDED
+	oldMatch, newMatch := w.match(oldObj), w.match(newObj)
+	switch {
+	case oldMatch && newMatch:
+		w.send(Event{Type: Modified, ResourceVersion: rv, Object: newObj})
+	case oldMatch:
+		w.send(Event{Type: Deleted, ResourceVersion: rv, Object: newObj})
+	case newMatch:
+		w.send(Event{Type: Added, ResourceVersion: rv, Object: newObj})
+	}
+}
+
+func (w *Watcher) onDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if !w.match(obj) {
+		return
+	}
+	w.send(Event{Type: Deleted, ResourceVersion: resourceVersion(obj), Object: obj})
+}
+
+// send 推送实时事件，缓存写满时断开连接，避免事件在 informer 中无限堆积，由客户端携带 resourceVersion 重连
+func (w *Watcher) send(event Event) {
+	select {
+	case <-w.done:
+	case w.result <- event:
+	default:
+		w.stop(ErrTooSlow)
+	}
+}
+
+func (w *Watcher) match(obj interface{}) bool {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return false
+	}
+	if w.namespace != "" && accessor.GetNamespace() != w.namespace {
+		return false
+	}
+	return w.selector.Matches(labels.Set(accessor.GetLabels()))
+}
+
+func resourceVersion(obj interface{}) string {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	return accessor.GetResourceVersion()
+}
@@ -0,0 +1,121 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newPod(name, rv string, podLabels map[string]string) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name: name, Namespace: "default", ResourceVersion: rv, Labels: podLabels,
+	}}
+}
+
+func startInformer(t *testing.T, objs ...*corev1.Pod) (*fake.Clientset, cache.SharedIndexInformer) {
+	clientset := fake.NewSimpleClientset()
+	for _, obj := range objs {
+		if _, err := clientset.CoreV1().Pods(obj.Namespace).Create(context.TODO(), obj, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	factory := informers.NewSharedInformerFactory(clientset, 0)
+	informer := factory.Core().V1().Pods().Informer()
+	stop := make(chan struct{})
+	t.Cleanup(func() { close(stop) })
+	factory.Start(stop)
+	cache.WaitForCacheSync(stop, informer.HasSynced)
+	return clientset, informer
+}
+
+func receive(t *testing.T, w *Watcher) Event {
+	select {
+	case e := <-w.ResultChan():
+		return e
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout waiting for event")
+	}
+	return Event{}
+}
+
+func TestWatcher(t *testing.T) {
+	clientset, informer := startInformer(t,
+		newPod("web-1", "10", map[string]string{"app": "web"}),
+		newPod("db-1", "11", map[string]string{"app": "db"}),
+		newPod("web-2", "12", map[string]string{"app": "web"}),
+	)
+
+	w, err := NewWatcher(informer, Options{
+		Namespace:       "default",
+		LabelSelector:   labels.SelectorFromSet(labels.Set{"app": "web"}),
+		ResourceVersion: "10",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	// 重连时只推送 resourceVersion 大于 10 且满足标签选择器的对象
+	if e := receive(t, w); e.Type != Added || e.Object.(*corev1.Pod).Name != "web-2" {
+		t.Fatalf("expected ADDED web-2, got %s %v", e.Type, e.Object)
+	}
+
+	updated := newPod("web-2", "13", map[string]string{"app": "db"})
+	if _, err := clientset.CoreV1().Pods("default").Update(context.TODO(), updated, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// 标签不再匹配时推送 DELETED
+	if e := receive(t, w); e.Type != Deleted || e.ResourceVersion != "13" {
+		t.Fatalf("expected DELETED with resourceVersion 13, got %s %s", e.Type, e.ResourceVersion)
+	}
+
+	if _, err := NewWatcher(informer, Options{ResourceVersion: "abc"}); !errors.Is(err, ErrInvalidResourceVersion) {
+		t.Fatalf("expected ErrInvalidResourceVersion, got %v", err)
+	}
+}
+
+func TestWatcherTooSlow(t *testing.T) {
+	clientset, informer := startInformer(t)
+
+	w, err := NewWatcher(informer, Options{BufferSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pod := range []*corev1.Pod{newPod("a", "1", nil), newPod("b", "2", nil)} {
+		if _, err := clientset.CoreV1().Pods("default").Create(context.TODO(), pod, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case <-w.Done():
+	case <-time.After(3 * time.Second):
+		t.Fatal("expected watcher to stop")
+	}
+	if !errors.Is(w.Err(), ErrTooSlow) {
+		t.Fatalf("expected ErrTooSlow, got %v", w.Err())
+	}
+}
+
+func TestConnectionLimiter(t *testing.T) {
+	l := NewConnectionLimiter()
+	release, err := l.Acquire("admin", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Acquire("admin", 1); !errors.Is(err, ErrTooManyConnections) {
+		t.Fatalf("expected ErrTooManyConnections, got %v", err)
+	}
+	release()
+	release()
+	if _, err := l.Acquire("admin", 1); err != nil {
+		t.Fatalf("expected connection after release, got %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package pagequery
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidContinue continue 令牌无法解析
+var ErrInvalidContinue = errors.New("invalid continue token")
+
+// Cursor continue 令牌的内容，编码后对客户端不透明。
+// 不经过缓存的列表（例如通过动态客户端查询的 CRD 实例）使用 Kubernetes 的 continue，
+// 数据库列表使用上一页最后一条记录的 ID 做 keyset 分页。
+type Cursor struct {
+	Continue string `json:"c,omitempty"` // Kubernetes List 返回的 continue
+	LastID   int64  `json:"i,omitempty"` // 上一页最后一条记录的 ID
+	Desc     bool   `json:"d,omitempty"` // 数据库列表是否按 ID 降序，翻页时不能改变
+}
+
+// Encode 将 Cursor 编码为 continue 令牌
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor 解析 continue 令牌，空令牌表示第一页
+func DecodeCursor(token string) (Cursor, error) {
+	var c Cursor
+	if token == "" {
+		return c, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, ErrInvalidContinue
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, ErrInvalidContinue
+	}
+	return c, nil
+}
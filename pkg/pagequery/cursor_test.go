@@ -0,0 +1,27 @@
+package pagequery
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCursorEncodeDecode(t *testing.T) {
+	c := Cursor{Continue: "eyJ2IjoibWV0YS5rOHMuaW8vdjEifQ", LastID: 42, Desc: true}
+	got, err := DecodeCursor(c.Encode())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != c {
+		t.Fatalf("expected %+v, got %+v", c, got)
+	}
+
+	if got, err := DecodeCursor(""); err != nil || got != (Cursor{}) {
+		t.Fatalf("expected empty cursor for empty token, got %+v, %v", got, err)
+	}
+
+	for _, token := range []string{"not base64!", "bm90IGpzb24"} {
+		if _, err := DecodeCursor(token); !errors.Is(err, ErrInvalidContinue) {
+			t.Errorf("%q: expected ErrInvalidContinue, got %v", token, err)
+		}
+	}
+}
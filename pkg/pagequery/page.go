@@ -1,9 +1,11 @@
 package pagequery
 
 type Page struct {
-	PageNo     int64       `json:"pageNo"`     // 当前页码
-	PageSize   int64       `json:"pageSize"`   // 每页条数
-	TotalPage  int64       `json:"totalPage"`  // 总页数
-	TotalCount int64       `json:"totalCount"` // 总记录数
-	List       interface{} `json:"list"`       // 数据列表
+	PageNo         int64       `json:"pageNo"`                   // 当前页码
+	PageSize       int64       `json:"pageSize"`                 // 每页条数
+	TotalPage      int64       `json:"totalPage"`                // 总页数
+	TotalCount     int64       `json:"totalCount"`               // 总记录数
+	List           interface{} `json:"list"`                     // 数据列表
+	Continue       string      `json:"continue,omitempty"`       // 游标分页时用于获取下一页的令牌，为空表示没有更多数据
+	RemainingCount *int64      `json:"remainingCount,omitempty"` // 游标分页时剩余的记录数，未知时不返回
 }
@@ -1,14 +1,32 @@
 package pagequery
 
+import "errors"
+
+// ErrInvalidQuery 查询参数（过滤、排序、列投影）解析失败，调用方可以据此返回 400
+var ErrInvalidQuery = errors.New("invalid query")
+
 type QueryParam struct {
-	PageNo   int64                  `json:"pageNo"`   // 当前页码
-	PageSize int64                  `json:"pageSize"` // 每页条数
-	Query    map[string]interface{} `json:"query"`    // 查询条件
-	Sortby   string                 `json:"sortby"`   // 排序字段
-	Groupby  []string               `json:"groupby"`  // 分组字段
-	Relate   string                 `json:"relate"`   // 关联条件
+	PageNo   int64                    `json:"pageNo"`            // 当前页码
+	PageSize int64                    `json:"pageSize"`          // 每页条数
+	Query    map[string]interface{}   `json:"query"`             // 查询条件
+	OrQuery  []map[string]interface{} `json:"orQuery,omitempty"` // 额外的查询条件组，与 Query 为 OR 关系
+	Sortby   string                   `json:"sortby"`            // 排序字段
+	Groupby  []string                 `json:"groupby"`           // 分组字段
+	Relate   string                   `json:"relate"`            // 关联条件
 	// only for kubernetes resource
-	LabelSelector string `json:"-"` // Kubernetes 使用的字段，避免序列化
+	LabelSelector string   `json:"-"` // Kubernetes 使用的字段，避免序列化
+	Fields        []string `json:"-"` // 列投影，jsonpath 表达式，例如 replicas:.spec.replicas
+	UseContinue   bool     `json:"-"` // 游标分页，请求中带有 continue 参数时启用，此时忽略 PageNo
+	Continue      string   `json:"-"` // 上一页返回的 continue 令牌，为空表示第一页
+}
+
+// AddQuery 添加所有查询条件组都需要满足的条件，例如非管理员只能查看自己的数据。
+// 直接写入 Query 只对第一组生效，其它 OR 组不受限制。
+func (q *QueryParam) AddQuery(key string, value interface{}) {
+	q.Query[key] = value
+	for _, query := range q.OrQuery {
+		query[key] = value
+	}
 }
 
 func (q *QueryParam) Offset() int64 {
@@ -37,3 +55,13 @@ func (q *QueryParam) NewPage(count int64, list interface{}) *Page {
 		List:       list,
 	}
 }
+
+// NewContinuePage 创建游标分页结果，next 为空表示没有更多数据，remaining 为剩余的记录数，未知时为 nil
+func (q *QueryParam) NewContinuePage(list interface{}, next string, remaining *int64) *Page {
+	return &Page{
+		PageSize:       q.PageSize,
+		List:           list,
+		Continue:       next,
+		RemainingCount: remaining,
+	}
+}
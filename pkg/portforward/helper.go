@@ -0,0 +1,223 @@
+package portforward
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/gorilla/websocket"
+)
+
+const helperUsage = `Usage: gwayne port-forward [flags] TYPE/NAME [LOCAL_PORT:]REMOTE_PORT...
+
+Listen on local ports and forward connections to a pod or a service through the gwayne API.
+TYPE is pod or svc, a bare NAME means a pod. LOCAL_PORT 0 or empty picks a random port.
+The gwayne token is read from -token or the GWAYNE_TOKEN environment variable.
+
+Examples:
+  gwayne port-forward -server https://gwayne.example.com -cluster dev -namespace default pod/web-0 8080:80
+  gwayne port-forward -server https://gwayne.example.com -cluster dev -namespace default svc/mysql :3306
+
+Flags:
+`
+
+// PortMapping 本地端口到远端端口的映射
+type PortMapping struct {
+	Local  int
+	Remote int
+}
+
+// ParsePorts 解析 [LOCAL_PORT:]REMOTE_PORT 格式的端口映射
+func ParsePorts(specs []string) ([]PortMapping, error) {
+	mappings := make([]PortMapping, 0, len(specs))
+	for _, spec := range specs {
+		local, remote, found := strings.Cut(spec, ":")
+		if !found {
+			local, remote = spec, spec
+		}
+		if local == "" {
+			local = "0"
+		}
+		l, err := strconv.ParseUint(local, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid local port in %q", spec)
+		}
+		r, err := strconv.ParseUint(remote, 10, 16)
+		if err != nil || r == 0 {
+			return nil, fmt.Errorf("invalid remote port in %q", spec)
+		}
+		mappings = append(mappings, PortMapping{Local: int(l), Remote: int(r)})
+	}
+	return mappings, nil
+}
+
+// ParseTarget 解析 TYPE/NAME，返回 API 路径中的资源类型 pods 或 services
+func ParseTarget(target string) (string, string, error) {
+	kind, name, found := strings.Cut(target, "/")
+	if !found {
+		kind, name = "pod", target
+	}
+	if name == "" {
+		return "", "", fmt.Errorf("invalid target %q", target)
+	}
+	switch kind {
+	case "pod", "pods", "po":
+		return "pods", name, nil
+	case "svc", "service", "services":
+		return "services", name, nil
+	}
+	return "", "", fmt.Errorf("unsupported target type %q, expected pod or svc", kind)
+}
+
+// TunnelURL 返回端口转发的 WebSocket 地址
+func TunnelURL(server, cluster, namespace, resource, name string, port int) (string, error) {
+	u, err := url.Parse(strings.TrimSuffix(server, "/"))
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case "http", "ws":
+		u.Scheme = "ws"
+	case "https", "wss":
+		u.Scheme = "wss"
+	default:
+		return "", fmt.Errorf("invalid server address %q", server)
+	}
+	u.Path += fmt.Sprintf("/api/v1/portforward/%s/%s/namespaces/%s/clusters/%s",
+		url.PathEscape(resource), url.PathEscape(name), url.PathEscape(namespace), url.PathEscape(cluster))
+	u.RawQuery = url.Values{"port": {strconv.Itoa(port)}}.Encode()
+	return u.String(), nil
+}
+
+type helper struct {
+	dialer *websocket.Dialer
+	header http.Header
+}
+
+// RunHelper 本地端口转发模式，在本地监听端口，每个连接通过 gwayne 的 WebSocket 接口转发到 Pod，返回进程退出码
+func RunHelper(args []string) int {
+	fs := flag.NewFlagSet("port-forward", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), helperUsage)
+		fs.PrintDefaults()
+	}
+	server := fs.String("server", "http://127.0.0.1:8080", "the gwayne server address")
+	token := fs.String("token", os.Getenv("GWAYNE_TOKEN"), "the gwayne token, defaults to $GWAYNE_TOKEN")
+	cluster := fs.String("cluster", "", "the cluster name")
+	namespace := fs.String("namespace", "default", "the namespace")
+	address := fs.String("address", "127.0.0.1", "the local address to listen on")
+	insecure := fs.Bool("insecure-skip-tls-verify", false, "skip verifying the server certificate")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() < 2 || *cluster == "" || *token == "" {
+		fs.Usage()
+		return 2
+	}
+	resource, name, err := ParseTarget(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	mappings, err := ParsePorts(fs.Args()[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	h := &helper{
+		dialer: &websocket.Dialer{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: *insecure},
+		},
+		header: http.Header{"Authorization": {"Bearer " + *token}},
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	listeners := make([]net.Listener, 0, len(mappings))
+	defer func() {
+		for _, l := range listeners {
+			l.Close()
+		}
+	}()
+	for _, m := range mappings {
+		tunnel, err := TunnelURL(*server, *cluster, *namespace, resource, name, m.Remote)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		l, err := net.Listen("tcp", net.JoinHostPort(*address, strconv.Itoa(m.Local)))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		listeners = append(listeners, l)
+		fmt.Printf("Forwarding from %s -> %d\n", l.Addr(), m.Remote)
+		go h.serve(ctx, l, tunnel)
+	}
+
+	<-ctx.Done()
+	return 0
+}
+
+func (h *helper) serve(ctx context.Context, l net.Listener, tunnel string) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				fmt.Fprintf(os.Stderr, "accept connection on %s error: %v\n", l.Addr(), err)
+			}
+			return
+		}
+		go func() {
+			defer conn.Close()
+			if err := h.forward(ctx, conn, tunnel); err != nil {
+				fmt.Fprintf(os.Stderr, "forward connection from %s error: %v\n", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+func (h *helper) forward(ctx context.Context, local net.Conn, tunnel string) error {
+	ws, resp, err := h.dialer.DialContext(ctx, tunnel, h.header)
+	if err != nil {
+		if resp != nil {
+			// 握手失败时返回的是 JSON 格式的错误信息
+			var body struct {
+				Error string `json:"error"`
+			}
+			if json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&body) == nil && body.Error != "" {
+				err = fmt.Errorf("%s: %s", resp.Status, body.Error)
+			}
+			resp.Body.Close()
+		}
+		return err
+	}
+	remote := NewConn(ws)
+	defer remote.Close()
+
+	go func() {
+		io.Copy(remote, local)
+		// 本地连接不再发送数据，通知服务端
+		remote.CloseWrite()
+	}()
+	_, err = io.Copy(local, remote)
+	var closeErr *websocket.CloseError
+	if errors.As(err, &closeErr) {
+		return fmt.Errorf("closed by server: %s", closeErr.Text)
+	}
+	return err
+}
@@ -0,0 +1,110 @@
+package portforward
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// Dial 通过 SPDY 连接到 Pod 的 portforward 子资源，一个连接上可以转发多个 TCP 连接
+func Dial(cli kubernetes.Interface, cfg *rest.Config, namespace, pod string) (httpstream.Connection, error) {
+	transport, upgrader, err := spdy.RoundTripperFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	url := cli.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(pod).
+		SubResource("portforward").
+		URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, url)
+	conn, _, err := dialer.Dial(portforward.PortForwardProtocolV1Name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to pod %s/%s: %w", namespace, pod, err)
+	}
+	return conn, nil
+}
+
+var requestId int64
+
+// Forward 在连接上转发一个 TCP 连接到 Pod 的端口，任意一端关闭后返回，同时返回双向传输的字节数
+func Forward(ctx context.Context, conn httpstream.Connection, port int, local io.ReadWriter) (sent, received int64, err error) {
+	headers := http.Header{}
+	headers.Set(corev1.StreamType, corev1.StreamTypeError)
+	headers.Set(corev1.PortHeader, strconv.Itoa(port))
+	headers.Set(corev1.PortForwardRequestIDHeader, strconv.FormatInt(atomic.AddInt64(&requestId, 1), 10))
+	errorStream, err := conn.CreateStream(headers)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error creating error stream for port %d: %w", port, err)
+	}
+	// 不向 error stream 写入数据
+	errorStream.Close()
+	defer conn.RemoveStreams(errorStream)
+
+	errorChan := make(chan error, 1)
+	go func() {
+		message, err := io.ReadAll(errorStream)
+		switch {
+		case err != nil:
+			errorChan <- fmt.Errorf("error reading from error stream for port %d: %w", port, err)
+		case len(message) > 0:
+			errorChan <- fmt.Errorf("an error occurred forwarding port %d: %s", port, message)
+		}
+		close(errorChan)
+	}()
+
+	headers.Set(corev1.StreamType, corev1.StreamTypeData)
+	dataStream, err := conn.CreateStream(headers)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error creating data stream for port %d: %w", port, err)
+	}
+	defer conn.RemoveStreams(dataStream)
+
+	var sentBytes, receivedBytes int64
+	localDone := make(chan struct{})
+	remoteDone := make(chan struct{})
+	go func() {
+		io.Copy(&countingWriter{w: local, n: &receivedBytes}, dataStream)
+		close(remoteDone)
+	}()
+	go func() {
+		// 本地不再发送数据后通知 Pod
+		defer dataStream.Close()
+		if _, err := io.Copy(&countingWriter{w: dataStream, n: &sentBytes}, local); err != nil {
+			close(localDone)
+		}
+	}()
+
+	select {
+	case <-remoteDone:
+	case <-localDone:
+	case <-ctx.Done():
+	}
+	// 丢弃没有发送的数据，避免阻塞 error stream
+	_ = dataStream.Reset()
+	err = <-errorChan
+	return atomic.LoadInt64(&sentBytes), atomic.LoadInt64(&receivedBytes), err
+}
+
+// countingWriter 统计写入的字节数，可以在写入的同时读取
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	atomic.AddInt64(c.n, int64(n))
+	return n, err
+}
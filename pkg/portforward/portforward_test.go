@@ -0,0 +1,109 @@
+package portforward
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParse(t *testing.T) {
+	mappings, err := ParsePorts([]string{"8080:80", "3306", ":9090"})
+	if err != nil {
+		t.Fatalf("ParsePorts error: %v", err)
+	}
+	expected := []PortMapping{{8080, 80}, {3306, 3306}, {0, 9090}}
+	for i, m := range mappings {
+		if m != expected[i] {
+			t.Errorf("unexpected mapping %+v, expected %+v", m, expected[i])
+		}
+	}
+	for _, spec := range []string{"8080:", "a:80", "70000", "80:0"} {
+		if _, err := ParsePorts([]string{spec}); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+
+	for target, expected := range map[string]string{"web-0": "pods/web-0", "pod/web-0": "pods/web-0", "svc/mysql": "services/mysql"} {
+		resource, name, err := ParseTarget(target)
+		if err != nil || resource+"/"+name != expected {
+			t.Errorf("ParseTarget(%s) = %s/%s, %v", target, resource, name, err)
+		}
+	}
+	if _, _, err := ParseTarget("deploy/web"); err == nil {
+		t.Error("expected error for unsupported type")
+	}
+
+	u, err := TunnelURL("https://gwayne.example.com/", "dev", "default", "services", "mysql", 3306)
+	if err != nil || u != "wss://gwayne.example.com/api/v1/portforward/services/mysql/namespaces/default/clusters/dev?port=3306" {
+		t.Errorf("unexpected url %s, %v", u, err)
+	}
+}
+
+func TestResolveService(t *testing.T) {
+	ready, notReady := true, false
+	name, port := "http", int32(8080)
+	cli := fake.NewSimpleClientset(
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "http", Port: 80}}},
+		},
+		&discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-abc", Namespace: "default", Labels: map[string]string{discoveryv1.LabelServiceName: "web"}},
+			Ports:      []discoveryv1.EndpointPort{{Name: &name, Port: &port}},
+			Endpoints: []discoveryv1.Endpoint{
+				{Conditions: discoveryv1.EndpointConditions{Ready: &notReady}, TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "web-0"}},
+				{Conditions: discoveryv1.EndpointConditions{Ready: &ready}, TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "web-1"}},
+			},
+		},
+	)
+
+	pod, targetPort, err := ResolveService(context.Background(), cli, "default", "web", 80)
+	if err != nil || pod != "web-1" || targetPort != 8080 {
+		t.Errorf("ResolveService = %s %d %v", pod, targetPort, err)
+	}
+	if _, _, err := ResolveService(context.Background(), cli, "default", "web", 443); !errors.Is(err, ErrPortNotFound) {
+		t.Errorf("expected ErrPortNotFound, got %v", err)
+	}
+}
+
+func TestConnHalfClose(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		conn := NewConn(ws)
+		defer conn.Close()
+		// 读取到客户端半关闭后，仍然可以返回数据
+		request, _ := io.ReadAll(conn)
+		conn.Write([]byte(strings.ToUpper(string(request))))
+	}))
+	defer server.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial error: %v", err)
+	}
+	conn := NewConn(ws)
+	defer conn.Close()
+	conn.Write([]byte("hello "))
+	conn.Write([]byte("world"))
+	if err := conn.CloseWrite(); err != nil {
+		t.Fatalf("CloseWrite error: %v", err)
+	}
+	response, err := io.ReadAll(conn)
+	if err != nil || string(response) != "HELLO WORLD" {
+		t.Errorf("unexpected response %q, %v", response, err)
+	}
+}
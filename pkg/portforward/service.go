@@ -0,0 +1,73 @@
+package portforward
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	ErrPortNotFound = errors.New("service port not found")
+	ErrNoReadyPod   = errors.New("no ready pod found")
+)
+
+// ResolveService 将 Service 的端口解析为一个就绪的 Pod 和容器端口
+func ResolveService(ctx context.Context, cli kubernetes.Interface, namespace, service string, port int) (string, int, error) {
+	svc, err := cli.CoreV1().Services(namespace).Get(ctx, service, metav1.GetOptions{})
+	if err != nil {
+		return "", 0, err
+	}
+	portName := ""
+	found := false
+	for _, p := range svc.Spec.Ports {
+		if int(p.Port) == port {
+			portName, found = p.Name, true
+			break
+		}
+	}
+	if !found {
+		return "", 0, fmt.Errorf("%w: service %s has no port %d", ErrPortNotFound, service, port)
+	}
+
+	slices, err := cli.DiscoveryV1().EndpointSlices(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: discoveryv1.LabelServiceName + "=" + service,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	pod, targetPort, ok := readyEndpoint(slices.Items, portName)
+	if !ok {
+		return "", 0, fmt.Errorf("%w for service %s port %d", ErrNoReadyPod, service, port)
+	}
+	return pod, targetPort, nil
+}
+
+// readyEndpoint 从 EndpointSlice 中选择第一个就绪的 Pod，端口按 Service 端口的名称匹配，EndpointSlice 中是解析后的容器端口
+func readyEndpoint(slices []discoveryv1.EndpointSlice, portName string) (string, int, bool) {
+	for _, slice := range slices {
+		targetPort := 0
+		for _, p := range slice.Ports {
+			if p.Port != nil && p.Name != nil && *p.Name == portName {
+				targetPort = int(*p.Port)
+				break
+			}
+		}
+		if targetPort == 0 {
+			continue
+		}
+		for _, endpoint := range slice.Endpoints {
+			if endpoint.TargetRef == nil || endpoint.TargetRef.Kind != "Pod" {
+				continue
+			}
+			if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+				continue
+			}
+			return endpoint.TargetRef.Name, targetPort, true
+		}
+	}
+	return "", 0, false
+}
@@ -0,0 +1,85 @@
+package portforward
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const closeTimeout = 5 * time.Second
+
+// Conn 把 WebSocket 的二进制消息转换为字节流，每条消息是流中的一段数据
+type Conn struct {
+	ws      *websocket.Conn
+	reader  io.Reader
+	writeMu sync.Mutex
+}
+
+// NewConn 将 WebSocket 连接包装为字节流。
+// 收到关闭消息相当于 TCP 的半关闭，读取返回 io.EOF，仍然可以继续写入，直到调用 Close。
+func NewConn(ws *websocket.Conn) *Conn {
+	// 默认的处理会立即回复关闭消息，之后不能再发送数据
+	ws.SetCloseHandler(func(int, string) error { return nil })
+	return &Conn{ws: ws}
+}
+
+func (c *Conn) Read(p []byte) (int, error) {
+	for {
+		if c.reader == nil {
+			messageType, reader, err := c.ws.NextReader()
+			if err != nil {
+				if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					return 0, io.EOF
+				}
+				return 0, err
+			}
+			if messageType != websocket.BinaryMessage {
+				continue
+			}
+			c.reader = reader
+		}
+		n, err := c.reader.Read(p)
+		if err == io.EOF {
+			c.reader = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (c *Conn) Write(p []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := c.ws.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// CloseWrite 通知对端不再发送数据，仍然可以继续读取
+func (c *Conn) CloseWrite() error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(closeTimeout))
+}
+
+// CloseWithReason 发送关闭消息后关闭连接，reason 会返回给对端
+func (c *Conn) CloseWithReason(code int, reason string) error {
+	c.writeMu.Lock()
+	// 关闭原因最长 123 字节
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	_ = c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(closeTimeout))
+	c.writeMu.Unlock()
+	return c.ws.Close()
+}
+
+func (c *Conn) Close() error {
+	return c.CloseWithReason(websocket.CloseNormalClosure, "")
+}
@@ -0,0 +1,234 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JLPAY/gwayne/models"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/client"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/node"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/rollout"
+	"github.com/JLPAY/gwayne/pkg/kubernetes/resources/scale"
+	"github.com/robfig/cron/v3"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ScaleParams scale 操作的参数
+type ScaleParams struct {
+	Replicas *int32 `json:"replicas"`
+}
+
+// Validate 校验任务配置，保存任务前调用
+func Validate(job *models.ScheduledJob) error {
+	if job.Name == "" || job.Cluster == "" {
+		return errors.New("name and cluster are required")
+	}
+	if _, err := parseSchedule(job); err != nil {
+		return err
+	}
+
+	switch job.Action {
+	case models.ScheduledJobActionRestart:
+		if job.Kind != "deployments" && job.Kind != "statefulsets" && job.Kind != "daemonsets" {
+			return errors.New("restart is only supported for deployments, statefulsets and daemonsets")
+		}
+		if job.Namespace == "" || job.Target == "" {
+			return errors.New("namespace and target are required for restart")
+		}
+	case models.ScheduledJobActionScale:
+		if job.Kind == "" || job.Namespace == "" || job.Target == "" {
+			return errors.New("kind, namespace and target are required for scale")
+		}
+		var params ScaleParams
+		if err := json.Unmarshal([]byte(job.Params), &params); err != nil || params.Replicas == nil || *params.Replicas < 0 {
+			return errors.New(`params must be {"replicas": n} with n >= 0 for scale`)
+		}
+	case models.ScheduledJobActionPark, models.ScheduledJobActionRestore:
+		if job.Namespace == "" {
+			return fmt.Errorf("namespace is required for %s", job.Action)
+		}
+	case models.ScheduledJobActionCordon, models.ScheduledJobActionUncordon, models.ScheduledJobActionDrain:
+		if job.Target == "" && job.Selector == "" {
+			return fmt.Errorf("target or selector is required for %s", job.Action)
+		}
+		if job.Selector != "" {
+			if _, err := labels.Parse(job.Selector); err != nil {
+				return fmt.Errorf("invalid selector: %v", err)
+			}
+		}
+		if job.Action == models.ScheduledJobActionDrain && job.Params != "" {
+			var options node.DrainOptions
+			if err := json.Unmarshal([]byte(job.Params), &options); err != nil {
+				return fmt.Errorf("invalid drain options: %v", err)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported action %q", job.Action)
+	}
+	return nil
+}
+
+// IsNodeAction 节点操作影响整个集群，只允许管理员配置
+func IsNodeAction(action models.ScheduledJobAction) bool {
+	return action == models.ScheduledJobActionCordon || action == models.ScheduledJobActionUncordon ||
+		action == models.ScheduledJobActionDrain
+}
+
+// parseSchedule 解析标准 5 段 cron 表达式（分 时 日 月 周）和 @daily 等简写，使用任务的时区，时区为空时使用服务器时区
+func parseSchedule(job *models.ScheduledJob) (cron.Schedule, error) {
+	spec := job.Schedule
+	if job.TimeZone != "" {
+		if _, err := time.LoadLocation(job.TimeZone); err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %v", job.TimeZone, err)
+		}
+		spec = fmt.Sprintf("CRON_TZ=%s %s", job.TimeZone, spec)
+	}
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %v", job.Schedule, err)
+	}
+	return schedule, nil
+}
+
+// NextRunTime 计算 from 之后的下次执行时间
+func NextRunTime(job *models.ScheduledJob, from time.Time) (*time.Time, error) {
+	schedule, err := parseSchedule(job)
+	if err != nil {
+		return nil, err
+	}
+	next := schedule.Next(from)
+	if next.IsZero() {
+		return nil, fmt.Errorf("schedule %q never fires", job.Schedule)
+	}
+	return &next, nil
+}
+
+// execute 执行任务，返回执行结果说明
+func execute(ctx context.Context, job *models.ScheduledJob) (string, error) {
+	manager, err := client.Manager(job.Cluster)
+	if err != nil {
+		return "", err
+	}
+
+	switch job.Action {
+	case models.ScheduledJobActionRestart:
+		if err := rollout.Restart(ctx, manager.Client, job.Kind, job.Namespace, job.Target); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s %s/%s restarted", job.Kind, job.Namespace, job.Target), nil
+
+	case models.ScheduledJobActionScale:
+		var params ScaleParams
+		if err := json.Unmarshal([]byte(job.Params), &params); err != nil || params.Replicas == nil {
+			return "", fmt.Errorf("invalid scale params %q", job.Params)
+		}
+		resource, err := manager.KubeClient.GVRK(job.Kind)
+		if err != nil {
+			return "", err
+		}
+		result, err := scale.UpdateScale(ctx, manager.DynamicClient, resource.GroupVersionResourceKind.GroupVersionResource,
+			job.Namespace, job.Target, *params.Replicas, "")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s %s/%s scaled to %d replicas", job.Kind, job.Namespace, job.Target, result.Spec.Replicas), nil
+
+	case models.ScheduledJobActionPark, models.ScheduledJobActionRestore:
+		results, err := scale.Namespace(ctx, manager.Client, job.Namespace, scale.Action(job.Action))
+		if err != nil {
+			return "", err
+		}
+		return summarizeScale(results)
+
+	case models.ScheduledJobActionCordon, models.ScheduledJobActionUncordon, models.ScheduledJobActionDrain:
+		return executeNodeAction(ctx, manager.Client, job)
+	}
+	return "", fmt.Errorf("unsupported action %q", job.Action)
+}
+
+func summarizeScale(results []scale.Result) (string, error) {
+	var done, skipped int
+	var failed []string
+	for _, result := range results {
+		switch result.Status {
+		case scale.StatusDone:
+			done++
+		case scale.StatusSkipped:
+			skipped++
+		case scale.StatusFailed:
+			failed = append(failed, fmt.Sprintf("%s %s: %s", result.Kind, result.Name, result.Error))
+		}
+	}
+	message := fmt.Sprintf("%d scaled, %d skipped, %d failed", done, skipped, len(failed))
+	if len(failed) > 0 {
+		return "", fmt.Errorf("%s\n%s", message, strings.Join(failed, "\n"))
+	}
+	return message, nil
+}
+
+// executeNodeAction 对指定节点或标签选择器匹配的节点执行隔离、解除隔离或驱逐，单个节点失败不影响其它节点
+func executeNodeAction(ctx context.Context, clientset *kubernetes.Clientset, job *models.ScheduledJob) (string, error) {
+	var nodes []corev1.Node
+	if job.Target != "" {
+		n, err := node.GetNodeByName(clientset, job.Target)
+		if err != nil {
+			return "", err
+		}
+		nodes = append(nodes, *n)
+	} else {
+		list, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: job.Selector})
+		if err != nil {
+			return "", err
+		}
+		nodes = list.Items
+	}
+	if len(nodes) == 0 {
+		return "", fmt.Errorf("no nodes match selector %q", job.Selector)
+	}
+
+	drainOptions := &node.DrainOptions{IgnoreDaemonSets: true}
+	if job.Action == models.ScheduledJobActionDrain && job.Params != "" {
+		if err := json.Unmarshal([]byte(job.Params), drainOptions); err != nil {
+			return "", fmt.Errorf("invalid drain options: %v", err)
+		}
+	}
+
+	var names, failed []string
+	for i := range nodes {
+		n := &nodes[i]
+		if err := ctx.Err(); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", n.Name, err))
+			continue
+		}
+
+		var err error
+		switch job.Action {
+		case models.ScheduledJobActionCordon, models.ScheduledJobActionUncordon:
+			unschedulable := job.Action == models.ScheduledJobActionCordon
+			if n.Spec.Unschedulable != unschedulable {
+				n.Spec.Unschedulable = unschedulable
+				_, err = node.UpdateNode(clientset, n)
+			}
+		case models.ScheduledJobActionDrain:
+			err = node.DrainNode(clientset, n.Name, drainOptions)
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", n.Name, err))
+			continue
+		}
+		names = append(names, n.Name)
+	}
+
+	message := fmt.Sprintf("%s %d nodes: %s", job.Action, len(names), strings.Join(names, ", "))
+	if len(failed) > 0 {
+		return "", fmt.Errorf("%s\nfailed:\n%s", message, strings.Join(failed, "\n"))
+	}
+	return message, nil
+}
@@ -0,0 +1,195 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/JLPAY/gwayne/models"
+	"github.com/JLPAY/gwayne/pkg/config"
+	"k8s.io/klog/v2"
+)
+
+const (
+	leaseName = "scheduler"
+
+	defaultLeaseSeconds      = 30
+	defaultIntervalSeconds   = 10
+	defaultJobTimeoutSeconds = 600
+)
+
+// Scheduler 定时任务调度器，多个实例通过数据库租约选主，只有 leader 执行到期的任务
+type Scheduler struct {
+	instance   string
+	lease      time.Duration
+	interval   time.Duration
+	jobTimeout time.Duration
+
+	// 正在执行的任务，避免上一次执行尚未结束时重复执行
+	running sync.Map
+	ctx     context.Context
+	cancel  context.CancelFunc
+	started bool
+	wg      sync.WaitGroup
+	leader  bool
+}
+
+var defaultScheduler = newScheduler()
+
+func newScheduler() *Scheduler {
+	hostname, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		instance:   fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		jobTimeout: defaultJobTimeoutSeconds * time.Second,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Start 启动调度器，配置中未启用时不执行任何任务
+func Start() {
+	conf := config.Conf.Scheduler
+	if !conf.Enabled {
+		klog.Info("Scheduler is disabled")
+		return
+	}
+	defaultScheduler.lease = seconds(conf.LeaseSeconds, defaultLeaseSeconds)
+	defaultScheduler.interval = seconds(conf.IntervalSeconds, defaultIntervalSeconds)
+	defaultScheduler.jobTimeout = seconds(conf.JobTimeoutSeconds, defaultJobTimeoutSeconds)
+
+	defaultScheduler.started = true
+	go defaultScheduler.run(defaultScheduler.ctx)
+	klog.Infof("Scheduler started, instance: %s", defaultScheduler.instance)
+}
+
+// Stop 停止调度并释放租约，取消并等待正在执行的任务
+func Stop() {
+	defaultScheduler.cancel()
+	defaultScheduler.wg.Wait()
+	if !defaultScheduler.started {
+		return
+	}
+	if err := models.ReleaseSchedulerLease(leaseName, defaultScheduler.instance); err != nil {
+		klog.Errorf("Release scheduler lease error: %v", err)
+	}
+}
+
+// Trigger 立即执行一次任务，不影响下次执行时间，返回执行记录
+func Trigger(job *models.ScheduledJob, user string) (*models.ScheduledJobExecution, error) {
+	return defaultScheduler.execute(defaultScheduler.ctx, job, "manual:"+user)
+}
+
+func seconds(value, defaultValue int) time.Duration {
+	if value <= 0 {
+		value = defaultValue
+	}
+	return time.Duration(value) * time.Second
+}
+
+func (s *Scheduler) run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context) {
+	leader, err := models.AcquireSchedulerLease(leaseName, s.instance, s.lease)
+	if err != nil {
+		klog.Errorf("Acquire scheduler lease error: %v", err)
+		return
+	}
+	if leader != s.leader {
+		klog.Infof("Scheduler instance %s leader: %v", s.instance, leader)
+		s.leader = leader
+	}
+	if !leader {
+		return
+	}
+
+	now := time.Now()
+	jobs, err := models.GetDueScheduledJobs(now)
+	if err != nil {
+		klog.Errorf("Get due scheduled jobs error: %v", err)
+		return
+	}
+	for i := range jobs {
+		job := jobs[i]
+		next, err := NextRunTime(&job, now)
+		if err != nil {
+			klog.Errorf("Scheduled job %d has invalid schedule: %v", job.ID, err)
+			continue
+		}
+		// 先更新下次执行时间，切换 leader 后不会重复执行。停机期间错过的多次执行只补执行一次。
+		if err := models.UpdateScheduledJobNextRunTime(job.ID, next); err != nil {
+			klog.Errorf("Update next run time of scheduled job %d error: %v", job.ID, err)
+			continue
+		}
+		// 新建的任务还没有执行时间，只计算下次执行时间
+		if job.NextRunTime == nil {
+			continue
+		}
+		if _, err := s.execute(ctx, &job, s.instance); err != nil {
+			klog.Errorf("Execute scheduled job %d error: %v", job.ID, err)
+		}
+	}
+}
+
+// execute 记录执行开始并在后台执行任务
+func (s *Scheduler) execute(ctx context.Context, job *models.ScheduledJob, instance string) (*models.ScheduledJobExecution, error) {
+	if _, running := s.running.LoadOrStore(job.ID, struct{}{}); running {
+		return nil, fmt.Errorf("scheduled job %d is still running", job.ID)
+	}
+
+	execution := &models.ScheduledJobExecution{
+		JobID:     job.ID,
+		Action:    job.Action,
+		Instance:  instance,
+		Status:    models.ScheduledJobStatusRunning,
+		StartTime: time.Now(),
+	}
+	if err := models.AddScheduledJobExecution(execution); err != nil {
+		s.running.Delete(job.ID)
+		return nil, err
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.running.Delete(job.ID)
+
+		runCtx, cancel := context.WithTimeout(ctx, s.jobTimeout)
+		defer cancel()
+
+		result := *execution
+		message, err := execute(runCtx, job)
+		if err != nil {
+			result.Status = models.ScheduledJobStatusFailed
+			result.Message = err.Error()
+			klog.Errorf("Scheduled job %d (%s) failed: %v", job.ID, job.Action, err)
+		} else {
+			result.Status = models.ScheduledJobStatusSuccess
+			result.Message = message
+			klog.Infof("Scheduled job %d (%s) succeeded: %s", job.ID, job.Action, message)
+		}
+		end := time.Now()
+		result.EndTime = &end
+
+		if err := models.FinishScheduledJobExecution(&result); err != nil {
+			klog.Errorf("Save execution of scheduled job %d error: %v", job.ID, err)
+		}
+		if err := models.UpdateScheduledJobLastRun(job.ID, result.StartTime, result.Status); err != nil {
+			klog.Errorf("Update last run of scheduled job %d error: %v", job.ID, err)
+		}
+	}()
+	return execution, nil
+}
@@ -0,0 +1,89 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+)
+
+const debugContainerPrefix = "debugger-"
+
+var (
+	ErrImageNotAllowed    = errors.New("image is not allowed")
+	ErrDebugContainerFail = errors.New("debug container failed to start")
+)
+
+// AllowImage 检查调试镜像是否在白名单中，以 * 结尾的条目按前缀匹配，例如 registry.example.com/debug/*。
+// 白名单为空时不允许任何镜像。
+func AllowImage(allowlist []string, image string) error {
+	for _, allowed := range allowlist {
+		if prefix, ok := strings.CutSuffix(allowed, "*"); ok {
+			if strings.HasPrefix(image, prefix) {
+				return nil
+			}
+		} else if allowed == image {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrImageNotAllowed, image)
+}
+
+// NewDebugContainer 生成挂载到 Pod 上的临时调试容器，target 不为空时与该容器共享进程命名空间。
+// 临时容器添加后不能删除，退出后会一直保留在 Pod 中直到 Pod 被删除。
+func NewDebugContainer(pod *corev1.Pod, image, target string) (*corev1.EphemeralContainer, error) {
+	names := map[string]bool{}
+	for _, container := range pod.Spec.Containers {
+		names[container.Name] = true
+	}
+	if target != "" && !names[target] {
+		return nil, fmt.Errorf("target container %s not found in pod %s", target, pod.Name)
+	}
+	for _, container := range pod.Spec.InitContainers {
+		names[container.Name] = true
+	}
+	for _, container := range pod.Spec.EphemeralContainers {
+		names[container.Name] = true
+	}
+
+	name := debugContainerPrefix + utilrand.String(5)
+	for names[name] {
+		name = debugContainerPrefix + utilrand.String(5)
+	}
+	return &corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name:                     name,
+			Image:                    image,
+			ImagePullPolicy:          corev1.PullIfNotPresent,
+			TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+			// 保持 stdin 和 tty 打开，镜像默认的 shell 不会立即退出，终端通过 exec 连接
+			Stdin: true,
+			TTY:   true,
+		},
+		TargetContainerName: target,
+	}, nil
+}
+
+// EphemeralContainerReady 检查临时容器是否已经运行，容器启动失败时返回错误
+func EphemeralContainerReady(pod *corev1.Pod, name string) (bool, error) {
+	for _, status := range pod.Status.EphemeralContainerStatuses {
+		if status.Name != name {
+			continue
+		}
+		switch {
+		case status.State.Running != nil:
+			return true, nil
+		case status.State.Terminated != nil:
+			return false, fmt.Errorf("%w: %s terminated: %s %s", ErrDebugContainerFail, name, status.State.Terminated.Reason, status.State.Terminated.Message)
+		case status.State.Waiting != nil:
+			switch reason := status.State.Waiting.Reason; reason {
+			case "ErrImagePull", "ImagePullBackOff", "InvalidImageName", "CreateContainerError":
+				return false, fmt.Errorf("%w: %s %s %s", ErrDebugContainerFail, name, reason, status.State.Waiting.Message)
+			}
+		}
+		return false, nil
+	}
+	return false, nil
+}
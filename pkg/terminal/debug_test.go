@@ -0,0 +1,60 @@
+package terminal
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAllowImage(t *testing.T) {
+	allowlist := ParseList("busybox:1.36, registry.example.com/debug/*")
+	for image, ok := range map[string]bool{
+		"busybox:1.36":                         true,
+		"busybox:latest":                       false,
+		"registry.example.com/debug/netshoot":  true,
+		"registry.example.com/debugger/attack": false,
+	} {
+		if err := AllowImage(allowlist, image); (err == nil) != ok {
+			t.Errorf("AllowImage(%s) = %v", image, err)
+		}
+	}
+	if err := AllowImage(nil, "busybox:1.36"); !errors.Is(err, ErrImageNotAllowed) {
+		t.Errorf("expected ErrImageNotAllowed with empty allowlist, got %v", err)
+	}
+}
+
+func TestNewDebugContainer(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+	}
+	if _, err := NewDebugContainer(pod, "busybox:1.36", "missing"); err == nil {
+		t.Error("expected error for missing target container")
+	}
+	container, err := NewDebugContainer(pod, "busybox:1.36", "app")
+	if err != nil {
+		t.Fatalf("NewDebugContainer error: %v", err)
+	}
+	if !strings.HasPrefix(container.Name, debugContainerPrefix) || container.TargetContainerName != "app" || !container.Stdin || !container.TTY {
+		t.Errorf("unexpected container %+v", container)
+	}
+
+	pod.Status.EphemeralContainerStatuses = []corev1.ContainerStatus{{
+		Name:  container.Name,
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+	}}
+	if ready, err := EphemeralContainerReady(pod, container.Name); ready || err != nil {
+		t.Errorf("unexpected state %v %v", ready, err)
+	}
+	pod.Status.EphemeralContainerStatuses[0].State.Waiting.Reason = "ImagePullBackOff"
+	if _, err := EphemeralContainerReady(pod, container.Name); !errors.Is(err, ErrDebugContainerFail) {
+		t.Error("expected error for image pull failure")
+	}
+	pod.Status.EphemeralContainerStatuses[0].State = corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	if ready, err := EphemeralContainerReady(pod, container.Name); !ready || err != nil {
+		t.Errorf("unexpected state %v %v", ready, err)
+	}
+}
@@ -0,0 +1,130 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// NodeShellLabel 节点终端 Pod 的标签，用于清理残留的 Pod
+	NodeShellLabel = "gwayne.io/node-shell"
+	// NodeShellUserAnnotation 打开节点终端的用户
+	NodeShellUserAnnotation = "gwayne.io/node-shell-user"
+	// NodeShellHeartbeatAnnotation 会话存活期间定期更新，gwayne 异常退出后不再更新
+	NodeShellHeartbeatAnnotation = "gwayne.io/node-shell-heartbeat"
+	NodeShellContainer           = "shell"
+	// NodeShellHeartbeatInterval 更新心跳和检查残留 Pod 的间隔
+	NodeShellHeartbeatInterval = time.Minute
+	// NodeShellStaleAfter 心跳超过该时间没有更新的 Pod 会被清理，需要大于 ticket 有效期和等待 Pod 启动的时间
+	NodeShellStaleAfter = 5 * time.Minute
+	// 节点的根文件系统在 Pod 中的挂载点
+	nodeShellRoot = "/host"
+)
+
+// NodeShellOptions 节点终端 Pod 的参数
+type NodeShellOptions struct {
+	Node      string
+	Namespace string
+	Image     string
+	User      string
+	// Pod 的最长存活时间，超过后由 kubelet 停止
+	Lifetime time.Duration
+}
+
+// NewNodeShellPod 生成调度到指定节点的特权 Pod，共享节点的 PID 和网络命名空间，节点的根文件系统挂载在 /host
+func NewNodeShellPod(opt NodeShellOptions) *corev1.Pod {
+	privileged := true
+	gracePeriod := int64(0)
+	deadline := int64(opt.Lifetime / time.Second)
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "node-shell-",
+			Namespace:    opt.Namespace,
+			Labels:       map[string]string{NodeShellLabel: "true"},
+			Annotations: map[string]string{
+				NodeShellUserAnnotation:      opt.User,
+				NodeShellHeartbeatAnnotation: time.Now().UTC().Format(time.RFC3339),
+			},
+		},
+		Spec: corev1.PodSpec{
+			// 直接指定节点，不经过调度器，节点不可调度时也可以使用
+			NodeName:                      opt.Node,
+			HostPID:                       true,
+			HostNetwork:                   true,
+			HostIPC:                       true,
+			RestartPolicy:                 corev1.RestartPolicyNever,
+			TerminationGracePeriodSeconds: &gracePeriod,
+			ActiveDeadlineSeconds:         &deadline,
+			AutomountServiceAccountToken:  new(bool),
+			Tolerations:                   []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			Containers: []corev1.Container{{
+				Name:            NodeShellContainer,
+				Image:           opt.Image,
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				Command:         []string{"sleep", strconv.FormatInt(deadline, 10)},
+				SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+				VolumeMounts:    []corev1.VolumeMount{{Name: "host", MountPath: nodeShellRoot}},
+			}},
+			Volumes: []corev1.Volume{{
+				Name:         "host",
+				VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/"}},
+			}},
+		},
+	}
+}
+
+// AllowNodeShellPod 节点终端的 Pod 拥有节点的 root 权限，不论在哪个命名空间，只有管理员可以访问
+func AllowNodeShellPod(admin bool, pod *corev1.Pod) error {
+	if admin || pod.Labels[NodeShellLabel] != "true" {
+		return nil
+	}
+	return fmt.Errorf("%w: only admin is allowed to access node shell pod %s", ErrPermissionDenied, pod.Name)
+}
+
+// NodeShellCommand 通过 chroot 进入节点的根文件系统，shell 为空时优先使用节点上的 bash
+func NodeShellCommand(shell string) []string {
+	if shell != "" {
+		return []string{"chroot", nodeShellRoot, shell}
+	}
+	return []string{"chroot", nodeShellRoot, "sh", "-c", "if command -v bash >/dev/null 2>&1; then exec bash; else exec sh; fi"}
+}
+
+// NodeShellExpired 判断节点终端 Pod 是否需要清理：Pod 已经结束，或者心跳超过 staleAfter 没有更新
+func NodeShellExpired(pod *corev1.Pod, now time.Time, staleAfter time.Duration) bool {
+	if pod.DeletionTimestamp != nil {
+		return false
+	}
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return true
+	}
+	heartbeat := pod.CreationTimestamp.Time
+	if t, err := time.Parse(time.RFC3339, pod.Annotations[NodeShellHeartbeatAnnotation]); err == nil && t.After(heartbeat) {
+		heartbeat = t
+	}
+	return now.Sub(heartbeat) >= staleAfter
+}
+
+// TouchNodeShellPod 更新节点终端 Pod 的心跳
+func TouchNodeShellPod(ctx context.Context, cli kubernetes.Interface, namespace, name string, now time.Time) error {
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, NodeShellHeartbeatAnnotation, now.UTC().Format(time.RFC3339))
+	_, err := cli.CoreV1().Pods(namespace).Patch(ctx, name, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+	return err
+}
+
+// DeleteNodeShellPod 立即删除节点终端 Pod，Pod 不存在时不返回错误
+func DeleteNodeShellPod(ctx context.Context, cli kubernetes.Interface, namespace, name string) error {
+	gracePeriod := int64(0)
+	err := cli.CoreV1().Pods(namespace).Delete(ctx, name, metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package terminal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNewNodeShellPod(t *testing.T) {
+	pod := NewNodeShellPod(NodeShellOptions{Node: "node-1", Namespace: "kube-system", Image: "busybox:1.36", User: "admin", Lifetime: time.Hour})
+	spec := pod.Spec
+	if spec.NodeName != "node-1" || !spec.HostPID || !spec.HostNetwork || *spec.ActiveDeadlineSeconds != 3600 {
+		t.Errorf("unexpected pod spec %+v", spec)
+	}
+	container := spec.Containers[0]
+	if container.Name != NodeShellContainer || !*container.SecurityContext.Privileged || container.VolumeMounts[0].MountPath != nodeShellRoot {
+		t.Errorf("unexpected container %+v", container)
+	}
+	if pod.Labels[NodeShellLabel] != "true" || pod.Annotations[NodeShellUserAnnotation] != "admin" {
+		t.Errorf("unexpected metadata %+v", pod.ObjectMeta)
+	}
+}
+
+func TestAllowNodeShellPod(t *testing.T) {
+	// 节点终端的 Pod 不在受保护的命名空间中，也只有管理员可以访问
+	pod := NewNodeShellPod(NodeShellOptions{Node: "node-1", Namespace: "default", Image: "busybox:1.36", User: "admin", Lifetime: time.Hour})
+	if err := AllowNodeShellPod(false, pod); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("expected ErrPermissionDenied, got %v", err)
+	}
+	if err := AllowNodeShellPod(true, pod); err != nil {
+		t.Errorf("admin should be allowed, got %v", err)
+	}
+	if err := AllowNodeShellPod(false, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "default"}}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestNodeShellExpired(t *testing.T) {
+	now := time.Now()
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(now.Add(-time.Hour))},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	if !NodeShellExpired(pod, now, NodeShellStaleAfter) {
+		t.Error("pod without heartbeat should expire")
+	}
+	pod.Annotations = map[string]string{NodeShellHeartbeatAnnotation: now.Add(-time.Minute).UTC().Format(time.RFC3339)}
+	if NodeShellExpired(pod, now, NodeShellStaleAfter) {
+		t.Error("pod with recent heartbeat should not expire")
+	}
+	pod.Status.Phase = corev1.PodFailed
+	if !NodeShellExpired(pod, now, NodeShellStaleAfter) {
+		t.Error("finished pod should expire")
+	}
+}
+
+func TestTouchAndDeleteNodeShellPod(t *testing.T) {
+	ctx := context.Background()
+	cli := fake.NewSimpleClientset(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "node-shell-x", Namespace: "kube-system"}})
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := TouchNodeShellPod(ctx, cli, "kube-system", "node-shell-x", now); err != nil {
+		t.Fatalf("TouchNodeShellPod error: %v", err)
+	}
+	pod, _ := cli.CoreV1().Pods("kube-system").Get(ctx, "node-shell-x", metav1.GetOptions{})
+	if pod.Annotations[NodeShellHeartbeatAnnotation] != "2024-01-02T03:04:05Z" {
+		t.Errorf("unexpected annotations %v", pod.Annotations)
+	}
+	for i := 0; i < 2; i++ {
+		if err := DeleteNodeShellPod(ctx, cli, "kube-system", "node-shell-x"); err != nil {
+			t.Errorf("DeleteNodeShellPod error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+var ErrPermissionDenied = errors.New("permission denied")
+
+// Shells 允许在终端中执行的命令
+var Shells = []string{"bash", "sh"}
+
+// Policy 打开终端的权限策略，端口转发也使用相同的策略
+type Policy struct {
+	// 只有管理员可以打开终端
+	AdminOnly bool
+	// 只有管理员可以打开终端的命名空间，例如 kube-system
+	ProtectedNamespaces []string
+}
+
+// ParseList 解析以逗号分隔的列表，例如命名空间和镜像
+func ParseList(value string) []string {
+	namespaces := make([]string, 0)
+	for _, ns := range strings.Split(value, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces
+}
+
+// Allow 检查用户是否可以访问命名空间下的容器
+func (p Policy) Allow(admin bool, namespace string) error {
+	if admin {
+		return nil
+	}
+	if p.AdminOnly {
+		return fmt.Errorf("%w: only admin is allowed", ErrPermissionDenied)
+	}
+	for _, ns := range p.ProtectedNamespaces {
+		if ns == namespace {
+			return fmt.Errorf("%w: only admin is allowed in namespace %s", ErrPermissionDenied, namespace)
+		}
+	}
+	return nil
+}
+
+// ValidateCommand 检查终端命令，为空时自动检测容器中可用的 shell
+func ValidateCommand(cmd string) error {
+	if cmd == "" {
+		return nil
+	}
+	for _, shell := range Shells {
+		if shell == cmd {
+			return nil
+		}
+	}
+	return fmt.Errorf("command %q is not allowed, expected one of %s", cmd, strings.Join(Shells, ", "))
+}
+
+// ValidateContainer 检查容器是否属于 Pod 并且正在运行
+func ValidateContainer(pod *corev1.Pod, container string) error {
+	if pod.DeletionTimestamp != nil {
+		return fmt.Errorf("pod %s is terminating", pod.Name)
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		return fmt.Errorf("pod %s is %s, not running", pod.Name, pod.Status.Phase)
+	}
+	for _, statuses := range [][]corev1.ContainerStatus{pod.Status.ContainerStatuses, pod.Status.EphemeralContainerStatuses} {
+		for _, status := range statuses {
+			if status.Name != container {
+				continue
+			}
+			if status.State.Running == nil {
+				return fmt.Errorf("container %s is not running", container)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("container %s not found in pod %s", container, pod.Name)
+}
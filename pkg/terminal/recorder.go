@@ -0,0 +1,200 @@
+package terminal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultWidth  = 80
+	defaultHeight = 24
+)
+
+// Header asciinema v2 录像的文件头
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// RecorderOptions 录像选项
+type RecorderOptions struct {
+	Title string
+	// 是否记录用户输入，输入中可能包含密码等敏感信息
+	RecordInput bool
+	// 录像文件的最大字节数，超过后不再记录，0 表示不限制
+	MaxBytes int64
+}
+
+// Recorder 以 asciinema v2 格式（https://docs.asciinema.org/manual/asciicast/v2/）录制终端会话，
+// 每行一个事件：[距离开始的秒数, "o" | "i" | "r", 数据]。
+// 文件头需要终端尺寸，在第一次输出时写入，之前收到的尺寸调整直接作为初始尺寸。
+type Recorder struct {
+	mu       sync.Mutex
+	w        io.WriteCloser
+	buf      *bufio.Writer
+	opts     RecorderOptions
+	start    time.Time
+	width    int
+	height   int
+	started  bool
+	written  int64
+	truncate bool
+	err      error
+	// 不完整的 UTF-8 字符留到下一次写入
+	pendingOutput []byte
+	pendingInput  []byte
+}
+
+// NewRecorder 创建录像，Close 时关闭 w
+func NewRecorder(w io.WriteCloser, opts RecorderOptions) *Recorder {
+	return &Recorder{
+		w:      w,
+		buf:    bufio.NewWriter(w),
+		opts:   opts,
+		start:  time.Now(),
+		width:  defaultWidth,
+		height: defaultHeight,
+	}
+}
+
+// Output 记录终端输出
+func (r *Recorder) Output(p []byte) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pendingOutput = r.writeData("o", r.pendingOutput, p)
+}
+
+// Input 记录用户输入，未开启 RecordInput 时忽略
+func (r *Recorder) Input(p []byte) {
+	if r == nil || !r.opts.RecordInput {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pendingInput = r.writeData("i", r.pendingInput, p)
+}
+
+// Resize 记录终端尺寸变化
+func (r *Recorder) Resize(cols, rows uint16) {
+	if r == nil || cols == 0 || rows == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.started {
+		r.width, r.height = int(cols), int(rows)
+		return
+	}
+	r.writeEvent("r", fmt.Sprintf("%dx%d", cols, rows))
+}
+
+func (r *Recorder) writeData(kind string, pending, p []byte) []byte {
+	data := append(pending, p...)
+	n := len(data) - incompleteSuffix(data)
+	if n > 0 {
+		r.writeEvent(kind, string(data[:n]))
+	}
+	return append([]byte(nil), data[n:]...)
+}
+
+func (r *Recorder) writeEvent(kind, data string) {
+	if r.err != nil || r.truncate {
+		return
+	}
+	if !r.started {
+		r.started = true
+		header, _ := json.Marshal(Header{
+			Version:   2,
+			Width:     r.width,
+			Height:    r.height,
+			Timestamp: r.start.Unix(),
+			Title:     r.opts.Title,
+			Env:       map[string]string{"TERM": "xterm"},
+		})
+		if !r.writeLine(header) {
+			return
+		}
+	}
+	elapsed := time.Since(r.start).Seconds()
+	event, _ := json.Marshal([]interface{}{json.Number(fmt.Sprintf("%.6f", elapsed)), kind, data})
+	r.writeLine(event)
+}
+
+func (r *Recorder) writeLine(line []byte) bool {
+	if r.opts.MaxBytes > 0 && r.written+int64(len(line))+1 > r.opts.MaxBytes {
+		r.truncate = true
+		return false
+	}
+	if _, err := r.buf.Write(append(line, '\n')); err != nil {
+		r.err = err
+		return false
+	}
+	r.written += int64(len(line)) + 1
+	return true
+}
+
+// Close 写入剩余的事件并关闭存储
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.pendingOutput) > 0 {
+		r.writeEvent("o", string(r.pendingOutput))
+		r.pendingOutput = nil
+	}
+	if r.err == nil {
+		r.err = r.buf.Flush()
+	}
+	if err := r.w.Close(); r.err == nil {
+		r.err = err
+	}
+	return r.err
+}
+
+// Bytes 已经写入的字节数
+func (r *Recorder) Bytes() int64 {
+	if r == nil {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.written
+}
+
+// Truncated 录像是否因为超过 MaxBytes 而不完整
+func (r *Recorder) Truncated() bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.truncate
+}
+
+// incompleteSuffix 返回末尾被截断的 UTF-8 字符的字节数，非法的字节不保留
+func incompleteSuffix(p []byte) int {
+	for i := 1; i < utf8.UTFMax && i <= len(p); i++ {
+		c := p[len(p)-i]
+		if utf8.RuneStart(c) {
+			if !utf8.FullRune(p[len(p)-i:]) {
+				return i
+			}
+			return 0
+		}
+	}
+	return 0
+}
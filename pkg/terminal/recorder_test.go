@@ -0,0 +1,136 @@
+package terminal
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readCast(t *testing.T, r io.Reader) (Header, [][]interface{}) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		t.Fatal("empty recording")
+	}
+	var header Header
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		t.Fatalf("invalid header %s: %v", scanner.Text(), err)
+	}
+	events := make([][]interface{}, 0)
+	for scanner.Scan() {
+		var event []interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("invalid event %s: %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+	return header, events
+}
+
+func TestRecorder(t *testing.T) {
+	store := NewMemoryStore()
+	key := RecordingKey("abc", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	if key != "2024/01/02/abc.cast" {
+		t.Errorf("unexpected key %s", key)
+	}
+	w, err := store.Create(key)
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+
+	r := NewRecorder(w, RecorderOptions{Title: "web-0", RecordInput: true})
+	r.Resize(120, 40)
+	r.Output([]byte("$ "))
+	r.Input([]byte("ls\r"))
+	// "中" 被拆分到两次输出中
+	r.Output([]byte{0xe4, 0xb8})
+	r.Output([]byte{0xad, '\n'})
+	r.Resize(100, 30)
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+
+	f, err := store.Open(key)
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	header, events := readCast(t, f)
+	if header.Version != 2 || header.Width != 120 || header.Height != 40 || header.Title != "web-0" {
+		t.Errorf("unexpected header %+v", header)
+	}
+	expected := [][2]string{{"o", "$ "}, {"i", "ls\r"}, {"o", "中\n"}, {"r", "100x30"}}
+	if len(events) != len(expected) {
+		t.Fatalf("unexpected events %v", events)
+	}
+	for i, e := range expected {
+		if events[i][1] != e[0] || events[i][2] != e[1] {
+			t.Errorf("event %d = %v, expected %v", i, events[i], e)
+		}
+		if _, ok := events[i][0].(float64); !ok {
+			t.Errorf("event %d time is not a number: %v", i, events[i][0])
+		}
+	}
+}
+
+func TestRecorderLimits(t *testing.T) {
+	store := NewMemoryStore()
+	w, _ := store.Create("a.cast")
+	r := NewRecorder(w, RecorderOptions{MaxBytes: 200})
+	r.Input([]byte("secret"))
+	for i := 0; i < 10; i++ {
+		r.Output([]byte(strings.Repeat("x", 20)))
+	}
+	r.Close()
+	if !r.Truncated() || r.Bytes() > 200 {
+		t.Errorf("expected truncated recording, got %d bytes", r.Bytes())
+	}
+
+	f, _ := store.Open("a.cast")
+	_, events := readCast(t, f)
+	for _, event := range events {
+		if event[1] == "i" {
+			t.Errorf("input should not be recorded: %v", event)
+		}
+	}
+	if len(events) == 0 || len(events) >= 10 {
+		t.Errorf("unexpected events count %d", len(events))
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewStore(StorageLocal, t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore error: %v", err)
+	}
+	for _, key := range []string{"../a.cast", "/etc/passwd", "a/../../b", ""} {
+		if _, err := store.Create(key); err == nil {
+			t.Errorf("expected error for key %q", key)
+		}
+	}
+
+	w, err := store.Create("2024/01/02/a.cast")
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+	io.WriteString(w, "data")
+	w.Close()
+
+	f, err := store.Open("2024/01/02/a.cast")
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	data, _ := io.ReadAll(f)
+	f.Close()
+	if string(data) != "data" {
+		t.Errorf("unexpected data %q", data)
+	}
+
+	if err := store.Delete("2024/01/02/a.cast"); err != nil {
+		t.Errorf("Delete error: %v", err)
+	}
+	if _, err := store.Open("2024/01/02/a.cast"); err != ErrRecordingNotFound {
+		t.Errorf("expected ErrRecordingNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,297 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// 旁观者加入时补发的最近输出
+	recentOutputBytes = 64 * 1024
+	// 每个旁观者缓存的输出块数，写满时断开旁观者，不阻塞终端会话
+	shadowBufferChunks = 256
+	// 检查空闲和超时的间隔
+	watchdogInterval = 5 * time.Second
+)
+
+var (
+	ErrTooManySessions = errors.New("too many terminal sessions")
+	ErrSessionNotFound = errors.New("terminal session not found")
+)
+
+// Limits 终端会话限制，0 表示不限制
+type Limits struct {
+	// 没有输入超过该时间后关闭会话
+	IdleTimeout time.Duration
+	// 会话的最长持续时间
+	MaxDuration time.Duration
+	// 每个用户、每个集群同时打开的会话数量
+	MaxPerUser    int
+	MaxPerCluster int
+}
+
+// SessionInfo 活动的终端会话
+type SessionInfo struct {
+	ID         string    `json:"id"`
+	User       string    `json:"user"`
+	Cluster    string    `json:"cluster"`
+	Namespace  string    `json:"namespace"`
+	Pod        string    `json:"pod"`
+	Container  string    `json:"container"`
+	Command    string    `json:"command,omitempty"`
+	StartTime  time.Time `json:"startTime"`
+	LastActive time.Time `json:"lastActive"`
+	Shadows    int       `json:"shadows"`
+}
+
+// Session 注册的终端会话，记录活动时间并向旁观者转发输出
+type Session struct {
+	mu      sync.Mutex
+	info    SessionInfo
+	closer  func(reason string)
+	done    chan struct{}
+	closed  bool
+	recent  []byte
+	shadows map[chan []byte]bool
+	// 不完整的 UTF-8 字符留到下一次输出，旁观者收到的每块输出都是完整的字符
+	pending []byte
+}
+
+// Touch 记录用户活动，用于判断会话是否空闲
+func (s *Session) Touch() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.info.LastActive = time.Now()
+}
+
+// Broadcast 将终端输出转发给旁观者，旁观者消费过慢时断开，不会阻塞会话
+func (s *Session) Broadcast(p []byte) {
+	if s == nil || len(p) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data := append(s.pending, p...)
+	n := len(data) - incompleteSuffix(data)
+	s.pending = append([]byte(nil), data[n:]...)
+	if n == 0 {
+		return
+	}
+	p = data[:n]
+
+	s.recent = append(s.recent, p...)
+	if over := len(s.recent) - recentOutputBytes; over > 0 {
+		s.recent = append(s.recent[:0], s.recent[over:]...)
+	}
+	if len(s.shadows) == 0 {
+		return
+	}
+	chunk := append([]byte(nil), p...)
+	for ch := range s.shadows {
+		select {
+		case ch <- chunk:
+		default:
+			delete(s.shadows, ch)
+			close(ch)
+		}
+	}
+}
+
+// Shadow 以只读方式旁观会话，返回最近的输出和后续输出的通道，会话结束或旁观者过慢时通道关闭。
+// 调用 cancel 停止旁观。
+func (s *Session) Shadow() (recent []byte, output <-chan []byte, cancel func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch := make(chan []byte, shadowBufferChunks)
+	if s.closed {
+		close(ch)
+		return nil, ch, func() {}
+	}
+	s.shadows[ch] = true
+	cancel = func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.shadows[ch] {
+			delete(s.shadows, ch)
+			close(ch)
+		}
+	}
+	return append([]byte(nil), s.recent...), ch, cancel
+}
+
+// Done 会话结束后关闭
+func (s *Session) Done() <-chan struct{} {
+	if s == nil {
+		return nil
+	}
+	return s.done
+}
+
+// Info 返回会话信息
+func (s *Session) Info() SessionInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info := s.info
+	info.Shadows = len(s.shadows)
+	return info
+}
+
+// Terminate 关闭会话，reason 会发送给客户端
+func (s *Session) Terminate(reason string) {
+	s.closer(reason)
+}
+
+func (s *Session) finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.done)
+	for ch := range s.shadows {
+		delete(s.shadows, ch)
+		close(ch)
+	}
+}
+
+// Registry 记录当前实例上活动的终端会话，多实例部署时每个实例分别限制
+type Registry struct {
+	mu       sync.Mutex
+	limits   func() Limits
+	sessions map[string]*Session
+}
+
+// NewRegistry 创建 Registry，limits 在每次检查时调用，配置修改后立即生效
+func NewRegistry(limits func() Limits) *Registry {
+	return &Registry{limits: limits, sessions: map[string]*Session{}}
+}
+
+// Check 检查用户是否还可以打开新的会话
+func (r *Registry) Check(user, cluster string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.check(r.limits(), user, cluster)
+}
+
+func (r *Registry) check(limits Limits, user, cluster string) error {
+	var userCount, clusterCount int
+	for _, s := range r.sessions {
+		if s.info.User == user {
+			userCount++
+		}
+		if s.info.Cluster == cluster {
+			clusterCount++
+		}
+	}
+	if limits.MaxPerUser > 0 && userCount >= limits.MaxPerUser {
+		return fmt.Errorf("%w: user %s already has %d sessions", ErrTooManySessions, user, userCount)
+	}
+	if limits.MaxPerCluster > 0 && clusterCount >= limits.MaxPerCluster {
+		return fmt.Errorf("%w: cluster %s already has %d sessions", ErrTooManySessions, cluster, clusterCount)
+	}
+	return nil
+}
+
+// Register 注册会话，超过限制时返回 ErrTooManySessions。
+// closer 用于关闭会话（空闲超时、超过最长时间或者被管理员关闭），会话结束后需要调用 Unregister。
+func (r *Registry) Register(info SessionInfo, closer func(reason string)) (*Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	limits := r.limits()
+	if err := r.check(limits, info.User, info.Cluster); err != nil {
+		return nil, err
+	}
+	if _, ok := r.sessions[info.ID]; ok {
+		return nil, fmt.Errorf("terminal session %s already exists", info.ID)
+	}
+
+	now := time.Now()
+	info.StartTime, info.LastActive = now, now
+	s := &Session{
+		info:    info,
+		closer:  closer,
+		done:    make(chan struct{}),
+		shadows: map[chan []byte]bool{},
+	}
+	r.sessions[info.ID] = s
+	go watchdog(s, limits)
+	return s, nil
+}
+
+// Unregister 会话结束后移除会话并断开所有旁观者
+func (r *Registry) Unregister(id string) {
+	r.mu.Lock()
+	s, ok := r.sessions[id]
+	delete(r.sessions, id)
+	r.mu.Unlock()
+	if ok {
+		s.finish()
+	}
+}
+
+// Get 获取活动的会话
+func (r *Registry) Get(id string) (*Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.sessions[id]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return s, nil
+}
+
+// List 返回所有活动的会话，按开始时间排序
+func (r *Registry) List() []SessionInfo {
+	r.mu.Lock()
+	sessions := make([]*Session, 0, len(r.sessions))
+	for _, s := range r.sessions {
+		sessions = append(sessions, s)
+	}
+	r.mu.Unlock()
+
+	infos := make([]SessionInfo, 0, len(sessions))
+	for _, s := range sessions {
+		infos = append(infos, s.Info())
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].StartTime.Before(infos[j].StartTime)
+	})
+	return infos
+}
+
+// watchdog 关闭空闲超时和超过最长时间的会话
+func watchdog(s *Session, limits Limits) {
+	if limits.IdleTimeout <= 0 && limits.MaxDuration <= 0 {
+		return
+	}
+	ticker := time.NewTicker(watchdogInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			if reason := expired(s.Info(), limits, now); reason != "" {
+				s.Terminate(reason)
+				return
+			}
+		}
+	}
+}
+
+func expired(info SessionInfo, limits Limits, now time.Time) string {
+	if limits.MaxDuration > 0 && now.Sub(info.StartTime) >= limits.MaxDuration {
+		return fmt.Sprintf("session exceeded the max duration of %s", limits.MaxDuration)
+	}
+	if limits.IdleTimeout > 0 && now.Sub(info.LastActive) >= limits.IdleTimeout {
+		return fmt.Sprintf("session idle for more than %s", limits.IdleTimeout)
+	}
+	return ""
+}
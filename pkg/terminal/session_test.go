@@ -0,0 +1,132 @@
+package terminal
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistryLimits(t *testing.T) {
+	registry := NewRegistry(func() Limits {
+		return Limits{MaxPerUser: 2, MaxPerCluster: 3}
+	})
+	register := func(id, user, cluster string) error {
+		_, err := registry.Register(SessionInfo{ID: id, User: user, Cluster: cluster}, func(string) {})
+		return err
+	}
+
+	if err := register("1", "alice", "dev"); err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+	if err := register("1", "bob", "dev"); err == nil {
+		t.Error("expected error for duplicate session id")
+	}
+	if err := register("2", "alice", "dev"); err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+	if err := registry.Check("alice", "prod"); !errors.Is(err, ErrTooManySessions) {
+		t.Errorf("expected per user limit, got %v", err)
+	}
+	if err := register("3", "bob", "dev"); err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+	if err := register("4", "carol", "dev"); !errors.Is(err, ErrTooManySessions) {
+		t.Errorf("expected per cluster limit, got %v", err)
+	}
+
+	registry.Unregister("1")
+	if err := registry.Check("alice", "dev"); err != nil {
+		t.Errorf("unexpected error after unregister %v", err)
+	}
+	if infos := registry.List(); len(infos) != 2 || infos[0].ID != "2" {
+		t.Errorf("unexpected sessions %+v", infos)
+	}
+	if _, err := registry.Get("1"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected ErrSessionNotFound, got %v", err)
+	}
+}
+
+func TestSessionShadow(t *testing.T) {
+	registry := NewRegistry(func() Limits { return Limits{} })
+	var reason string
+	s, err := registry.Register(SessionInfo{ID: "1", User: "alice"}, func(r string) { reason = r })
+	if err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+
+	s.Broadcast([]byte("before"))
+	recent, output, cancel := s.Shadow()
+	if string(recent) != "before" || s.Info().Shadows != 1 {
+		t.Errorf("unexpected recent output %q", recent)
+	}
+	s.Broadcast([]byte("after"))
+	if chunk := <-output; string(chunk) != "after" {
+		t.Errorf("unexpected output %q", chunk)
+	}
+	cancel()
+	if _, ok := <-output; ok {
+		t.Error("output should be closed after cancel")
+	}
+	cancel()
+
+	// "中" 被拆分到两次输出中
+	_, output, cancel = s.Shadow()
+	s.Broadcast([]byte{0xe4, 0xb8})
+	s.Broadcast([]byte{0xad})
+	if chunk := <-output; string(chunk) != "中" {
+		t.Errorf("unexpected output %q", chunk)
+	}
+	cancel()
+
+	// 旁观者消费过慢时断开，不阻塞会话
+	_, slow, _ := s.Shadow()
+	for i := 0; i < shadowBufferChunks+1; i++ {
+		s.Broadcast([]byte("x"))
+	}
+	if s.Info().Shadows != 0 {
+		t.Error("slow shadow should be removed")
+	}
+	for range slow {
+	}
+
+	// 最近输出只保留 recentOutputBytes
+	s.Broadcast([]byte(strings.Repeat("y", recentOutputBytes+10)))
+	recent, output, _ = s.Shadow()
+	if len(recent) != recentOutputBytes {
+		t.Errorf("unexpected recent output size %d", len(recent))
+	}
+
+	s.Terminate("killed by admin")
+	if reason != "killed by admin" {
+		t.Errorf("unexpected reason %q", reason)
+	}
+	registry.Unregister("1")
+	select {
+	case <-s.Done():
+	default:
+		t.Error("session should be done after unregister")
+	}
+	if _, ok := <-output; ok {
+		t.Error("shadow output should be closed when session ends")
+	}
+}
+
+func TestExpired(t *testing.T) {
+	start := time.Now()
+	info := SessionInfo{StartTime: start, LastActive: start.Add(time.Minute)}
+	limits := Limits{IdleTimeout: 10 * time.Minute, MaxDuration: time.Hour}
+	if reason := expired(info, limits, start.Add(5*time.Minute)); reason != "" {
+		t.Errorf("unexpected reason %q", reason)
+	}
+	if reason := expired(info, limits, start.Add(11*time.Minute)); !strings.Contains(reason, "idle") {
+		t.Errorf("expected idle timeout, got %q", reason)
+	}
+	info.LastActive = start.Add(59 * time.Minute)
+	if reason := expired(info, limits, start.Add(time.Hour)); !strings.Contains(reason, "max duration") {
+		t.Errorf("expected max duration, got %q", reason)
+	}
+	if reason := expired(info, Limits{}, start.Add(24*time.Hour)); reason != "" {
+		t.Errorf("unexpected reason without limits %q", reason)
+	}
+}
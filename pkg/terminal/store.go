@@ -0,0 +1,153 @@
+package terminal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	StorageLocal  = "local"
+	StorageMemory = "memory"
+)
+
+var (
+	ErrRecordingNotFound = errors.New("recording not found")
+	ErrInvalidKey        = errors.New("invalid recording key")
+)
+
+// Store 录像存储，key 为以 / 分隔的相对路径
+type Store interface {
+	// Create 创建录像，写入的内容在 Close 之后才保证可以读取
+	Create(key string) (io.WriteCloser, error)
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// NewStore 根据配置创建录像存储，dir 只在本地存储时使用
+func NewStore(storage, dir string) (Store, error) {
+	switch storage {
+	case "", StorageLocal:
+		if dir == "" {
+			return nil, errors.New("recording dir is required for local storage")
+		}
+		return &LocalStore{Dir: dir}, nil
+	case StorageMemory:
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unsupported recording storage %q", storage)
+	}
+}
+
+// RecordingKey 录像的存储路径，按日期分目录：2006/01/02/<sessionId>.cast
+func RecordingKey(sessionId string, t time.Time) string {
+	return path.Join(t.Format("2006/01/02"), sessionId+".cast")
+}
+
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.ContainsRune(key, 0) || path.Clean(key) != key ||
+		key == ".." || strings.HasPrefix(key, "../") {
+		return ErrInvalidKey
+	}
+	return nil
+}
+
+// LocalStore 存储到本地磁盘，多实例部署时需要使用共享存储
+type LocalStore struct {
+	Dir string
+}
+
+func (s *LocalStore) file(key string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(key)), nil
+}
+
+func (s *LocalStore) Create(key string) (io.WriteCloser, error) {
+	name, err := s.file(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0750); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0640)
+}
+
+func (s *LocalStore) Open(key string) (io.ReadCloser, error) {
+	name, err := s.file(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, ErrRecordingNotFound
+	}
+	return f, err
+}
+
+func (s *LocalStore) Delete(key string) error {
+	name, err := s.file(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// MemoryStore 对象存储的替代实现，和对象存储一样在 Close 时整体上传，重启后数据丢失，只用于开发和测试
+type MemoryStore struct {
+	mu      sync.RWMutex
+	objects map[string][]byte
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{objects: map[string][]byte{}}
+}
+
+func (s *MemoryStore) Create(key string) (io.WriteCloser, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	return &memoryObject{store: s, key: key}, nil
+}
+
+func (s *MemoryStore) Open(key string) (io.ReadCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, ErrRecordingNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	return nil
+}
+
+type memoryObject struct {
+	bytes.Buffer
+	store *MemoryStore
+	key   string
+}
+
+func (o *memoryObject) Close() error {
+	o.store.mu.Lock()
+	defer o.store.mu.Unlock()
+	o.store.objects[o.key] = o.Bytes()
+	return nil
+}
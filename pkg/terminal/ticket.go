@@ -0,0 +1,147 @@
+package terminal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	defaultTicketTTL = time.Minute
+	ticketPurpose    = "terminal"
+)
+
+var (
+	ErrInvalidTicket = errors.New("invalid terminal ticket")
+	ErrTicketExpired = errors.New("terminal ticket expired")
+	ErrTicketUsed    = errors.New("terminal ticket has already been used")
+)
+
+// Ticket 打开终端的凭证，绑定用户和要连接的容器，只能使用一次
+type Ticket struct {
+	SessionId string `json:"sid"`
+	User      string `json:"user"`
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	Command   string `json:"cmd,omitempty"`
+	// 节点终端：通过 chroot 进入节点的根文件系统，会话结束后删除 Pod
+	NodeShell bool `json:"nodeShell,omitempty"`
+	// 区分其他用途的 token，防止把其他 token 当作 ticket 使用
+	Purpose string `json:"purpose"`
+	jwt.StandardClaims
+}
+
+// Redeemer 记录已经使用的 ticket，ticket 已经被使用过时返回 false。
+// 多实例部署时需要使用共享的存储，否则同一个 ticket 可以在每个实例上各使用一次。
+type Redeemer interface {
+	Redeem(id string, expireTime time.Time) (bool, error)
+}
+
+// TicketIssuer 签发和验证 ticket，使用 HMAC-SHA256 签名
+type TicketIssuer struct {
+	key      []byte
+	ttl      time.Duration
+	redeemer Redeemer
+}
+
+// NewTicketIssuer 创建 TicketIssuer，ttl 为 0 时有效期为 1 分钟
+func NewTicketIssuer(key []byte, ttl time.Duration, redeemer Redeemer) *TicketIssuer {
+	if ttl <= 0 {
+		ttl = defaultTicketTTL
+	}
+	return &TicketIssuer{key: key, ttl: ttl, redeemer: redeemer}
+}
+
+// Issue 签发 ticket
+func (i *TicketIssuer) Issue(ticket Ticket) (string, error) {
+	id, err := randomId()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	ticket.Purpose = ticketPurpose
+	ticket.StandardClaims = jwt.StandardClaims{
+		Id:        id,
+		Subject:   ticket.User,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(i.ttl).Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, &ticket).SignedString(i.key)
+}
+
+// Redeem 验证 ticket 并标记为已使用
+func (i *TicketIssuer) Redeem(token string) (*Ticket, error) {
+	ticket := &Ticket{}
+	_, err := jwt.ParseWithClaims(token, ticket, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return i.key, nil
+	})
+	if err != nil {
+		var validationErr *jwt.ValidationError
+		if errors.As(err, &validationErr) && validationErr.Errors&jwt.ValidationErrorExpired != 0 {
+			return nil, ErrTicketExpired
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTicket, err)
+	}
+	if ticket.Purpose != ticketPurpose || ticket.Id == "" || ticket.ExpiresAt == 0 {
+		return nil, ErrInvalidTicket
+	}
+
+	ok, err := i.redeemer.Redeem(ticket.Id, time.Unix(ticket.ExpiresAt, 0))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTicketUsed
+	}
+	return ticket, nil
+}
+
+// MemoryRedeemer 在内存中记录已经使用的 ticket，只适用于单实例部署
+type MemoryRedeemer struct {
+	mu   sync.Mutex
+	used map[string]time.Time
+}
+
+func NewMemoryRedeemer() *MemoryRedeemer {
+	return &MemoryRedeemer{used: map[string]time.Time{}}
+}
+
+func (r *MemoryRedeemer) Redeem(id string, expireTime time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for usedId, t := range r.used {
+		if now.After(t) {
+			delete(r.used, usedId)
+		}
+	}
+	if _, ok := r.used[id]; ok {
+		return false, nil
+	}
+	r.used[id] = expireTime
+	return true, nil
+}
+
+// randomId 生成 128 位的随机 ID，用于 ticket 和会话 ID
+func randomId() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// NewSessionId 生成终端会话 ID
+func NewSessionId() (string, error) {
+	return randomId()
+}
@@ -0,0 +1,105 @@
+package terminal
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTicket(t *testing.T) {
+	issuer := NewTicketIssuer([]byte("secret"), time.Minute, NewMemoryRedeemer())
+	token, err := issuer.Issue(Ticket{SessionId: "s1", User: "alice", Cluster: "dev", Namespace: "default", Pod: "web-0", Container: "app", Command: "bash"})
+	if err != nil {
+		t.Fatalf("Issue error: %v", err)
+	}
+
+	ticket, err := issuer.Redeem(token)
+	if err != nil {
+		t.Fatalf("Redeem error: %v", err)
+	}
+	if ticket.User != "alice" || ticket.Pod != "web-0" || ticket.Container != "app" || ticket.Command != "bash" || ticket.SessionId != "s1" {
+		t.Errorf("unexpected ticket %+v", ticket)
+	}
+
+	// 只能使用一次
+	if _, err := issuer.Redeem(token); !errors.Is(err, ErrTicketUsed) {
+		t.Errorf("expected ErrTicketUsed, got %v", err)
+	}
+
+	// 其他密钥签发的 ticket 和被修改过的 ticket
+	other := NewTicketIssuer([]byte("other"), time.Minute, NewMemoryRedeemer())
+	forged, _ := other.Issue(Ticket{User: "admin"})
+	parts := strings.Split(token, ".")
+	tampered := parts[0] + "." + strings.TrimRight(parts[1], "=") + "x." + parts[2]
+	for _, token := range []string{forged, tampered, "", "abc"} {
+		if _, err := issuer.Redeem(token); !errors.Is(err, ErrInvalidTicket) {
+			t.Errorf("expected ErrInvalidTicket for %q, got %v", token, err)
+		}
+	}
+
+	// 相同密钥签发的其他用途的 token
+	login, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"aud": "alice", "exp": time.Now().Add(time.Hour).Unix(), "jti": "x"}).SignedString([]byte("secret"))
+	if _, err := issuer.Redeem(login); !errors.Is(err, ErrInvalidTicket) {
+		t.Errorf("expected ErrInvalidTicket for login token, got %v", err)
+	}
+}
+
+func TestTicketExpired(t *testing.T) {
+	issuer := NewTicketIssuer([]byte("secret"), time.Minute, NewMemoryRedeemer())
+	expired, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, &Ticket{
+		Purpose:        ticketPurpose,
+		StandardClaims: jwt.StandardClaims{Id: "x", ExpiresAt: time.Now().Add(-time.Second).Unix()},
+	}).SignedString([]byte("secret"))
+	if _, err := issuer.Redeem(expired); !errors.Is(err, ErrTicketExpired) {
+		t.Errorf("expected ErrTicketExpired, got %v", err)
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	policy := Policy{ProtectedNamespaces: ParseList(" kube-system, ,monitoring")}
+	if err := policy.Allow(false, "default"); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := policy.Allow(false, "kube-system"); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("expected ErrPermissionDenied, got %v", err)
+	}
+	if err := policy.Allow(true, "kube-system"); err != nil {
+		t.Errorf("admin should be allowed, got %v", err)
+	}
+	if err := (Policy{AdminOnly: true}).Allow(false, "default"); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("expected ErrPermissionDenied, got %v", err)
+	}
+
+	if ValidateCommand("") != nil || ValidateCommand("sh") != nil || ValidateCommand("rm -rf /") == nil {
+		t.Error("unexpected ValidateCommand result")
+	}
+}
+
+func TestValidateContainer(t *testing.T) {
+	running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "app", State: running},
+				{Name: "sidecar", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{}}},
+			},
+			EphemeralContainerStatuses: []corev1.ContainerStatus{{Name: "debugger", State: running}},
+		},
+	}
+	for container, ok := range map[string]bool{"app": true, "debugger": true, "sidecar": false, "missing": false} {
+		if err := ValidateContainer(pod, container); (err == nil) != ok {
+			t.Errorf("ValidateContainer(%s) = %v", container, err)
+		}
+	}
+	pod.Status.Phase = corev1.PodPending
+	if ValidateContainer(pod, "app") == nil {
+		t.Error("expected error for pending pod")
+	}
+}
@@ -65,6 +65,18 @@ func InitRouter() *gin.Engine {
 
 		// Kubernetes Event 路由
 		SetupKubernetesEventRoutes(apiV1)
+
+		// 定时任务路由
+		SetupScheduledJobRoutes(apiV1)
+
+		// 终端会话路由
+		SetupTerminalRoutes(apiV1)
+
+		// 端口转发路由
+		SetupPortForwardRoutes(apiV1)
+
+		// 审计日志路由
+		SetupAuditRoutes(apiV1)
 	}
 
 	return r
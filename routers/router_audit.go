@@ -0,0 +1,15 @@
+package routers
+
+import (
+	"github.com/JLPAY/gwayne/controllers/kubernetes/pod"
+	"github.com/JLPAY/gwayne/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupAuditRoutes(rg *gin.RouterGroup) {
+	// 定义 /api/v1/audit 路由，容器操作的审计日志，只有管理员可以查看
+	auditGroup := rg.Group("/audit").Use(middleware.JWTauth())
+	{
+		auditGroup.GET("/logs", pod.ListAuditLogs)
+	}
+}
@@ -33,8 +33,18 @@ func SetupKubernetesAppRoutes(rg *gin.RouterGroup) {
 		appGroup.GET("/pods/namespaces/:namespace/clusters/:cluster", pod.List)
 		// 容器终端
 		appGroup.POST("/pods/:pod/terminal/namespaces/:namespace/clusters/:cluster", pod.Terminal)
+		// 临时调试容器
+		appGroup.POST("/pods/:pod/debug/namespaces/:namespace/clusters/:cluster", pod.Debug)
+		// 容器文件上传下载
+		appGroup.POST("/pods/:pod/files/namespaces/:namespace/clusters/:cluster", pod.UploadFiles)
+		appGroup.GET("/pods/:pod/files/namespaces/:namespace/clusters/:cluster", pod.DownloadFile)
 
 		appGroup.GET("/podlogs/:pod/containers/:container/namespaces/:namespace/clusters/:cluster", pod.ListLogs)
+		// 实时日志流（SSE）
+		appGroup.GET("/podlogs/:pod/containers/:container/namespaces/:namespace/clusters/:cluster/stream", pod.StreamLogs)
+		// 日志下载和服务端搜索
+		appGroup.GET("/podlogs/:pod/containers/:container/namespaces/:namespace/clusters/:cluster/download", pod.DownloadLogs)
+		appGroup.GET("/podlogs/:pod/containers/:container/namespaces/:namespace/clusters/:cluster/search", pod.SearchLogs)
 		
 		// 诊断 Pod
 		appGroup.GET("/pods/namespaces/:namespace/clusters/:cluster/diagnose", pod.Diagnose)
@@ -55,5 +55,8 @@ func SetupKubernetesNodeRoutes(rg *gin.RouterGroup) {
 
 		// 诊断节点
 		nodeGroup.GET("/:name/clusters/:cluster/diagnose", node.Diagnose)
+
+		// 节点终端
+		nodeGroup.POST("/:name/clusters/:cluster/shell", node.Shell)
 	}
 }
@@ -22,7 +22,10 @@ func SetupKubernetesProxyResourcesRoutes(rg *gin.RouterGroup) {
 		proxyResourceGroup.GET("/:kind/:name", proxy.Get)
 		// update
 		proxyResourceGroup.PUT("/:kind/:name", proxy.Put)
+		proxyResourceGroup.PATCH("/:kind/:name", proxy.Patch)
 		proxyResourceGroup.DELETE("/:kind/:name", proxy.Delete)
+		// dry-run 后与线上对象比较
+		proxyResourceGroup.POST("/:kind/:name/diff", proxy.Diff)
 
 		// namespaces 为kind时，获取所有 namespaces 列表和详情
 		proxyResourceGroup.GET("/namespaces", proxy.NamespacesList)
@@ -56,9 +59,52 @@ func SetupKubernetesProxyResourcesRoutes(rg *gin.RouterGroup) {
 		proxyResourceGroup.POST("/namespaces/:namespaceName/:kind", proxy.Create)
 		proxyResourceGroup.GET("/namespaces/:namespaceName/:kind/:name", proxy.Get)
 		proxyResourceGroup.PUT("/namespaces/:namespaceName/:kind/:name", proxy.Put)
+		proxyResourceGroup.PATCH("/namespaces/:namespaceName/:kind/:name", proxy.Patch)
 		proxyResourceGroup.DELETE("/namespaces/:namespaceName/:kind/:name", proxy.Delete)
+		proxyResourceGroup.POST("/namespaces/:namespaceName/:kind/:name/diff", proxy.Diff)
+
+		// 按依赖顺序应用多文档清单
+		proxyResourceGroup.POST("/apply", proxy.Apply)
+
+		// 监听资源变化（Server-Sent Events）
+		proxyResourceGroup.GET("/watch/:kind", proxy.Watch)
+		proxyResourceGroup.GET("/watch/namespaces/:namespaceName/:kind", proxy.Watch)
+		proxyResourceGroup.GET("/watch/apis/:group/:version/:kind", proxy.Watch)
+		proxyResourceGroup.GET("/watch/apis/:group/:version/namespaces/:namespaceName/:kind", proxy.Watch)
 
 		// 重启功能路由
 		proxyResourceGroup.PUT("/namespaces/:namespaceName/:kind/:name/restart", proxy.RestartWorkload) // 使用polymorphichelpers的重启功能
+
+		// 发布历史、回滚、暂停/恢复和发布状态
+		proxyResourceGroup.GET("/namespaces/:namespaceName/:kind/:name/rollout/history", proxy.RolloutHistory)
+		proxyResourceGroup.GET("/namespaces/:namespaceName/:kind/:name/rollout/diff", proxy.RolloutDiff)
+		proxyResourceGroup.POST("/namespaces/:namespaceName/:kind/:name/rollout/rollback", proxy.RolloutRollback)
+		proxyResourceGroup.PUT("/namespaces/:namespaceName/:kind/:name/rollout/pause", proxy.RolloutPause)
+		proxyResourceGroup.PUT("/namespaces/:namespaceName/:kind/:name/rollout/resume", proxy.RolloutResume)
+		proxyResourceGroup.GET("/namespaces/:namespaceName/:kind/:name/rollout/status", proxy.RolloutStatus)
+
+		// scale 子资源，CRD 需要声明 scale 子资源
+		proxyResourceGroup.GET("/namespaces/:namespaceName/:kind/:name/scale", proxy.GetScale)
+		proxyResourceGroup.PUT("/namespaces/:namespaceName/:kind/:name/scale", proxy.UpdateScale)
+		proxyResourceGroup.GET("/apis/:group/:version/namespaces/:namespacesName/:kind/:name/scale", proxy.GetScale)
+		proxyResourceGroup.PUT("/apis/:group/:version/namespaces/:namespacesName/:kind/:name/scale", proxy.UpdateScale)
+		// 命名空间下的工作负载批量缩容到 0（park）或恢复原副本数（restore）
+		proxyResourceGroup.POST("/namespaces/:namespaceName/scale/:action", proxy.ScaleNamespace)
+
+		// CronJob 和 Job 操作
+		proxyResourceGroup.POST("/namespaces/:namespaceName/:kind/:name/trigger", proxy.TriggerCronJob)
+		proxyResourceGroup.PUT("/namespaces/:namespaceName/:kind/:name/suspend", proxy.SuspendCronJob)
+		proxyResourceGroup.PUT("/namespaces/:namespaceName/:kind/:name/resume", proxy.ResumeCronJob)
+		proxyResourceGroup.POST("/namespaces/:namespaceName/:kind/:name/rerun", proxy.RerunJob)
+		proxyResourceGroup.GET("/namespaces/:namespaceName/:kind/:name/jobs", proxy.ListCronJobJobs)
+		proxyResourceGroup.GET("/namespaces/:namespaceName/:kind/:name/logs", proxy.ListJobLogs)
+		// 工作负载所有 Pod 的聚合日志流（SSE）
+		proxyResourceGroup.GET("/namespaces/:namespaceName/:kind/:name/logs/stream", proxy.AggregateLogs)
+
+		// 工作负载关联的 Pod 和资源关系树
+		proxyResourceGroup.GET("/namespaces/:namespaceName/:kind/:name/pods", proxy.ListWorkloadPods)
+		proxyResourceGroup.GET("/namespaces/:namespaceName/:kind/:name/tree", proxy.WorkloadTree)
+		// 命名空间资源依赖图
+		proxyResourceGroup.GET("/namespaces/:namespaceName/topology", proxy.NamespaceTopology)
 	}
 }
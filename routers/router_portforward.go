@@ -0,0 +1,16 @@
+package routers
+
+import (
+	"github.com/JLPAY/gwayne/controllers/kubernetes/pod"
+	"github.com/JLPAY/gwayne/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupPortForwardRoutes(rg *gin.RouterGroup) {
+	// 定义 /api/v1/portforward 路由，WebSocket 连接，每个连接转发一个 TCP 连接
+	portForwardGroup := rg.Group("/portforward").Use(middleware.JWTauth())
+	{
+		portForwardGroup.GET("/pods/:pod/namespaces/:namespace/clusters/:cluster", pod.PortForward)
+		portForwardGroup.GET("/services/:service/namespaces/:namespace/clusters/:cluster", pod.ServicePortForward)
+	}
+}
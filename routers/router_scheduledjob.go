@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"github.com/JLPAY/gwayne/controllers/scheduledjob"
+	"github.com/JLPAY/gwayne/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupScheduledJobRoutes(rg *gin.RouterGroup) {
+	// 定义 /api/v1/scheduledjobs 路由
+	jobGroup := rg.Group("/scheduledjobs").Use(middleware.JWTauth())
+	{
+		jobGroup.GET("", scheduledjob.List)
+		jobGroup.POST("", scheduledjob.Create)
+		jobGroup.GET("/:id", scheduledjob.Get)
+		jobGroup.PUT("/:id", scheduledjob.Update)
+		jobGroup.DELETE("/:id", scheduledjob.Delete)
+		// 立即执行一次
+		jobGroup.POST("/:id/run", scheduledjob.Run)
+		// 执行记录
+		jobGroup.GET("/:id/executions", scheduledjob.Executions)
+	}
+}
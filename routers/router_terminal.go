@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"github.com/JLPAY/gwayne/controllers/kubernetes/pod"
+	"github.com/JLPAY/gwayne/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupTerminalRoutes(rg *gin.RouterGroup) {
+	// 定义 /api/v1/terminal 路由
+	terminalGroup := rg.Group("/terminal").Use(middleware.JWTauth())
+	{
+		// 终端会话录像和回放
+		terminalGroup.GET("/recordings", pod.ListRecordings)
+		terminalGroup.GET("/recordings/:id", pod.GetRecording)
+		terminalGroup.GET("/recordings/:id/cast", pod.ReplayRecording)
+
+		// 活动的终端会话，只有管理员可以查看、关闭和旁观
+		terminalGroup.GET("/sessions", pod.ListSessions)
+		terminalGroup.DELETE("/sessions/:id", pod.TerminateSession)
+		terminalGroup.GET("/sessions/:id/shadow", pod.ShadowSession)
+	}
+}